@@ -15,13 +15,16 @@
 package backend
 
 import (
+	"context"
 	"testing"
 
 	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +64,22 @@ func makeResourcePreEvent(urn, resType string, op display.StepOp, retainOnDelete
 
 	return event
 }
+
+// TestPreviewOnly tests that a preview-only operation is previewed without prompting, and is never performed.
+func TestPreviewOnly(t *testing.T) {
+	t.Parallel()
+
+	var dryRuns []bool
+	apply := func(ctx context.Context, kind apitype.UpdateKind, stack Stack, op UpdateOperation,
+		opts ApplierOptions, events chan<- engine.Event,
+	) (*deploy.Plan, display.ResourceChanges, result.Result) {
+		dryRuns = append(dryRuns, opts.DryRun)
+		return nil, display.ResourceChanges{deploy.OpUpdate: 1}, nil
+	}
+
+	op := UpdateOperation{Opts: UpdateOptions{PreviewOnly: true}}
+	changes, res := PreviewThenPromptThenExecute(context.Background(), apitype.RefreshUpdate, nil, op, apply)
+	assert.Nil(t, res)
+	assert.Equal(t, display.ResourceChanges{deploy.OpUpdate: 1}, changes)
+	assert.Equal(t, []bool{true}, dryRuns)
+}
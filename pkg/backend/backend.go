@@ -228,6 +228,34 @@ type SpecificDeploymentExporter interface {
 	ExportDeploymentForVersion(ctx context.Context, stack Stack, version string) (*apitype.UntypedDeployment, error)
 }
 
+// StackLock describes a lock held on a stack by an operation that is in progress.
+type StackLock struct {
+	// ID uniquely identifies the lock.
+	ID string
+	// Username is the name of the user that acquired the lock.
+	Username string
+	// Hostname is the name of the machine on which the lock was acquired.
+	Hostname string
+	// Pid is the ID of the process that acquired the lock.
+	Pid int
+	// Acquired is the time at which the lock was acquired.
+	Acquired time.Time
+	// Expires is the time at which the lock expires unless it is renewed, or the zero time if it never expires.
+	Expires time.Time
+	// StaleReason is empty if the lock is held by a live operation, and otherwise explains why the lock is stale.
+	StaleReason string
+}
+
+// StackLockManager is an interface defining an additional capability of a Backend, specifically the ability to
+// inspect and break the locks held on a stack. This isn't a requirement for all backends and should be checked for
+// dynamically.
+type StackLockManager interface {
+	// ListStackLocks returns the locks currently held on the given stack.
+	ListStackLocks(ctx context.Context, stackRef StackReference) ([]StackLock, error)
+	// BreakStackLock forcibly releases the lock with the given ID, recording who broke it and why.
+	BreakStackLock(ctx context.Context, stackRef StackReference, id string, reason string) error
+}
+
 // UpdateOperation is a complete stack update operation (preview, update, import, refresh, or destroy).
 type UpdateOperation struct {
 	Proj               *workspace.Project
@@ -269,6 +297,8 @@ type UpdateOptions struct {
 	AutoApprove bool
 	// SkipPreview, when true, causes the preview step to be skipped.
 	SkipPreview bool
+	// PreviewOnly, when true, causes only the preview step to be run, without prompting or performing the update.
+	PreviewOnly bool
 }
 
 // QueryOptions configures a query to operate against a backend and the engine.
@@ -29,6 +29,7 @@ import (
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -77,6 +78,8 @@ func ShowDiffEvents(op string, events <-chan engine.Event, done chan<- bool, opt
 				if payload.Severity == diag.Error || payload.Severity == diag.Warning {
 					out = stderr
 				}
+			} else if event.Type == engine.ResourceOperationRetry {
+				out = stderr
 			}
 
 			msg := RenderDiffEvent(event, seen, opts)
@@ -111,6 +114,8 @@ func RenderDiffEvent(event engine.Event, seen map[resource.URN]engine.StepEventM
 		// that the display is appropriate for both.
 	case engine.ResourceOperationFailed:
 		return renderDiffResourceOperationFailedEvent(event.Payload().(engine.ResourceOperationFailedPayload), opts)
+	case engine.ResourceOperationRetry:
+		return renderDiffDiagEvent(retryDiagEventPayload(event.Payload().(engine.ResourceOperationRetryPayload)), opts)
 	case engine.ResourceOutputsEvent:
 		return renderDiffResourceOutputsEvent(event.Payload().(engine.ResourceOutputsEventPayload), seen, opts)
 	case engine.ResourcePreEvent:
@@ -133,6 +138,19 @@ func renderDiffDiagEvent(payload engine.DiagEventPayload, opts Options) string {
 	return opts.Color.Colorize(payload.Prefix + payload.Message)
 }
 
+// retryDiagEventPayload renders a retry event as a warning about the resource that is being retried.
+func retryDiagEventPayload(payload engine.ResourceOperationRetryPayload) engine.DiagEventPayload {
+	return engine.DiagEventPayload{
+		URN:    payload.Metadata.URN,
+		Prefix: colors.SpecWarning + string(diag.Warning) + ": " + colors.Reset,
+		Message: fmt.Sprintf("%sretrying %v of '%v' after a transient error (attempt %d of %d, waiting %v): %s%s\n",
+			colors.SpecNote, payload.Metadata.Op, payload.Metadata.URN, payload.Attempt, payload.MaxAttempts,
+			payload.Delay, payload.Message, colors.Reset),
+		Color:    colors.Raw,
+		Severity: diag.Warning,
+	}
+}
+
 func renderDiffPolicyViolationEvent(payload engine.PolicyViolationEventPayload, opts Options) string {
 	return opts.Color.Colorize(payload.Prefix + payload.Message)
 }
@@ -220,6 +238,11 @@ func renderSummaryEvent(event engine.SummaryEventPayload, wroteDiagnosticHeader
 		fprintfIgnoreError(out, "\n")
 	}
 
+	// For previews, show the resources that would be affected by replacements and deletions.
+	if event.IsPreview {
+		renderBlastRadius(out, event.BlastRadius, opts)
+	}
+
 	// Print policy packs loaded. Data is rendered as a table of {policy-pack-name, version}.
 	renderPolicyPacks(out, event.PolicyPacks, opts)
 
@@ -237,6 +260,41 @@ func renderSummaryEvent(event engine.SummaryEventPayload, wroteDiagnosticHeader
 	return out.String()
 }
 
+// renderBlastRadius renders each resource that would be replaced or deleted, followed by the resources that depend on it
+// and would be changed too. Protected and retained resources, and replacements with many dependents, are flagged.
+func renderBlastRadius(out io.Writer, radius *display.BlastRadius, opts Options) {
+	if radius == nil || len(radius.Resources) == 0 {
+		return
+	}
+	fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("\n%sBlast radius:%s\n", colors.SpecHeadline, colors.Reset)))
+
+	flags := func(protect, retainOnDelete bool) string {
+		var s string
+		if protect {
+			s += fmt.Sprintf(" %s[protected]%s", colors.SpecAttention, colors.Reset)
+		}
+		if retainOnDelete {
+			s += fmt.Sprintf(" %s[retained on delete]%s", colors.SpecWarning, colors.Reset)
+		}
+		return s
+	}
+
+	for _, r := range radius.Resources {
+		line := fmt.Sprintf("    %s%s %s(%s, %s)%s", deploy.Prefix(r.Op, true /*done*/), r.URN, colors.Reset,
+			r.Op, english.Plural(len(r.Dependents), "dependent", ""), flags(r.Protect, r.RetainOnDelete))
+		if r.HighImpact {
+			line += fmt.Sprintf(" %s[high impact]%s", colors.SpecAttention, colors.Reset)
+		}
+		fprintIgnoreError(out, opts.Color.Colorize(line+"\n"))
+
+		for _, dep := range r.Dependents {
+			fprintIgnoreError(out, opts.Color.Colorize(fmt.Sprintf("        %s%s %s(%s)%s\n",
+				deploy.Prefix(dep.Op, true /*done*/), dep.URN, colors.Reset, dep.Op,
+				flags(dep.Protect, dep.RetainOnDelete))))
+		}
+	}
+}
+
 func renderPolicyPacks(out io.Writer, policyPacks map[string]string, opts Options) {
 	if len(policyPacks) == 0 {
 		return
@@ -10,8 +10,11 @@ import (
 	"testing"
 
 	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 
@@ -137,3 +140,32 @@ func TestDiffEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestRenderSummaryEventBlastRadius(t *testing.T) {
+	t.Parallel()
+
+	db := resource.URN("urn:pulumi:dev::project::pkg:index:typ::db")
+	app := resource.URN("urn:pulumi:dev::project::pkg:index:typ::app")
+	event := engine.SummaryEventPayload{
+		IsPreview:       true,
+		ResourceChanges: display.ResourceChanges{deploy.OpReplace: 1, deploy.OpUpdate: 1},
+		BlastRadius: &display.BlastRadius{
+			Replaces: 1,
+			Resources: []display.BlastRadiusResource{{
+				URN:        db,
+				Op:         deploy.OpReplace,
+				Dependents: []display.BlastRadiusDependent{{URN: app, Op: deploy.OpUpdate, Protect: true}},
+				HighImpact: true,
+			}},
+		},
+	}
+
+	out := renderSummaryEvent(event, false, Options{Color: colors.Never})
+	assert.Contains(t, out, "Blast radius:\n"+
+		"    +-"+string(db)+" (replace, 1 dependent) [high impact]\n"+
+		"        ~ "+string(app)+" (update) [protected]\n")
+
+	// Updates don't describe their blast radius.
+	event.IsPreview = false
+	assert.NotContains(t, renderSummaryEvent(event, false, Options{Color: colors.Never}), "Blast radius:")
+}
@@ -70,7 +70,9 @@ func ShowEvents(
 	streamPreview := cmdutil.IsTruthy(os.Getenv("PULUMI_ENABLE_STREAMING_JSON_PREVIEW"))
 
 	if opts.JSONDisplay {
-		if isPreview && !streamPreview {
+		if opts.Type == DisplayDrift {
+			ShowDriftDigest(events, done, opts)
+		} else if isPreview && !streamPreview {
 			ShowPreviewDigest(events, done, opts)
 		} else {
 			ShowJSONEvents(events, done, opts)
@@ -92,6 +94,8 @@ func ShowEvents(
 			"directly instead of through ShowEvents")
 	case DisplayWatch:
 		ShowWatchEvents(op, events, done, opts)
+	case DisplayDrift:
+		ShowDriftEvents(events, done, opts)
 	default:
 		contract.Failf("Unknown display type %d", opts.Type)
 	}
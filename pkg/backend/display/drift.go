@@ -0,0 +1,162 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dustin/go-humanize/english"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// driftResourceOf classifies the resource read by a refresh step, given the metadata of the step's outputs event. It
+// returns false if the resource can't drift, as is the case for component and provider resources.
+func driftResourceOf(m engine.StepEventMetadata) (display.DriftResource, bool) {
+	if m.Old == nil || !m.Old.Custom || providers.IsProviderType(m.Type) {
+		return display.DriftResource{}, false
+	}
+
+	res := display.DriftResource{URN: m.URN}
+	switch m.Op {
+	case deploy.OpDelete:
+		res.Status = display.DriftDeleted
+	case deploy.OpUpdate:
+		res.Status = display.DriftDrifted
+		diff := m.Old.Outputs.Diff(m.New.Outputs, resource.IsInternalPropertyKey)
+		res.DetailedDiff = make(map[string]display.PropertyDiff)
+		for k, v := range plugin.NewDetailedDiffFromObjectDiff(diff) {
+			res.DetailedDiff[k] = display.PropertyDiff{Kind: v.Kind.String()}
+		}
+	default:
+		res.Status = display.DriftInSync
+	}
+	return res, true
+}
+
+// ShowDriftEvents displays the result of a drift detection operation: the diagnostics issued while reading resources,
+// followed by the property-level diffs of the resources that drifted and a summary.
+func ShowDriftEvents(events <-chan engine.Event, done chan<- bool, opts Options) {
+	// Ensure we close the done channel before exiting.
+	defer func() { close(done) }()
+
+	stdout := opts.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	stderr := opts.Stderr
+	if stderr == nil {
+		stderr = os.Stderr
+	}
+
+	var drifted []engine.StepEventMetadata
+	inSync := 0
+	for e := range events {
+		if e.Type == engine.CancelEvent {
+			break
+		}
+
+		switch e.Type {
+		case engine.DiagEvent:
+			p := e.Payload().(engine.DiagEventPayload)
+			out := stdout
+			if p.Severity == diag.Error || p.Severity == diag.Warning {
+				out = stderr
+			}
+			fprintIgnoreError(out, renderDiffDiagEvent(p, opts))
+		case engine.ResourceOutputsEvent:
+			m := e.Payload().(engine.ResourceOutputsEventPayload).Metadata
+			if res, ok := driftResourceOf(m); ok {
+				if res.Status == display.DriftInSync {
+					inSync++
+				} else {
+					drifted = append(drifted, m)
+				}
+			}
+		}
+	}
+
+	if len(drifted) == 0 {
+		fprintfIgnoreError(stdout, opts.Color.Colorize(fmt.Sprintf("\n%sNo drift detected%s in %s.\n",
+			colors.SpecInfo, colors.Reset, english.Plural(inSync, "resource", ""))))
+		return
+	}
+
+	out := &bytes.Buffer{}
+	fprintfIgnoreError(out, "\n%sDrift detected%s in %d of %s:\n",
+		colors.SpecAttention, colors.Reset, len(drifted), english.Plural(len(drifted)+inSync, "resource", ""))
+	for _, m := range drifted {
+		fprintIgnoreError(out, "\n")
+		fprintIgnoreError(out, getResourcePropertiesSummary(m, 0))
+		if m.Op == deploy.OpDelete {
+			writeWithIndentNoPrefix(out, 1, m.Op, "deleted out-of-band\n")
+			continue
+		}
+		fprintIgnoreError(out, getResourceOutputsPropertiesString(m, 1, false /*planning*/, opts.Debug,
+			true /*refresh*/, false /*showSames*/))
+	}
+	fprintIgnoreError(stdout, opts.Color.Colorize(out.String()))
+}
+
+// ShowDriftDigest displays the result of a drift detection operation as a JSON display.DriftDigest.
+func ShowDriftDigest(events <-chan engine.Event, done chan<- bool, opts Options) {
+	// Ensure we close the done channel before exiting.
+	defer func() { close(done) }()
+
+	digest := display.DriftDigest{Resources: []display.DriftResource{}}
+	for e := range events {
+		if e.Type == engine.CancelEvent {
+			break
+		}
+
+		switch e.Type {
+		case engine.DiagEvent:
+			// Skip any ephemeral or debug messages, and elide all colorization.
+			p := e.Payload().(engine.DiagEventPayload)
+			if !p.Ephemeral && p.Severity != diag.Debug {
+				digest.Diagnostics = append(digest.Diagnostics, display.PreviewDiagnostic{
+					URN:      p.URN,
+					Message:  colors.Never.Colorize(p.Prefix + p.Message),
+					Severity: p.Severity,
+				})
+			}
+		case engine.ResourceOutputsEvent:
+			if res, ok := driftResourceOf(e.Payload().(engine.ResourceOutputsEventPayload).Metadata); ok {
+				digest.Resources = append(digest.Resources, res)
+			}
+		case engine.SummaryEvent:
+			digest.Duration = e.Payload().(engine.SummaryEventPayload).Duration
+		}
+	}
+
+	stdout := opts.Stdout
+	if stdout == nil {
+		stdout = os.Stdout
+	}
+	out, err := json.MarshalIndent(&digest, "", "    ")
+	contract.Assertf(err == nil, "unexpected JSON error: %v", err)
+	fprintfIgnoreError(stdout, "%s\n", out)
+}
@@ -0,0 +1,108 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// refreshOutputsEvent returns the outputs event of a refresh step that read the given outputs for a resource that
+// previously had the given outputs. A nil new map means that the resource was deleted.
+func refreshOutputsEvent(name string, op display.StepOp, olds, news resource.PropertyMap) engine.Event {
+	urn := resource.NewURN("dev", "project", "", "pkg:index:typ", tokens.QName(name))
+	state := func(outputs resource.PropertyMap) *engine.StepEventStateMetadata {
+		return &engine.StepEventStateMetadata{
+			Type:    urn.Type(),
+			URN:     urn,
+			Custom:  true,
+			ID:      resource.ID(name),
+			Outputs: outputs,
+		}
+	}
+	m := engine.StepEventMetadata{Op: op, URN: urn, Type: urn.Type(), Old: state(olds), Res: state(olds)}
+	if news != nil {
+		m.New = state(news)
+	}
+	return engine.NewEvent(engine.ResourceOutputsEvent, engine.ResourceOutputsEventPayload{Metadata: m})
+}
+
+func driftEvents() []engine.Event {
+	olds := resource.PropertyMap{"size": resource.NewNumberProperty(1), "name": resource.NewStringProperty("a")}
+	news := resource.PropertyMap{"size": resource.NewNumberProperty(2), "name": resource.NewStringProperty("a")}
+	return []engine.Event{
+		refreshOutputsEvent("same", deploy.OpSame, olds, olds),
+		refreshOutputsEvent("updated", deploy.OpUpdate, olds, news),
+		refreshOutputsEvent("deleted", deploy.OpDelete, olds, nil),
+		engine.NewEvent(engine.SummaryEvent, engine.SummaryEventPayload{IsPreview: true}),
+	}
+}
+
+func showDriftEvents(t *testing.T, events []engine.Event, opts Options) string {
+	var stdout bytes.Buffer
+	opts.Stdout, opts.Stderr = &stdout, &stdout
+	eventsC, done := make(chan engine.Event), make(chan bool)
+	go ShowEvents("refresh", "refresh", "dev", "project", "", eventsC, done, opts, true)
+	for _, e := range events {
+		eventsC <- e
+	}
+	close(eventsC)
+	<-done
+	return stdout.String()
+}
+
+func TestDriftEvents(t *testing.T) {
+	t.Parallel()
+
+	out := showDriftEvents(t, driftEvents(), Options{Color: colors.Never, Type: DisplayDrift})
+	assert.Contains(t, out, "Drift detected in 2 of 3 resources:")
+	assert.Contains(t, out, "[urn=urn:pulumi:dev::project::pkg:index:typ::updated]")
+	assert.Contains(t, out, "size: 1 => 2")
+	assert.NotContains(t, out, "name:")
+	assert.Contains(t, out, "deleted out-of-band")
+	assert.NotContains(t, out, "::same]")
+
+	out = showDriftEvents(t, driftEvents()[:1], Options{Color: colors.Never, Type: DisplayDrift})
+	assert.Contains(t, out, "No drift detected in 1 resource.")
+}
+
+func TestDriftDigest(t *testing.T) {
+	t.Parallel()
+
+	out := showDriftEvents(t, driftEvents(), Options{Color: colors.Never, Type: DisplayDrift, JSONDisplay: true})
+	var digest display.DriftDigest
+	require.NoError(t, json.Unmarshal([]byte(out), &digest))
+	assert.True(t, digest.HasDrift())
+	assert.Equal(t, []display.DriftResource{
+		{URN: "urn:pulumi:dev::project::pkg:index:typ::same", Status: display.DriftInSync},
+		{
+			URN:          "urn:pulumi:dev::project::pkg:index:typ::updated",
+			Status:       display.DriftDrifted,
+			DetailedDiff: map[string]display.PropertyDiff{"size": {Kind: "update"}},
+		},
+		{URN: "urn:pulumi:dev::project::pkg:index:typ::deleted", Status: display.DriftDeleted},
+	}, digest.Resources)
+}
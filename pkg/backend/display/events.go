@@ -143,6 +143,19 @@ func ConvertEngineEvent(e engine.Event, showSecrets bool) (apitype.EngineEvent,
 			Steps:    p.Steps,
 		}
 
+	case engine.ResourceOperationRetry:
+		p, ok := e.Payload().(engine.ResourceOperationRetryPayload)
+		if !ok {
+			return apiEvent, eventTypePayloadMismatch
+		}
+		apiEvent.ResOpRetryEvent = &apitype.ResOpRetryEvent{
+			Metadata:     convertStepEventMetadata(p.Metadata, showSecrets),
+			Attempt:      p.Attempt,
+			MaxAttempts:  p.MaxAttempts,
+			DelaySeconds: p.Delay.Seconds(),
+			Message:      p.Message,
+		}
+
 	default:
 		return apiEvent, fmt.Errorf("unknown event type %q", e.Type)
 	}
@@ -324,6 +337,16 @@ func ConvertJSONEvent(apiEvent apitype.EngineEvent) (engine.Event, error) {
 			Steps:    p.Steps,
 		})
 
+	case apiEvent.ResOpRetryEvent != nil:
+		p := apiEvent.ResOpRetryEvent
+		event = engine.NewEvent(engine.ResourceOperationRetry, engine.ResourceOperationRetryPayload{
+			Metadata:    convertJSONStepEventMetadata(p.Metadata),
+			Attempt:     p.Attempt,
+			MaxAttempts: p.MaxAttempts,
+			Delay:       time.Duration(p.DelaySeconds * float64(time.Second)),
+			Message:     p.Message,
+		})
+
 	default:
 		return event, errors.New("unknown event type")
 	}
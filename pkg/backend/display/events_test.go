@@ -2,9 +2,13 @@ package display
 
 import (
 	"testing"
+	"time"
 
 	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // This test checks that the ANSI control codes are removed from EngineEvents
@@ -24,3 +28,38 @@ func TestRemoveANSI(t *testing.T) {
 	assert.NoError(t, err, "unable to convert engine event")
 	assert.Equal(t, expected, res.DiagnosticEvent.Message)
 }
+
+func TestConvertRetryEvent(t *testing.T) {
+	t.Parallel()
+
+	payload := engine.ResourceOperationRetryPayload{
+		Metadata: engine.StepEventMetadata{
+			Op:   deploy.OpCreate,
+			URN:  "urn:pulumi:dev::proj::pkgA:m:typA::resA",
+			Type: "pkgA:m:typA",
+		},
+		Attempt:     2,
+		MaxAttempts: 3,
+		Delay:       1500 * time.Millisecond,
+		Message:     "throttled",
+	}
+
+	apiEvent, err := ConvertEngineEvent(engine.NewEvent(engine.ResourceOperationRetry, payload), false)
+	require.NoError(t, err)
+	require.NotNil(t, apiEvent.ResOpRetryEvent)
+	assert.Equal(t, 1.5, apiEvent.ResOpRetryEvent.DelaySeconds)
+
+	e, err := ConvertJSONEvent(apiEvent)
+	require.NoError(t, err)
+	require.Equal(t, engine.ResourceOperationRetry, e.Type)
+	roundTripped := e.Payload().(engine.ResourceOperationRetryPayload)
+	assert.Equal(t, payload.Metadata.URN, roundTripped.Metadata.URN)
+	assert.Equal(t, payload.Attempt, roundTripped.Attempt)
+	assert.Equal(t, payload.MaxAttempts, roundTripped.MaxAttempts)
+	assert.Equal(t, payload.Delay, roundTripped.Delay)
+	assert.Equal(t, payload.Message, roundTripped.Message)
+
+	msg := RenderDiffEvent(e, nil, Options{Color: colors.Never})
+	assert.Equal(t, "warning: retrying create of 'urn:pulumi:dev::proj::pkgA:m:typA::resA' after a transient error "+
+		"(attempt 2 of 3, waiting 1.5s): throttled\n", msg)
+}
@@ -195,9 +195,9 @@ func ShowPreviewDigest(events <-chan engine.Event, done chan<- bool, opts Option
 
 				digest.Steps = append(digest.Steps, step)
 			}
-		case engine.ResourceOutputsEvent, engine.ResourceOperationFailed:
+		case engine.ResourceOutputsEvent, engine.ResourceOperationFailed, engine.ResourceOperationRetry:
 		// Because we are only JSON serializing previews, we don't need to worry about outputs
-		// resolving or operations failing.
+		// resolving or operations failing or being retried.
 
 		// Events occurring late:
 		case engine.PolicyViolationEvent:
@@ -209,6 +209,7 @@ func ShowPreviewDigest(events <-chan engine.Event, done chan<- bool, opts Option
 			digest.Duration = p.Duration
 			digest.ChangeSummary = p.ResourceChanges
 			digest.MaybeCorrupt = p.MaybeCorrupt
+			digest.BlastRadius = p.BlastRadius
 		default:
 			contract.Failf("unknown event type '%s'", e.Type)
 		}
@@ -33,6 +33,8 @@ const (
 	DisplayQuery
 	// DisplayWatch displays watch output.
 	DisplayWatch
+	// DisplayDrift displays the drift detected by a refresh preview.
+	DisplayDrift
 )
 
 // Options controls how the output of events are rendered
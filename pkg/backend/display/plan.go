@@ -0,0 +1,287 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+// plannedOp returns the operation that sums up the operations planned for a resource: a replacement if the resource
+// is to be replaced, or else the first operation planned for it.
+func plannedOp(rp *deploy.ResourcePlan) display.StepOp {
+	for _, op := range rp.Ops {
+		if op == deploy.OpReplace {
+			return op
+		}
+	}
+	if len(rp.Ops) == 0 {
+		return deploy.OpSame
+	}
+	return rp.Ops[0]
+}
+
+// plannedChange is a change that a plan expects to make to a property of a resource.
+type plannedChange struct {
+	op    display.StepOp          // the kind of change: a create (add), update or delete.
+	value *resource.PropertyValue // the new value of the property, unless it is to be deleted.
+}
+
+// plannedChanges returns the changes that a resource plan expects to make to the inputs of its resource.
+func plannedChanges(rp *deploy.ResourcePlan) map[resource.PropertyKey]plannedChange {
+	changes := make(map[resource.PropertyKey]plannedChange)
+	if rp.Goal == nil {
+		return changes
+	}
+	for k, v := range rp.Goal.InputDiff.Adds {
+		v := v
+		changes[k] = plannedChange{op: deploy.OpCreate, value: &v}
+	}
+	for k, v := range rp.Goal.InputDiff.Updates {
+		v := v
+		changes[k] = plannedChange{op: deploy.OpUpdate, value: &v}
+	}
+	for _, k := range rp.Goal.InputDiff.Deletes {
+		changes[k] = plannedChange{op: deploy.OpDelete}
+	}
+	return changes
+}
+
+// sortedChangeKeys returns the keys of the given planned changes, sorted.
+func sortedChangeKeys(changes ...map[resource.PropertyKey]plannedChange) []resource.PropertyKey {
+	seen := make(map[resource.PropertyKey]bool)
+	var keys []resource.PropertyKey
+	for _, c := range changes {
+		for k := range c {
+			if !seen[k] && !resource.IsInternalPropertyKey(k) {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// renderPlannedValue renders a planned property value on a single line where possible.
+func renderPlannedValue(v resource.PropertyValue) string {
+	var b bytes.Buffer
+	p := propertyPrinter{dest: &b, planning: true, op: deploy.OpSame}
+	p.printPropertyValue(v)
+	return strings.TrimSuffix(colors.Never.Colorize(b.String()), "\n")
+}
+
+// String renders a planned change to a property, e.g. `+ "value"` for an added property.
+func (c plannedChange) String() string {
+	if c.value == nil {
+		return deploy.RawPrefix(c.op) + "<deleted>"
+	}
+	return deploy.RawPrefix(c.op) + renderPlannedValue(*c.value)
+}
+
+// renderConfigValue renders a plan's configuration value, hiding secrets.
+func renderConfigValue(v config.Value) string {
+	if v.Secure() {
+		return "[secret]"
+	}
+	s, err := v.Value(config.NopDecrypter)
+	if err != nil {
+		return "[unknown]"
+	}
+	return s
+}
+
+// sortedPlanURNs returns the URNs of the resources of the given plans, sorted.
+func sortedPlanURNs(plans ...*deploy.Plan) []resource.URN {
+	seen := make(map[resource.URN]bool)
+	var urns []resource.URN
+	for _, plan := range plans {
+		for urn := range plan.ResourcePlans {
+			if !seen[urn] {
+				seen[urn] = true
+				urns = append(urns, urn)
+			}
+		}
+	}
+	sort.Slice(urns, func(i, j int) bool { return urns[i] < urns[j] })
+	return urns
+}
+
+// sortedConfigKeys returns the configuration keys of the given plans, sorted.
+func sortedConfigKeys(plans ...*deploy.Plan) []config.Key {
+	seen := make(map[config.Key]bool)
+	var keys []config.Key
+	for _, plan := range plans {
+		for k := range plan.Config {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
+// RenderPlan renders a saved plan human-readably: the configuration it was made with, followed by the operations it
+// expects to perform on each resource and the changes it expects to make to each resource's inputs. Resources that
+// the plan leaves unchanged are only shown if opts.ShowSameResources is set.
+func RenderPlan(plan *deploy.Plan, opts Options) string {
+	out := &bytes.Buffer{}
+	fprintfIgnoreError(out, "Plan made at %s by version %s\n",
+		plan.Manifest.Time.Format("2006-01-02 15:04:05 MST"), plan.Manifest.Version)
+
+	if keys := sortedConfigKeys(plan); len(keys) > 0 {
+		fprintfIgnoreError(out, "\n%sConfiguration:%s\n", colors.SpecHeadline, colors.Reset)
+		for _, k := range keys {
+			fprintfIgnoreError(out, "    %s: %s\n", k, renderConfigValue(plan.Config[k]))
+		}
+	}
+
+	fprintfIgnoreError(out, "\n%sResources:%s\n", colors.SpecHeadline, colors.Reset)
+	counts := make(map[display.StepOp]int)
+	for _, urn := range sortedPlanURNs(plan) {
+		rp := plan.ResourcePlans[urn]
+		op := plannedOp(rp)
+		counts[op]++
+		if op == deploy.OpSame && !opts.ShowSameResources {
+			continue
+		}
+
+		ops := make([]string, len(rp.Ops))
+		for i, op := range rp.Ops {
+			ops[i] = string(op)
+		}
+		fprintfIgnoreError(out, "    %s%s %s(%s)%s\n", deploy.Prefix(op, true /*done*/), urn, colors.Reset,
+			strings.Join(ops, ", "), colors.Reset)
+
+		changes := plannedChanges(rp)
+		for _, k := range sortedChangeKeys(changes) {
+			c := changes[k]
+			if c.value == nil {
+				fprintfIgnoreError(out, "        %s%s%s%s\n", deploy.Color(c.op), deploy.RawPrefix(c.op), k, colors.Reset)
+			} else {
+				fprintfIgnoreError(out, "        %s%s%s: %s%s\n", deploy.Color(c.op), deploy.RawPrefix(c.op), k,
+					renderPlannedValue(*c.value), colors.Reset)
+			}
+		}
+	}
+
+	var summary []string
+	for _, op := range deploy.StepOps {
+		if c := counts[op]; c > 0 && op != deploy.OpSame {
+			summary = append(summary, fmt.Sprintf("%s%d to %s%s", deploy.Prefix(op, true /*done*/), c, op, colors.Reset))
+		}
+	}
+	if c := counts[deploy.OpSame]; c > 0 {
+		summary = append(summary, fmt.Sprintf("%d unchanged", c))
+	}
+	if len(summary) > 0 {
+		fprintfIgnoreError(out, "    %s\n", strings.Join(summary, ". "))
+	}
+
+	return opts.Color.Colorize(out.String())
+}
+
+// RenderPlanDiff renders the differences between two saved plans human-readably: the configuration values that
+// differ, the resources that only one of the plans expects to change, and the resources for which the plans expect
+// different operations or changes to their inputs. It returns an empty string if the plans are the same.
+func RenderPlanDiff(a, b *deploy.Plan, opts Options) string {
+	out := &bytes.Buffer{}
+
+	var configDiff bytes.Buffer
+	for _, k := range sortedConfigKeys(a, b) {
+		va, inA := a.Config[k]
+		vb, inB := b.Config[k]
+		switch {
+		case !inA:
+			fprintfIgnoreError(&configDiff, "    %s+ %s: %s%s\n", deploy.Color(deploy.OpCreate), k,
+				renderConfigValue(vb), colors.Reset)
+		case !inB:
+			fprintfIgnoreError(&configDiff, "    %s- %s: %s%s\n", deploy.Color(deploy.OpDelete), k,
+				renderConfigValue(va), colors.Reset)
+		case va != vb:
+			fprintfIgnoreError(&configDiff, "    %s~ %s: %s => %s%s\n", deploy.Color(deploy.OpUpdate), k,
+				renderConfigValue(va), renderConfigValue(vb), colors.Reset)
+		}
+	}
+	if configDiff.Len() > 0 {
+		fprintfIgnoreError(out, "%sConfiguration:%s\n", colors.SpecHeadline, colors.Reset)
+		fprintIgnoreError(out, configDiff.String())
+	}
+
+	var resourceDiff bytes.Buffer
+	for _, urn := range sortedPlanURNs(a, b) {
+		rpA, inA := a.ResourcePlans[urn]
+		rpB, inB := b.ResourcePlans[urn]
+		switch {
+		case !inA:
+			fprintfIgnoreError(&resourceDiff, "    %s+ %s: only in the second plan (%s)%s\n",
+				deploy.Color(deploy.OpCreate), urn, plannedOp(rpB), colors.Reset)
+			continue
+		case !inB:
+			fprintfIgnoreError(&resourceDiff, "    %s- %s: only in the first plan (%s)%s\n",
+				deploy.Color(deploy.OpDelete), urn, plannedOp(rpA), colors.Reset)
+			continue
+		}
+
+		var changeDiff bytes.Buffer
+		changesA, changesB := plannedChanges(rpA), plannedChanges(rpB)
+		for _, k := range sortedChangeKeys(changesA, changesB) {
+			ca, inA := changesA[k]
+			cb, inB := changesB[k]
+			switch {
+			case !inA:
+				fprintfIgnoreError(&changeDiff, "        %s+ %s: %s%s\n", deploy.Color(deploy.OpCreate), k, cb,
+					colors.Reset)
+			case !inB:
+				fprintfIgnoreError(&changeDiff, "        %s- %s: %s%s\n", deploy.Color(deploy.OpDelete), k, ca,
+					colors.Reset)
+			case ca.String() != cb.String():
+				fprintfIgnoreError(&changeDiff, "        %s~ %s: %s => %s%s\n", deploy.Color(deploy.OpUpdate), k, ca, cb,
+					colors.Reset)
+			}
+		}
+
+		opA, opB := plannedOp(rpA), plannedOp(rpB)
+		if opA == opB && changeDiff.Len() == 0 {
+			continue
+		}
+		if opA == opB {
+			fprintfIgnoreError(&resourceDiff, "    %s~ %s: %s%s\n", deploy.Color(deploy.OpUpdate), urn, opA, colors.Reset)
+		} else {
+			fprintfIgnoreError(&resourceDiff, "    %s~ %s: %s => %s%s\n", deploy.Color(deploy.OpUpdate), urn, opA, opB,
+				colors.Reset)
+		}
+		fprintIgnoreError(&resourceDiff, changeDiff.String())
+	}
+	if resourceDiff.Len() > 0 {
+		if out.Len() > 0 {
+			fprintIgnoreError(out, "\n")
+		}
+		fprintfIgnoreError(out, "%sResources:%s\n", colors.SpecHeadline, colors.Reset)
+		fprintIgnoreError(out, resourceDiff.String())
+	}
+
+	return opts.Color.Colorize(out.String())
+}
@@ -0,0 +1,127 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+)
+
+func newTestPlan(cfg config.Map, resourcePlans map[resource.URN]*deploy.ResourcePlan) *deploy.Plan {
+	return &deploy.Plan{
+		ResourcePlans: resourcePlans,
+		Manifest: deploy.Manifest{
+			Time:    time.Date(2023, 4, 26, 12, 0, 0, 0, time.UTC),
+			Version: "v3.65.0",
+		},
+		Config: cfg,
+	}
+}
+
+func newTestResourcePlan(inputDiff deploy.PlanDiff, ops ...display.StepOp) *deploy.ResourcePlan {
+	return &deploy.ResourcePlan{Goal: &deploy.GoalPlan{InputDiff: inputDiff}, Ops: ops}
+}
+
+func TestRenderPlan(t *testing.T) {
+	t.Parallel()
+
+	plan := newTestPlan(config.Map{
+		config.MustMakeKey("proj", "region"):   config.NewValue("us-west-2"),
+		config.MustMakeKey("proj", "password"): config.NewSecureValue("c2VjcmV0"),
+	}, map[resource.URN]*deploy.ResourcePlan{
+		"urn:pulumi:stack::proj::pkgA:m:typA::db": newTestResourcePlan(deploy.PlanDiff{
+			Updates: resource.PropertyMap{"size": resource.NewNumberProperty(2)},
+		}, deploy.OpCreateReplacement, deploy.OpReplace, deploy.OpDeleteReplaced),
+		"urn:pulumi:stack::proj::pkgA:m:typA::app": newTestResourcePlan(deploy.PlanDiff{
+			Adds:    resource.PropertyMap{"name": resource.NewStringProperty("app")},
+			Deletes: []resource.PropertyKey{"debug"},
+		}, deploy.OpUpdate),
+		"urn:pulumi:stack::proj::pkgA:m:typA::log": newTestResourcePlan(deploy.PlanDiff{}, deploy.OpSame),
+		"urn:pulumi:stack::proj::pkgA:m:typA::old": {Ops: []display.StepOp{deploy.OpDelete}},
+	})
+
+	expected := `Plan made at 2023-04-26 12:00:00 UTC by version v3.65.0
+
+Configuration:
+    proj:password: [secret]
+    proj:region: us-west-2
+
+Resources:
+    ~ urn:pulumi:stack::proj::pkgA:m:typA::app (update)
+        - debug
+        + name: "app"
+    +-urn:pulumi:stack::proj::pkgA:m:typA::db (create-replacement, replace, delete-replaced)
+        ~ size: 2
+    - urn:pulumi:stack::proj::pkgA:m:typA::old (delete)
+    ~ 1 to update. - 1 to delete. +-1 to replace. 1 unchanged
+`
+	assert.Equal(t, expected, RenderPlan(plan, Options{Color: colors.Never}))
+}
+
+func TestRenderPlanDiff(t *testing.T) {
+	t.Parallel()
+
+	a := newTestPlan(config.Map{
+		config.MustMakeKey("proj", "region"): config.NewValue("us-west-2"),
+		config.MustMakeKey("proj", "debug"):  config.NewValue("true"),
+	}, map[resource.URN]*deploy.ResourcePlan{
+		"urn:pulumi:stack::proj::pkgA:m:typA::app": newTestResourcePlan(deploy.PlanDiff{
+			Updates: resource.PropertyMap{"size": resource.NewNumberProperty(2)},
+		}, deploy.OpUpdate),
+		"urn:pulumi:stack::proj::pkgA:m:typA::db": newTestResourcePlan(deploy.PlanDiff{
+			Updates: resource.PropertyMap{"engine": resource.NewStringProperty("postgres")},
+		}, deploy.OpUpdate),
+		"urn:pulumi:stack::proj::pkgA:m:typA::log": newTestResourcePlan(deploy.PlanDiff{}, deploy.OpSame),
+		"urn:pulumi:stack::proj::pkgA:m:typA::old": {Ops: []display.StepOp{deploy.OpDelete}},
+	})
+	b := newTestPlan(config.Map{
+		config.MustMakeKey("proj", "region"): config.NewValue("eu-west-1"),
+		config.MustMakeKey("proj", "size"):   config.NewValue("large"),
+	}, map[resource.URN]*deploy.ResourcePlan{
+		"urn:pulumi:stack::proj::pkgA:m:typA::app": newTestResourcePlan(deploy.PlanDiff{
+			Updates: resource.PropertyMap{"size": resource.NewNumberProperty(4)},
+			Adds:    resource.PropertyMap{"name": resource.NewStringProperty("app")},
+		}, deploy.OpUpdate),
+		"urn:pulumi:stack::proj::pkgA:m:typA::db": newTestResourcePlan(deploy.PlanDiff{
+			Updates: resource.PropertyMap{"engine": resource.NewStringProperty("postgres")},
+		}, deploy.OpCreateReplacement, deploy.OpReplace, deploy.OpDeleteReplaced),
+		"urn:pulumi:stack::proj::pkgA:m:typA::log": newTestResourcePlan(deploy.PlanDiff{}, deploy.OpSame),
+		"urn:pulumi:stack::proj::pkgA:m:typA::new": newTestResourcePlan(deploy.PlanDiff{}, deploy.OpCreate),
+	})
+
+	expected := `Configuration:
+    - proj:debug: true
+    ~ proj:region: us-west-2 => eu-west-1
+    + proj:size: large
+
+Resources:
+    ~ urn:pulumi:stack::proj::pkgA:m:typA::app: update
+        + name: + "app"
+        ~ size: ~ 2 => ~ 4
+    ~ urn:pulumi:stack::proj::pkgA:m:typA::db: update => replace
+    + urn:pulumi:stack::proj::pkgA:m:typA::new: only in the second plan (create)
+    - urn:pulumi:stack::proj::pkgA:m:typA::old: only in the first plan (delete)
+`
+	assert.Equal(t, expected, RenderPlanDiff(a, b, Options{Color: colors.Never}))
+	assert.Empty(t, RenderPlanDiff(a, a, Options{Color: colors.Never}))
+}
@@ -765,6 +765,11 @@ func (display *ProgressDisplay) processNormalEvent(event engine.Event) {
 		if msg == "" {
 			return
 		}
+	case engine.ResourceOperationRetry:
+		// Retries are shown as warnings about the resource that is being retried.
+		payload := event.Payload().(engine.ResourceOperationRetryPayload)
+		display.processNormalEvent(engine.NewEvent(engine.DiagEvent, retryDiagEventPayload(payload)))
+		return
 	case engine.StdoutColorEvent:
 		display.handleSystemEvent(event.Payload().(engine.StdoutEventPayload))
 		return
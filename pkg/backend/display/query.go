@@ -87,7 +87,7 @@ func renderQueryEvent(event engine.Event, opts Options) string {
 	case engine.DiagEvent:
 		return renderQueryDiagEvent(event.Payload().(engine.DiagEventPayload), opts)
 
-	case engine.PreludeEvent, engine.SummaryEvent, engine.ResourceOperationFailed,
+	case engine.PreludeEvent, engine.SummaryEvent, engine.ResourceOperationFailed, engine.ResourceOperationRetry,
 		engine.ResourceOutputsEvent, engine.ResourcePreEvent:
 
 		contract.Failf("query mode does not support resource operations")
@@ -79,6 +79,10 @@ func ShowWatchEvents(op string, events <-chan engine.Event, done chan<- bool, op
 				PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
 					"failed %s %s\n", p.Metadata.Op, p.Metadata.URN.Type())
 			}
+		case engine.ResourceOperationRetry:
+			p := e.Payload().(engine.ResourceOperationRetryPayload)
+			PrintfWithWatchPrefix(time.Now(), string(p.Metadata.URN.Name()),
+				"%s", renderDiffDiagEvent(retryDiagEventPayload(p), opts))
 		default:
 			contract.Failf("unknown event type '%s'", e.Type)
 		}
@@ -24,6 +24,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -59,6 +60,7 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
@@ -88,6 +90,25 @@ var (
 	//
 	// This opt-out is intended to be removed in a future release.
 	PulumiFilestateLegacyLayoutEnvVar = env.SelfManagedStateLegacyLayout.Var().Name()
+
+	// PulumiFilestateLockTTLEnvVar is the name of an environment variable
+	// that sets how long a stack lock may go without being renewed
+	// before other processes consider it stale.
+	PulumiFilestateLockTTLEnvVar = env.SelfManagedStateLockTTL.Var().Name()
+
+	// PulumiFilestateJournalEnvVar is an env var that must be truthy
+	// to write checkpoints as a journal of delta records during updates.
+	PulumiFilestateJournalEnvVar = env.SelfManagedStateJournal.Var().Name()
+
+	// PulumiFilestateJournalCompactionEnvVar is the name of an environment variable
+	// that sets how many delta records are written to a checkpoint journal
+	// before it is compacted into a full checkpoint.
+	PulumiFilestateJournalCompactionEnvVar = env.SelfManagedStateJournalCompaction.Var().Name()
+
+	// PulumiFilestateEncryptEnvVar is an env var that must be truthy
+	// to encrypt whole checkpoints, history files and journal records
+	// with the stack's secrets manager.
+	PulumiFilestateEncryptEnvVar = env.SelfManagedStateEncrypt.Var().Name()
 )
 
 // Backend extends the base backend interface with specific information about local backends.
@@ -113,8 +134,33 @@ type localBackend struct {
 
 	lockID string
 
+	// lockTTL is how long a lock may go without being renewed before it is considered stale, or zero if locks never
+	// expire. Locks held by this backend are renewed by a heartbeat until they are released.
+	lockTTL time.Duration
+
+	// heartbeats holds a function to stop the heartbeat of each lock held by this backend, keyed by lock path.
+	heartbeats     map[string]func()
+	heartbeatsLock sync.Mutex
+
+	// revisions holds the revision of each stack's checkpoint as it was last loaded or saved by this backend, keyed
+	// by fully qualified stack name. Saving a checkpoint that has been modified since fails.
+	revisions     map[string]checkpointRevision
+	revisionsLock sync.Mutex
+
 	gzip bool
 
+	// journalCompaction is the number of delta records written to a stack's checkpoint journal before it is
+	// compacted into a full checkpoint, or zero if checkpoints are not journaled.
+	journalCompaction int
+
+	// encrypt is true if checkpoints, history files and journal records are encrypted with the stack's secrets
+	// manager when they are written.
+	encrypt bool
+
+	// secretsManagers caches the secrets managers used to encrypt and decrypt state, keyed by type and state.
+	secretsManagers     map[string]secrets.Manager
+	secretsManagersLock sync.Mutex
+
 	Getenv func(string) string // == os.Getenv
 
 	// The current project, if any.
@@ -127,6 +173,12 @@ type localBackend struct {
 	store referenceStore
 }
 
+// Assert we implement the backend.SpecificDeploymentExporter interface.
+var _ backend.SpecificDeploymentExporter = (*localBackend)(nil)
+
+// Assert we implement the backend.StackLockManager interface.
+var _ backend.StackLockManager = (*localBackend)(nil)
+
 type localBackendReference struct {
 	name    tokens.Name
 	project tokens.Name
@@ -190,6 +242,9 @@ func IsFileStateBackendURL(urlstr string) bool {
 		return false
 	}
 
+	if _, ok := lookupStorageDriver(u.Scheme); ok {
+		return true
+	}
 	return blob.DefaultURLMux().ValidBucketScheme(u.Scheme)
 }
 
@@ -197,8 +252,8 @@ const FilePathPrefix = "file://"
 
 // New constructs a new filestate backend,
 // using the given URL as the root for storage.
-// The URL must use one of the schemes supported by the go-cloud blob package.
-// Thes inclue: file, s3, gs, azblob.
+// The URL must use one of the schemes supported by the go-cloud blob package,
+// which include file, s3, gs and azblob, or a scheme registered with RegisterStorageDriver.
 func New(ctx context.Context, d diag.Sink, originalURL string, project *workspace.Project) (Backend, error) {
 	return newLocalBackend(ctx, d, originalURL, project, nil)
 }
@@ -225,7 +280,7 @@ func newLocalBackend(
 
 	if !IsFileStateBackendURL(originalURL) {
 		return nil, fmt.Errorf("local URL %s has an illegal prefix; expected one of: %s",
-			originalURL, strings.Join(blob.DefaultURLMux().BucketSchemes(), ", "))
+			originalURL, strings.Join(supportedSchemes(), ", "))
 	}
 
 	u, err := massageBlobPath(originalURL)
@@ -238,23 +293,30 @@ func newLocalBackend(
 		return nil, err
 	}
 
-	blobmux := blob.DefaultURLMux()
+	var bucket *blob.Bucket
+	driver, isDriver := lookupStorageDriver(p.Scheme)
+	if isDriver {
+		// The rest of a storage driver's URL is entirely up to the driver.
+		bucket, err = driver.OpenBucketURL(ctx, p)
+	} else {
+		blobmux := blob.DefaultURLMux()
 
-	// for gcp we want to support additional credentials
-	// schemes on top of go-cloud's default credentials mux.
-	if p.Scheme == gcsblob.Scheme {
-		blobmux, err = authhelpers.GoogleCredentialsMux(ctx)
-		if err != nil {
-			return nil, err
+		// for gcp we want to support additional credentials
+		// schemes on top of go-cloud's default credentials mux.
+		if p.Scheme == gcsblob.Scheme {
+			blobmux, err = authhelpers.GoogleCredentialsMux(ctx)
+			if err != nil {
+				return nil, err
+			}
 		}
-	}
 
-	bucket, err := blobmux.OpenBucket(ctx, u)
+		bucket, err = blobmux.OpenBucket(ctx, u)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("unable to open bucket %s: %w", u, err)
 	}
 
-	if !strings.HasPrefix(u, FilePathPrefix) {
+	if !isDriver && !strings.HasPrefix(u, FilePathPrefix) {
 		bucketSubDir := strings.TrimLeft(p.Path, "/")
 		if bucketSubDir != "" {
 			if !strings.HasSuffix(bucketSubDir, "/") {
@@ -272,18 +334,44 @@ func newLocalBackend(
 	}
 
 	gzipCompression := cmdutil.IsTruthy(opts.Getenv(PulumiFilestateGzipEnvVar))
+	encrypt := cmdutil.IsTruthy(opts.Getenv(PulumiFilestateEncryptEnvVar))
+
+	lockTTL := defaultLockTTL
+	if v := opts.Getenv(PulumiFilestateLockTTLEnvVar); v != "" {
+		if lockTTL, err = time.ParseDuration(v); err != nil || lockTTL < 0 {
+			return nil, fmt.Errorf("invalid value %q for %s: expected a non-negative duration such as 5m",
+				v, PulumiFilestateLockTTLEnvVar)
+		}
+	}
+
+	var journalCompaction int
+	if cmdutil.IsTruthy(opts.Getenv(PulumiFilestateJournalEnvVar)) {
+		journalCompaction = defaultJournalCompaction
+		if v := opts.Getenv(PulumiFilestateJournalCompactionEnvVar); v != "" {
+			if journalCompaction, err = strconv.Atoi(v); err != nil || journalCompaction < 1 {
+				return nil, fmt.Errorf("invalid value %q for %s: expected a positive number of records",
+					v, PulumiFilestateJournalCompactionEnvVar)
+			}
+		}
+	}
 
 	wbucket := &wrappedBucket{bucket: bucket}
 	bucket = nil // prevent accidental use of unwrapped bucket
 
 	backend := &localBackend{
-		d:           d,
-		originalURL: originalURL,
-		url:         u,
-		bucket:      wbucket,
-		lockID:      lockID.String(),
-		gzip:        gzipCompression,
-		Getenv:      opts.Getenv,
+		d:                 d,
+		originalURL:       originalURL,
+		url:               u,
+		bucket:            wbucket,
+		lockID:            lockID.String(),
+		lockTTL:           lockTTL,
+		heartbeats:        make(map[string]func()),
+		revisions:         make(map[string]checkpointRevision),
+		gzip:              gzipCompression,
+		journalCompaction: journalCompaction,
+		encrypt:           encrypt,
+		secretsManagers:   make(map[string]secrets.Manager),
+		Getenv:            opts.Getenv,
 	}
 	backend.currentProject.Store(project)
 
@@ -698,7 +786,14 @@ func (b *localBackend) ListStacks(
 	for _, stackRef := range stacks {
 		chk, err := b.getCheckpoint(ctx, stackRef)
 		if err != nil {
-			return nil, nil, err
+			// Like stacks whose secrets can't be decrypted, stacks whose state can't be decrypted are still listed,
+			// just without the details of their last update.
+			var decErr *stateDecryptionError
+			if !errors.As(err, &decErr) {
+				return nil, nil, err
+			}
+			logging.V(5).Infof("listing stack %s without its checkpoint: %v", stackRef, err)
+			chk = nil
 		}
 		results = append(results, newLocalStackSummary(stackRef, chk))
 	}
@@ -792,6 +887,10 @@ func (b *localBackend) renameStack(ctx context.Context, oldRef *localBackendRefe
 	// To remove the old stack, just make a backup of the file and don't write out anything new.
 	file := b.stackPath(ctx, oldRef)
 	backupTarget(ctx, b.bucket, file, false)
+	b.observeCheckpoint(oldRef.FullyQualifiedName().String(), nil)
+	if err = b.removeJournal(ctx, oldRef); err != nil {
+		return err
+	}
 
 	// And rename the history folder as well.
 	if err = b.renameHistory(ctx, oldRef, newRef); err != nil {
@@ -1012,7 +1111,13 @@ func (b *localBackend) apply(
 	var saveErr error
 	var backupErr error
 	if !opts.DryRun {
-		saveErr = b.addToHistory(ctx, localStackRef, info)
+		// Compact any journaled snapshots first, so that the history and backup get the whole checkpoint.
+		if journal, ok := persister.(*localJournalPersister); ok {
+			saveErr = journal.compact()
+		}
+		if saveErr == nil {
+			saveErr = b.addToHistory(ctx, localStackRef, info)
+		}
 		backupErr = b.backupStack(ctx, localStackRef)
 	}
 
@@ -1041,7 +1146,10 @@ func (b *localBackend) apply(
 			link = u.String()
 		} else {
 			link, err = b.bucket.SignedURL(ctx, b.stackPath(ctx, localStackRef), nil)
-			if err != nil {
+			if gcerrors.Code(err) == gcerrors.Unimplemented {
+				// Some storage drivers have no way to link to the checkpoint.
+				link = ""
+			} else if err != nil {
 				// set link to be empty to when there is an error to hide use of Permalinks
 				link = ""
 
@@ -1154,6 +1262,42 @@ func (b *localBackend) ExportDeployment(ctx context.Context,
 	}, nil
 }
 
+func (b *localBackend) ExportDeploymentForVersion(
+	ctx context.Context, stk backend.Stack, version string,
+) (*apitype.UntypedDeployment, error) {
+	// Versions are positive integers that number the updates in the stack's history, starting from 1.
+	versionNumber, err := strconv.Atoi(version)
+	if err != nil || versionNumber <= 0 {
+		return nil, fmt.Errorf(
+			"%q is not a valid stack version. It should be a positive integer",
+			version)
+	}
+
+	localStackRef, err := b.getReference(stk.Ref())
+	if err != nil {
+		return nil, err
+	}
+
+	chk, err := b.getHistoricalCheckpoint(ctx, localStackRef, versionNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	latest := chk.Latest
+	if latest == nil {
+		latest = &apitype.DeploymentV3{}
+	}
+	data, err := encoding.JSON.Marshal(latest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apitype.UntypedDeployment{
+		Version:    3,
+		Deployment: json.RawMessage(data),
+	}, nil
+}
+
 func (b *localBackend) ImportDeployment(ctx context.Context, stk backend.Stack,
 	deployment *apitype.UntypedDeployment,
 ) error {
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
@@ -299,6 +300,218 @@ func TestCancel(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestStaleLocks(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb := b.(*localBackend)
+
+	stackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, stackRef, "", nil)
+	require.NoError(t, err)
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+	writeLock := func(id string, content lockContent) string {
+		bytes, err := json.Marshal(content)
+		require.NoError(t, err)
+		lockPath := path.Join(stackLockDir(stackRef.FullyQualifiedName()), id+".json")
+		require.NoError(t, lb.bucket.WriteAll(ctx, lockPath, bytes, nil))
+		return lockPath
+	}
+
+	// A lock held by a live process that has not expired blocks the stack.
+	live := writeLock("live", lockContent{
+		Pid:       os.Getpid(),
+		Hostname:  hostname,
+		Timestamp: time.Now(),
+		Expires:   time.Now().Add(time.Hour),
+	})
+	assert.ErrorContains(t, lb.checkForLock(ctx, stackRef), "locked by 1 lock(s)")
+	require.NoError(t, lb.bucket.Delete(ctx, live))
+
+	// Expired locks, and locks held by processes on this machine that have exited, are removed.
+	expired := writeLock("expired", lockContent{
+		Pid:       os.Getpid(),
+		Hostname:  "elsewhere",
+		Timestamp: time.Now().Add(-time.Hour),
+		Expires:   time.Now().Add(-time.Minute),
+	})
+	cmd := exec.Command("go", "version")
+	require.NoError(t, cmd.Run())
+	dead := writeLock("dead", lockContent{
+		Pid:       cmd.Process.Pid,
+		Hostname:  hostname,
+		Timestamp: time.Now(),
+	})
+
+	locks, err := lb.ListStackLocks(ctx, stackRef)
+	require.NoError(t, err)
+	require.Len(t, locks, 2)
+	for _, l := range locks {
+		assert.NotEmpty(t, l.StaleReason, "lock %v", l.ID)
+	}
+
+	require.NoError(t, lb.Lock(ctx, stackRef))
+	defer lb.Unlock(ctx, stackRef)
+	for _, lockPath := range []string{expired, dead} {
+		exists, err := lb.bucket.Exists(ctx, lockPath)
+		require.NoError(t, err)
+		assert.False(t, exists, "lock %v", lockPath)
+	}
+}
+
+func TestLockHeartbeat(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil,
+		&localBackendOptions{
+			Getenv: mapGetenv(map[string]string{
+				"PULUMI_SELF_MANAGED_STATE_LOCK_TTL": "300ms",
+			}),
+		},
+	)
+	require.NoError(t, err)
+
+	stackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, stackRef, "", nil)
+	require.NoError(t, err)
+
+	readLock := func() lockContent {
+		bytes, err := b.bucket.ReadAll(ctx, b.lockPath(stackRef))
+		require.NoError(t, err)
+		var l lockContent
+		require.NoError(t, json.Unmarshal(bytes, &l))
+		return l
+	}
+
+	require.NoError(t, b.Lock(ctx, stackRef))
+	first := readLock()
+	assert.False(t, first.Expires.IsZero())
+
+	// The lock is renewed while it is held, so it never appears stale to other processes.
+	assert.Eventually(t, func() bool {
+		return readLock().Expires.After(first.Expires)
+	}, 5*time.Second, 50*time.Millisecond)
+	renewed := readLock()
+	assert.Empty(t, renewed.staleReason(time.Now()))
+
+	b.Unlock(ctx, stackRef)
+	exists, err := b.bucket.Exists(ctx, b.lockPath(stackRef))
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestRenewLock(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()), nil, nil)
+	require.NoError(t, err)
+
+	stackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, stackRef, "", nil)
+	require.NoError(t, err)
+
+	lockPath := b.lockPath(stackRef)
+	content, err := newLockContent(time.Minute)
+	require.NoError(t, err)
+	writeLock := func(l *lockContent) {
+		bytes, err := json.Marshal(l)
+		require.NoError(t, err)
+		require.NoError(t, b.bucket.WriteAll(ctx, lockPath, bytes, nil))
+	}
+
+	writeLock(content)
+	previous := content.Expires
+	assert.True(t, b.renewLock(ctx, lockPath, content))
+	assert.True(t, content.Expires.After(previous))
+
+	// A lock that was broken is not written back.
+	require.NoError(t, b.bucket.Delete(ctx, lockPath))
+	assert.False(t, b.renewLock(ctx, lockPath, content))
+	exists, err := b.bucket.Exists(ctx, lockPath)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	// Nor is a lock that was replaced by a different acquisition.
+	replacement := *content
+	replacement.Timestamp = content.Timestamp.Add(time.Second)
+	writeLock(&replacement)
+	assert.False(t, b.renewLock(ctx, lockPath, content))
+	bytes, err := b.bucket.ReadAll(ctx, lockPath)
+	require.NoError(t, err)
+	var stored lockContent
+	require.NoError(t, json.Unmarshal(bytes, &stored))
+	assert.True(t, stored.Timestamp.Equal(replacement.Timestamp))
+}
+
+func TestInvalidLockTTL(t *testing.T) {
+	t.Parallel()
+
+	_, err := newLocalBackend(context.Background(), diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()), nil,
+		&localBackendOptions{
+			Getenv: mapGetenv(map[string]string{
+				"PULUMI_SELF_MANAGED_STATE_LOCK_TTL": "soon",
+			}),
+		},
+	)
+	assert.ErrorContains(t, err, "PULUMI_SELF_MANAGED_STATE_LOCK_TTL")
+}
+
+func TestBreakStackLock(t *testing.T) {
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	lb := b.(*localBackend)
+
+	stackRef, err := b.ParseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, stackRef, "", nil)
+	require.NoError(t, err)
+
+	// Lock the stack from another backend, which has a different lock ID.
+	ob, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(tmpDir), nil)
+	require.NoError(t, err)
+	other := ob.(*localBackend)
+	require.NoError(t, other.Lock(ctx, stackRef))
+	defer other.stopLockHeartbeat(stackRef)
+
+	locks, err := lb.ListStackLocks(ctx, stackRef)
+	require.NoError(t, err)
+	require.Len(t, locks, 1)
+	assert.Equal(t, other.lockID, locks[0].ID)
+	assert.Equal(t, os.Getpid(), locks[0].Pid)
+	assert.Empty(t, locks[0].StaleReason)
+
+	assert.ErrorContains(t, lb.BreakStackLock(ctx, stackRef, "missing", "testing"), "no lock with ID missing")
+	require.NoError(t, lb.BreakStackLock(ctx, stackRef, other.lockID, "testing"))
+	assert.NoError(t, lb.checkForLock(ctx, stackRef))
+
+	// The broken lock is recorded.
+	records, err := listBucket(ctx, lb.bucket, lockAuditDir(stackRef.FullyQualifiedName()))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	bytes, err := lb.bucket.ReadAll(ctx, records[0].Key)
+	require.NoError(t, err)
+	var record lockAuditRecord
+	require.NoError(t, json.Unmarshal(bytes, &record))
+	assert.Equal(t, other.lockID, record.LockID)
+	assert.Equal(t, "testing", record.Reason)
+	assert.Equal(t, os.Getpid(), record.Lock.Pid)
+}
+
 func TestRemoveMakesBackups(t *testing.T) {
 	t.Parallel()
 
@@ -1131,6 +1344,61 @@ func TestCreateStack_retainCheckpoints(t *testing.T) {
 		"file with a timestamp extension not found in %v", got)
 }
 
+func TestExportDeploymentForVersion(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, err := New(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()), nil)
+	require.NoError(t, err)
+	lb := b.(*localBackend)
+
+	stackRef, err := lb.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	s, err := b.CreateStack(ctx, stackRef, "", nil)
+	require.NoError(t, err)
+
+	// Record two updates, the first with one resource and the second with two.
+	newDeployment := func(names ...string) *apitype.UntypedDeployment {
+		var resources []apitype.ResourceV3
+		for _, name := range names {
+			resources = append(resources, apitype.ResourceV3{
+				URN:  resource.NewURN("a", "project", "", "a:b:c", tokens.QName(name)),
+				Type: "a:b:c",
+			})
+		}
+		data, err := json.Marshal(apitype.DeploymentV3{Resources: resources})
+		require.NoError(t, err)
+		return &apitype.UntypedDeployment{Version: 3, Deployment: data}
+	}
+	require.NoError(t, b.ImportDeployment(ctx, s, newDeployment("foo")))
+	require.NoError(t, lb.addToHistory(ctx, stackRef, backend.UpdateInfo{Kind: apitype.UpdateUpdate}))
+	// History files are named by timestamp, so make sure the second update sorts after the first.
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, b.ImportDeployment(ctx, s, newDeployment("foo", "bar")))
+	require.NoError(t, lb.addToHistory(ctx, stackRef, backend.UpdateInfo{Kind: apitype.RefreshUpdate}))
+
+	history, err := b.GetHistory(ctx, stackRef, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, 2, history[0].Version)
+	assert.Equal(t, apitype.RefreshUpdate, history[0].Kind)
+	assert.Equal(t, 1, history[1].Version)
+	assert.Equal(t, apitype.UpdateUpdate, history[1].Kind)
+
+	for version, expected := range map[string]int{"1": 1, "2": 2} {
+		dep, err := lb.ExportDeploymentForVersion(ctx, s, version)
+		require.NoError(t, err)
+		var deployment apitype.DeploymentV3
+		require.NoError(t, json.Unmarshal(dep.Deployment, &deployment))
+		assert.Len(t, deployment.Resources, expected, "version %s", version)
+	}
+
+	for _, version := range []string{"0", "3", "latest"} {
+		_, err := lb.ExportDeploymentForVersion(ctx, s, version)
+		assert.Error(t, err, "version %s", version)
+	}
+}
+
 // mapGetenv builds an os.Getenv-like function
 // that returns values from the given map.
 func mapGetenv(m map[string]string) func(string) string {
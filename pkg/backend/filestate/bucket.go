@@ -22,6 +22,7 @@ type Bucket interface {
 	ReadAll(ctx context.Context, key string) (_ []byte, err error)
 	WriteAll(ctx context.Context, key string, p []byte, opts *blob.WriterOptions) (err error)
 	Exists(ctx context.Context, key string) (bool, error)
+	Attributes(ctx context.Context, key string) (*blob.Attributes, error)
 }
 
 // wrappedBucket encapsulates a true gocloud blob.Bucket, but ensures that all paths we send to it
@@ -62,6 +63,10 @@ func (b *wrappedBucket) Exists(ctx context.Context, key string) (bool, error) {
 	return b.bucket.Exists(ctx, filepath.ToSlash(key))
 }
 
+func (b *wrappedBucket) Attributes(ctx context.Context, key string) (*blob.Attributes, error) {
+	return b.bucket.Attributes(ctx, filepath.ToSlash(key))
+}
+
 // listBucket returns a list of all files in the bucket within a given directory. go-cloud sorts the results by key
 func listBucket(ctx context.Context, bucket Bucket, dir string) ([]*blob.ListObject, error) {
 	bucketIter := bucket.List(&blob.ListOptions{
@@ -0,0 +1,93 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+
+	"gocloud.dev/blob"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// StorageDriver opens the storage used by filestate backends whose URLs use a particular scheme.
+//
+// Out of the box, the filestate backend stores state in any bucket supported by the go-cloud blob package (file,
+// s3, gs and azblob URLs). Storage drivers extend this to other kinds of storage, such as databases. A driver
+// presents its storage as a go-cloud bucket, typically by implementing gocloud.dev/blob/driver.Bucket and wrapping
+// it with blob.NewBucket. Any blob.BucketURLOpener is also a StorageDriver.
+type StorageDriver interface {
+	// OpenBucketURL opens the bucket that stores state for the given URL.
+	OpenBucketURL(ctx context.Context, u *url.URL) (*blob.Bucket, error)
+}
+
+var (
+	storageDriversLock sync.RWMutex
+	storageDrivers     = map[string]StorageDriver{}
+)
+
+// RegisterStorageDriver makes a storage driver available for filestate backend URLs with the given scheme, so that,
+// for example, `pulumi login <scheme>://...` stores state using the driver. It is intended to be called from the init
+// function of the package that implements the driver. RegisterStorageDriver panics if the scheme is already handled
+// by another driver or by the go-cloud blob package.
+func RegisterStorageDriver(scheme string, driver StorageDriver) {
+	contract.Requiref(scheme != "", "scheme", "must not be empty")
+	contract.Requiref(driver != nil, "driver", "must not be nil")
+
+	storageDriversLock.Lock()
+	defer storageDriversLock.Unlock()
+
+	if _, has := storageDrivers[scheme]; has || blob.DefaultURLMux().ValidBucketScheme(scheme) {
+		panic(fmt.Sprintf("a storage driver is already registered for scheme %q", scheme))
+	}
+	storageDrivers[scheme] = driver
+}
+
+// lookupStorageDriver returns the storage driver registered for the given scheme, if any.
+func lookupStorageDriver(scheme string) (StorageDriver, bool) {
+	storageDriversLock.RLock()
+	defer storageDriversLock.RUnlock()
+
+	driver, ok := storageDrivers[scheme]
+	return driver, ok
+}
+
+// supportedSchemes returns the URL schemes supported by the filestate backend, in sorted order.
+func supportedSchemes() []string {
+	storageDriversLock.RLock()
+	defer storageDriversLock.RUnlock()
+
+	schemes := blob.DefaultURLMux().BucketSchemes()
+	for scheme := range storageDrivers {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// WriteConditions are preconditions on a write, for storage drivers that support conditional writes. The filestate
+// backend uses them to make checkpoint writes conditional on the checkpoint being unchanged since it was loaded.
+//
+// A driver exposes them by accepting a **WriteConditions in the asFunc passed to blob.WriterOptions.BeforeWrite; the
+// backend then fills in the conditions that the write must satisfy. A driver that cannot satisfy the conditions must
+// fail the write with an error that its ErrorCode maps to gcerrors.FailedPrecondition.
+type WriteConditions struct {
+	// IfMatch, if not empty, requires that the object exists and that its ETag is equal to IfMatch.
+	IfMatch string
+}
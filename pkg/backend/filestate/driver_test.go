@@ -0,0 +1,73 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/memblob"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+)
+
+// memDriver is a storage driver that stores state in memory, shared by all of the backends that it opens.
+type memDriver struct {
+	bucket *blob.Bucket
+	urls   []string
+}
+
+func (d *memDriver) OpenBucketURL(ctx context.Context, u *url.URL) (*blob.Bucket, error) {
+	d.urls = append(d.urls, u.String())
+	return d.bucket, nil
+}
+
+//nolint:paralleltest // registers a storage driver globally
+func TestRegisterStorageDriver(t *testing.T) {
+	ctx := context.Background()
+	driver := &memDriver{bucket: memblob.OpenBucket(nil)}
+	RegisterStorageDriver("memtest", driver)
+
+	assert.True(t, IsFileStateBackendURL("memtest://some/path"))
+	assert.Contains(t, supportedSchemes(), "memtest")
+
+	be, err := newLocalBackend(ctx, diagtest.LogSink(t), "memtest://some/path", nil, nil)
+	require.NoError(t, err)
+	ref, err := be.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = be.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	// The driver is given the whole URL, and the path is not treated as a subdirectory of the bucket.
+	assert.Equal(t, []string{"memtest://some/path"}, driver.urls)
+	exists, err := driver.bucket.Exists(ctx, ".pulumi/stacks/project/dev.json")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Schemes can only be registered once, and built-in schemes cannot be replaced.
+	assert.Panics(t, func() { RegisterStorageDriver("memtest", driver) })
+	assert.Panics(t, func() { RegisterStorageDriver("s3", driver) })
+}
+
+func TestUnsupportedSchemeError(t *testing.T) {
+	t.Parallel()
+
+	_, err := newLocalBackend(context.Background(), diagtest.LogSink(t), "unknown://path", nil, nil)
+	assert.ErrorContains(t, err, "file, gs")
+}
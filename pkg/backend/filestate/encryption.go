@@ -0,0 +1,213 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+// Checkpoints only encrypt the values of secrets, so everything else about a stack's resources is stored in
+// plaintext. When encryption at rest is enabled, the backend encrypts the whole of each checkpoint, history file and
+// journal record that it writes with the stack's secrets manager.
+//
+// An encrypted file is an envelope holding the ciphertext of the file's original contents, along with the secrets
+// providers needed to decrypt it. Files are decrypted as they are read whether or not encryption is enabled, so that
+// turning it on or off only affects what is written. Files of stacks that do not have a secrets manager yet, such as
+// the empty checkpoints of newly created stacks, are not encrypted.
+
+// encryptedStatePrefix is the prefix of every encrypted file, used to recognize them without parsing the contents of
+// files that are not encrypted.
+var encryptedStatePrefix = []byte(`{"encrypted":`)
+
+// encryptedState is the envelope of an encrypted file.
+type encryptedState struct {
+	Encrypted encryptedPayload `json:"encrypted"`
+}
+
+// encryptedPayload is the encrypted contents of a file.
+type encryptedPayload struct {
+	// SecretsProviders describe the secrets manager that encrypted the contents.
+	SecretsProviders apitype.SecretsProvidersV1 `json:"secretsProviders"`
+	// Ciphertext is the encrypted contents.
+	Ciphertext string `json:"ciphertext"`
+}
+
+// stateDecryptionError is returned when an encrypted file cannot be decrypted, typically because the passphrase or key
+// that it was encrypted with is not available.
+type stateDecryptionError struct {
+	err error
+}
+
+func (e *stateDecryptionError) Error() string {
+	return fmt.Sprintf("decrypting state: %v", e.err)
+}
+
+func (e *stateDecryptionError) Unwrap() error {
+	return e.err
+}
+
+// isEncryptedState returns true if the given file contents are encrypted.
+func isEncryptedState(data []byte) bool {
+	return bytes.HasPrefix(data, encryptedStatePrefix)
+}
+
+// secretsProvidersOf describes the given secrets manager as it is recorded in deployments.
+func secretsProvidersOf(sm secrets.Manager) (*apitype.SecretsProvidersV1, error) {
+	if sm == nil {
+		return nil, nil
+	}
+	providers := &apitype.SecretsProvidersV1{Type: sm.Type()}
+	if state := sm.State(); state != nil {
+		rm, err := json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		providers.State = rm
+	}
+	return providers, nil
+}
+
+// checkpointSecretsProviders returns the secrets providers recorded in the given checkpoint, if any.
+func checkpointSecretsProviders(checkpoint *apitype.VersionedCheckpoint) (*apitype.SecretsProvidersV1, error) {
+	var chk struct {
+		Latest *struct {
+			SecretsProviders *apitype.SecretsProvidersV1 `json:"secrets_providers"`
+		} `json:"latest"`
+	}
+	if err := json.Unmarshal(checkpoint.Checkpoint, &chk); err != nil {
+		return nil, err
+	}
+	if chk.Latest == nil {
+		return nil, nil
+	}
+	return chk.Latest.SecretsProviders, nil
+}
+
+// stateSecretsManager returns the secrets manager described by the given secrets providers. Managers are cached, so
+// that cloud key management services and passphrase prompts are only consulted once for each.
+func (b *localBackend) stateSecretsManager(providers apitype.SecretsProvidersV1) (secrets.Manager, error) {
+	key := secretsManagerKey(providers)
+
+	b.secretsManagersLock.Lock()
+	defer b.secretsManagersLock.Unlock()
+
+	if sm, has := b.secretsManagers[key]; has {
+		return sm, nil
+	}
+	sm, err := stack.DefaultSecretsProvider.OfType(providers.Type, providers.State)
+	if err != nil {
+		return nil, err
+	}
+	b.secretsManagers[key] = sm
+	return sm, nil
+}
+
+// forgetSecretsManager removes a secrets manager that failed to encrypt or decrypt from the cache, so that the next
+// use of it can pick up a passphrase or credentials that were missing.
+func (b *localBackend) forgetSecretsManager(providers apitype.SecretsProvidersV1) {
+	b.secretsManagersLock.Lock()
+	defer b.secretsManagersLock.Unlock()
+
+	delete(b.secretsManagers, secretsManagerKey(providers))
+}
+
+func secretsManagerKey(providers apitype.SecretsProvidersV1) string {
+	return providers.Type + "\x00" + string(providers.State)
+}
+
+// encryptState encrypts the contents of a file with the secrets manager described by the given secrets providers, if
+// encryption at rest is enabled. The contents are returned as they are if it is not, or if there are no providers.
+func (b *localBackend) encryptState(
+	ctx context.Context,
+	providers *apitype.SecretsProvidersV1,
+	data []byte,
+) ([]byte, error) {
+	if !b.encrypt || providers == nil {
+		return data, nil
+	}
+
+	sm, err := b.stateSecretsManager(*providers)
+	if err != nil {
+		return nil, fmt.Errorf("getting secrets manager for state encryption: %w", err)
+	}
+	enc, err := sm.Encrypter()
+	if err != nil {
+		return nil, fmt.Errorf("getting encrypter for state encryption: %w", err)
+	}
+	ciphertext, err := enc.EncryptValue(ctx, string(data))
+	if err != nil {
+		b.forgetSecretsManager(*providers)
+		return nil, fmt.Errorf("encrypting state: %w", err)
+	}
+
+	return json.Marshal(encryptedState{
+		Encrypted: encryptedPayload{SecretsProviders: *providers, Ciphertext: ciphertext},
+	})
+}
+
+// decryptState returns the decrypted contents of a file, or the contents as they are if the file is not encrypted.
+func (b *localBackend) decryptState(ctx context.Context, data []byte) ([]byte, error) {
+	if !isEncryptedState(data) {
+		return data, nil
+	}
+
+	var state encryptedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("reading encrypted state: %w", err)
+	}
+	sm, err := b.stateSecretsManager(state.Encrypted.SecretsProviders)
+	if err != nil {
+		return nil, &stateDecryptionError{fmt.Errorf("getting secrets manager: %w", err)}
+	}
+	dec, err := sm.Decrypter()
+	if err != nil {
+		return nil, &stateDecryptionError{fmt.Errorf("getting decrypter: %w", err)}
+	}
+	plaintext, err := dec.DecryptValue(ctx, state.Encrypted.Ciphertext)
+	if err != nil {
+		b.forgetSecretsManager(state.Encrypted.SecretsProviders)
+		return nil, &stateDecryptionError{err}
+	}
+	return []byte(plaintext), nil
+}
+
+// readState reads the file at the given path, decrypting it if it is encrypted.
+func (b *localBackend) readState(ctx context.Context, file string) ([]byte, error) {
+	data, err := b.bucket.ReadAll(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return b.decryptState(ctx, data)
+}
+
+// stateSecretsProviders returns the secrets providers with which the given file is encrypted, or nil if it is not.
+func (b *localBackend) stateSecretsProviders(ctx context.Context, file string) (*apitype.SecretsProvidersV1, error) {
+	data, err := b.bucket.ReadAll(ctx, file)
+	if err != nil || !isEncryptedState(data) {
+		return nil, err
+	}
+	var state encryptedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("reading encrypted state: %w", err)
+	}
+	return &state.Encrypted.SecretsProviders, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/passphrase"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+)
+
+func TestEncryptedState(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := "file://" + filepath.ToSlash(t.TempDir())
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, &localBackendOptions{
+		Getenv: mapGetenv(map[string]string{
+			"PULUMI_SELF_MANAGED_STATE_ENCRYPT": "true",
+			"PULUMI_SELF_MANAGED_STATE_JOURNAL": "true",
+		}),
+	})
+	require.NoError(t, err)
+	ref, err := b.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	// Constructing the secrets manager caches it, so reading the state back doesn't need the passphrase.
+	sm, err := passphrase.NewPassphraseSecretsManager("abc123",
+		"v1:4iF78gb0nF0=:v1:Co6IbTWYs/UdrjgY:FSrAWOFZnj9ealCUDdJL7LrUKXX9BA==")
+	require.NoError(t, err)
+
+	assertEncrypted := func(file string) {
+		data, err := b.bucket.ReadAll(ctx, file)
+		require.NoError(t, err)
+		assert.True(t, isEncryptedState(data), "%s is not encrypted", file)
+		assert.NotContains(t, string(data), "plaintext")
+		assert.Contains(t, string(data), `"type":"passphrase"`)
+	}
+
+	// Checkpoints are encrypted, and decrypted when read.
+	persister, ok := b.newSnapshotPersister(ctx, ref, sm).(*localJournalPersister)
+	require.True(t, ok)
+	a := newJournalResource("a", "plaintext")
+	require.NoError(t, persister.Save(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a}, nil)))
+	assertEncrypted(b.stackPath(ctx, ref))
+	assert.Equal(t, []string{"a=plaintext"}, exportedValues(t, b, ref))
+
+	// As are journal records.
+	c := newJournalResource("c", "plaintext")
+	err = persister.SaveDelta(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a, c}, nil),
+		backend.SnapshotDelta{Spans: []backend.SnapshotSpan{
+			{Persisted: true, Start: 0, Count: 1},
+			{Start: 1, Count: 1},
+		}})
+	require.NoError(t, err)
+	assertEncrypted(b.journalRecordPath(ref.FullyQualifiedName(), 1))
+	assert.Equal(t, []string{"a=plaintext", "c=plaintext"}, exportedValues(t, b, ref))
+	require.NoError(t, persister.compact())
+
+	// And history files, along with the copies of checkpoints kept with them.
+	require.NoError(t, b.addToHistory(ctx, ref, backend.UpdateInfo{
+		Kind:    apitype.UpdateUpdate,
+		Message: "plaintext",
+		Result:  backend.SucceededResult,
+	}))
+	historyFiles, err := b.listHistoryFiles(ctx, ref)
+	require.NoError(t, err)
+	require.Len(t, historyFiles, 1)
+	assertEncrypted(historyFiles[0].Key)
+	assertEncrypted(historyCheckpointPath(historyFiles[0].Key))
+	history, err := b.getHistory(ctx, ref, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "plaintext", history[0].Message)
+	chk, err := b.getHistoricalCheckpoint(ctx, ref, 1)
+	require.NoError(t, err)
+	assert.Len(t, chk.Latest.Resources, 2)
+
+	// Imported deployments are encrypted with their own secrets manager.
+	deployment, err := makeUntypedDeployment("a", "abc123",
+		"v1:4iF78gb0nF0=:v1:Co6IbTWYs/UdrjgY:FSrAWOFZnj9ealCUDdJL7LrUKXX9BA==")
+	require.NoError(t, err)
+	stk, err := b.GetStack(ctx, ref)
+	require.NoError(t, err)
+	require.NoError(t, b.ImportDeployment(ctx, stk, deployment))
+	assertEncrypted(b.stackPath(ctx, ref))
+
+	// A backend that doesn't encrypt state still reads it, but writes it in plaintext.
+	other, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, nil)
+	require.NoError(t, err)
+	snap, _, err := other.getStack(ctx, ref)
+	require.NoError(t, err)
+	assert.Len(t, snap.Resources, 1)
+	_, err = other.saveStack(ctx, ref, snap, nil)
+	require.NoError(t, err)
+	data, err := b.bucket.ReadAll(ctx, b.stackPath(ctx, ref))
+	require.NoError(t, err)
+	assert.False(t, isEncryptedState(data))
+}
+
+func TestEncryptedStateWithoutPassphrase(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()), nil, nil)
+	require.NoError(t, err)
+	ref, err := b.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	// Overwrite the checkpoint with one encrypted by a passphrase that isn't available.
+	data, err := json.Marshal(encryptedState{Encrypted: encryptedPayload{
+		SecretsProviders: apitype.SecretsProvidersV1{
+			Type:  passphrase.Type,
+			State: json.RawMessage(`{"salt":"v1:unknown"}`),
+		},
+		Ciphertext: "v1:unknown",
+	}})
+	require.NoError(t, err)
+	require.NoError(t, b.bucket.WriteAll(ctx, b.stackPath(ctx, ref), data, nil))
+
+	// The stack can't be read...
+	_, _, err = b.getStack(ctx, ref)
+	var decErr *stateDecryptionError
+	assert.True(t, errors.As(err, &decErr), "expected a decryption error, got %v", err)
+
+	// ...but it can still be listed.
+	stacks, _, err := b.ListStacks(ctx, backend.ListStacksFilter{}, nil)
+	require.NoError(t, err)
+	require.Len(t, stacks, 1)
+	assert.Nil(t, stacks[0].ResourceCount())
+}
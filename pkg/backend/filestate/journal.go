@@ -0,0 +1,203 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/fsutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// When journaling is enabled, the snapshots written during an update are persisted as a journal of delta records
+// rather than by rewriting the stack's checkpoint after every step.
+//
+// Each record holds the resources that changed since the previous record, along with the manifest and pending
+// operations of the snapshot, and refers to the resources that did not change by their position in the previous
+// snapshot. Records are numbered from one, and are based on a particular revision of the checkpoint. Reading the
+// checkpoint replays the records that are based on its revision, so the journal is invisible to everything that
+// reads state, and an update that is interrupted leaves behind a checkpoint and journal that together describe the
+// last snapshot that it persisted.
+//
+// Writing a full checkpoint, which happens at the start and end of every update and after every
+// journalCompaction records, starts a new journal.
+
+// defaultJournalCompaction is the number of delta records written to a journal before it is compacted into a full
+// checkpoint, unless overridden by PulumiFilestateJournalCompactionEnvVar.
+const defaultJournalCompaction = 100
+
+// journalRecord is a delta record in a stack's checkpoint journal.
+type journalRecord struct {
+	// Base is the revision of the checkpoint that the journal is based on.
+	Base int64 `json:"base"`
+	// Sequence is the position of the record in the journal, starting from one.
+	Sequence int `json:"sequence"`
+	// Entries describe the steps whose changes are recorded.
+	Entries []journalEntry `json:"entries,omitempty"`
+	// Manifest is the manifest of the snapshot.
+	Manifest apitype.ManifestV1 `json:"manifest"`
+	// Spans make up the resources of the snapshot, in order.
+	Spans []journalSpan `json:"spans,omitempty"`
+	// PendingOperations are the pending operations of the snapshot.
+	PendingOperations []apitype.OperationV2 `json:"pendingOperations,omitempty"`
+}
+
+// journalEntry describes a step whose changes are recorded in a journal record.
+type journalEntry struct {
+	Kind engine.JournalEntryKind `json:"kind"`
+	Op   display.StepOp          `json:"op"`
+	URN  resource.URN            `json:"urn"`
+}
+
+// journalSpan is a run of consecutive resources in a snapshot. If Resources is empty, the span refers to the Count
+// resources starting at Start in the previous snapshot; otherwise it holds the resources themselves.
+type journalSpan struct {
+	Start     int                  `json:"start,omitempty"`
+	Count     int                  `json:"count,omitempty"`
+	Resources []apitype.ResourceV3 `json:"resources,omitempty"`
+}
+
+// journalDir returns the directory that holds the checkpoint journal of the given stack.
+func journalDir(stack tokens.QName) string {
+	contract.Requiref(stack != "", "stack", "must not be empty")
+	return path.Join(workspace.BookkeepingDir, "journal", fsutil.QnamePath(stack))
+}
+
+// journalRecordPath returns the path of the journal record with the given sequence number.
+func (b *localBackend) journalRecordPath(stack tokens.QName, sequence int) string {
+	file := path.Join(journalDir(stack), fmt.Sprintf("%010d.json", sequence))
+	if b.gzip {
+		file += encoding.GZIPExt
+	}
+	return file
+}
+
+// writeJournalRecord appends a record to the checkpoint journal of the given stack, encrypted with the given secrets
+// providers if encryption at rest is enabled.
+func (b *localBackend) writeJournalRecord(
+	ctx context.Context,
+	ref *localBackendReference,
+	providers *apitype.SecretsProvidersV1,
+	rec *journalRecord,
+) error {
+	m := encoding.JSON
+	if b.gzip {
+		m = encoding.Gzip(m)
+	}
+	byts, err := m.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshalling journal record: %w", err)
+	}
+	if byts, err = b.encryptState(ctx, providers, byts); err != nil {
+		return err
+	}
+
+	file := b.journalRecordPath(ref.FullyQualifiedName(), rec.Sequence)
+	if err = b.bucket.WriteAll(ctx, file, byts, nil); err != nil {
+		return fmt.Errorf("writing journal record: %w", err)
+	}
+	logging.V(7).Infof("Saved stack %s journal record %d to: %s", ref.FullyQualifiedName(), rec.Sequence, file)
+	return nil
+}
+
+// removeJournal removes the checkpoint journal of the given stack.
+func (b *localBackend) removeJournal(ctx context.Context, ref *localBackendReference) error {
+	return removeAllByPrefix(ctx, b.bucket, journalDir(ref.FullyQualifiedName()))
+}
+
+// replayJournal applies the records in the checkpoint journal of the given stack to its checkpoint. Records that are
+// based on other revisions of the checkpoint are left over from journals that were never cleaned up, and are ignored.
+func (b *localBackend) replayJournal(
+	ctx context.Context,
+	ref *localBackendReference,
+	chk *apitype.CheckpointV3,
+) error {
+	files, err := listBucket(ctx, b.bucket, journalDir(ref.FullyQualifiedName()))
+	if err != nil {
+		return fmt.Errorf("listing journal records: %w", err)
+	}
+
+	var records []*journalRecord
+	for _, file := range files {
+		name := objectName(file)
+		if _, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(name, encoding.GZIPExt), ".json")); err != nil {
+			continue
+		}
+
+		byts, err := b.readState(ctx, file.Key)
+		if err != nil {
+			return fmt.Errorf("reading journal record %s: %w", file.Key, err)
+		}
+		m := encoding.JSON
+		if encoding.IsCompressed(byts) {
+			m = encoding.Gzip(m)
+		}
+		var rec journalRecord
+		if err = m.Unmarshal(byts, &rec); err != nil {
+			return fmt.Errorf("reading journal record %s: %w", file.Key, err)
+		}
+		if rec.Base != chk.Revision {
+			logging.V(7).Infof("Ignoring journal record %s based on revision %d", file.Key, rec.Base)
+			continue
+		}
+		// Keys are sorted and zero padded, so records are listed in order.
+		if rec.Sequence != len(records)+1 {
+			return fmt.Errorf("journal record %s is out of sequence: expected %d, got %d",
+				file.Key, len(records)+1, rec.Sequence)
+		}
+		records = append(records, &rec)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	if chk.Latest == nil {
+		return fmt.Errorf("journal has %d records, but the checkpoint has no deployment", len(records))
+	}
+
+	latest := *chk.Latest
+	for _, rec := range records {
+		var resources []apitype.ResourceV3
+		for _, span := range rec.Spans {
+			if len(span.Resources) != 0 {
+				resources = append(resources, span.Resources...)
+				continue
+			}
+			if span.Start < 0 || span.Count < 0 || span.Start+span.Count > len(latest.Resources) {
+				return fmt.Errorf("journal record %d refers to resources [%d, %d) of %d",
+					rec.Sequence, span.Start, span.Start+span.Count, len(latest.Resources))
+			}
+			resources = append(resources, latest.Resources[span.Start:span.Start+span.Count]...)
+		}
+		latest.Manifest = rec.Manifest
+		latest.Resources = resources
+		latest.PendingOperations = rec.PendingOperations
+	}
+	chk.Latest = &latest
+
+	logging.V(7).Infof("Replayed %d journal records for stack %s", len(records), ref.FullyQualifiedName())
+	return nil
+}
@@ -0,0 +1,278 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+func newJournalResource(name, value string) *resource.State {
+	return &resource.State{
+		URN:    resource.NewURN("dev", "project", "", "pkg:index:typ", tokens.QName(name)),
+		Type:   "pkg:index:typ",
+		Custom: true,
+		ID:     resource.ID(name),
+		Inputs: resource.PropertyMap{"value": resource.NewStringProperty(value)},
+	}
+}
+
+// exportedValues returns the names and values of the resources in the given stack's exported deployment.
+func exportedValues(t *testing.T, b *localBackend, ref *localBackendReference) []string {
+	ctx := context.Background()
+	stk, err := b.GetStack(ctx, ref)
+	require.NoError(t, err)
+	udep, err := b.ExportDeployment(ctx, stk)
+	require.NoError(t, err)
+	var dep apitype.DeploymentV3
+	require.NoError(t, json.Unmarshal(udep.Deployment, &dep))
+
+	var values []string
+	for _, res := range dep.Resources {
+		values = append(values, res.URN.Name().String()+"="+res.Inputs["value"].(string))
+	}
+	return values
+}
+
+func TestJournal(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := "file://" + filepath.ToSlash(t.TempDir())
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, &localBackendOptions{
+		Getenv: mapGetenv(map[string]string{
+			"PULUMI_SELF_MANAGED_STATE_JOURNAL":            "true",
+			"PULUMI_SELF_MANAGED_STATE_JOURNAL_COMPACTION": "2",
+		}),
+	})
+	require.NoError(t, err)
+	ref, err := b.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	sm := b64.NewBase64SecretsManager()
+	persister, ok := b.newSnapshotPersister(ctx, ref, sm).(*localJournalPersister)
+	require.True(t, ok)
+
+	revision := func() int64 {
+		rev, err := b.readCheckpointRevision(ctx, b.stackPath(ctx, ref), nil)
+		require.NoError(t, err)
+		return rev.revision
+	}
+	records := func() int {
+		files, err := listBucket(ctx, b.bucket, journalDir(ref.FullyQualifiedName()))
+		require.NoError(t, err)
+		return len(files)
+	}
+
+	// The first snapshot is saved in full.
+	a, c := newJournalResource("a", "1"), newJournalResource("c", "1")
+	require.NoError(t, persister.Save(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a, c}, nil)))
+	base := revision()
+
+	// Deltas are journaled without touching the checkpoint, and replayed when the stack is read.
+	bRes := newJournalResource("b", "1")
+	err = persister.SaveDelta(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a, bRes, c}, nil),
+		backend.SnapshotDelta{Spans: []backend.SnapshotSpan{
+			{Persisted: true, Start: 0, Count: 1},
+			{Start: 1, Count: 1},
+			{Persisted: true, Start: 1, Count: 1},
+		}})
+	require.NoError(t, err)
+	assert.Equal(t, base, revision())
+	assert.Equal(t, 1, records())
+	assert.Equal(t, []string{"a=1", "b=1", "c=1"}, exportedValues(t, b, ref))
+
+	c2 := newJournalResource("c", "2")
+	err = persister.SaveDelta(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a, bRes, c2}, nil),
+		backend.SnapshotDelta{Spans: []backend.SnapshotSpan{
+			{Persisted: true, Start: 0, Count: 2},
+			{Start: 2, Count: 1},
+		}})
+	require.NoError(t, err)
+	assert.Equal(t, base, revision())
+	assert.Equal(t, 2, records())
+
+	// A backend that does not journal itself still sees the journaled changes, as it would after a crash.
+	other, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a=1", "b=1", "c=2"}, exportedValues(t, other, ref))
+	snap, _, err := other.getStack(ctx, ref)
+	require.NoError(t, err)
+	require.Len(t, snap.Resources, 3)
+	assert.Equal(t, resource.NewStringProperty("2"), snap.Resources[2].Inputs["value"])
+
+	// Once the journal reaches the compaction limit, the next snapshot is saved in full.
+	err = persister.SaveDelta(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a, c2}, nil),
+		backend.SnapshotDelta{Spans: []backend.SnapshotSpan{
+			{Persisted: true, Start: 0, Count: 1},
+			{Persisted: true, Start: 2, Count: 1},
+		}})
+	require.NoError(t, err)
+	assert.Equal(t, base+1, revision())
+	assert.Equal(t, 0, records())
+	assert.Equal(t, []string{"a=1", "c=2"}, exportedValues(t, b, ref))
+
+	// Compacting writes out the last snapshot in full, if it was journaled.
+	require.NoError(t, persister.compact())
+	assert.Equal(t, base+1, revision())
+	err = persister.SaveDelta(deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{c2}, nil),
+		backend.SnapshotDelta{Spans: []backend.SnapshotSpan{{Persisted: true, Start: 1, Count: 1}}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, records())
+	require.NoError(t, persister.compact())
+	assert.Equal(t, base+2, revision())
+	assert.Equal(t, 0, records())
+	assert.Equal(t, []string{"c=2"}, exportedValues(t, b, ref))
+
+	// Removing the stack removes its journal.
+	err = persister.SaveDelta(deploy.NewSnapshot(deploy.Manifest{}, sm, nil, nil), backend.SnapshotDelta{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, records())
+	require.NoError(t, b.removeStack(ctx, ref))
+	assert.Equal(t, 0, records())
+}
+
+func TestJournalStaleRecords(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()), nil, nil)
+	require.NoError(t, err)
+	ref, err := b.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	sm := b64.NewBase64SecretsManager()
+	a := newJournalResource("a", "1")
+	_, err = b.saveStack(ctx, ref, deploy.NewSnapshot(deploy.Manifest{}, sm, []*resource.State{a}, nil), sm)
+	require.NoError(t, err)
+	chk, err := b.getCheckpoint(ctx, ref)
+	require.NoError(t, err)
+
+	// Records based on an older checkpoint are ignored.
+	require.NoError(t, b.writeJournalRecord(ctx, ref, nil, &journalRecord{Base: chk.Revision - 1, Sequence: 1}))
+	assert.Equal(t, []string{"a=1"}, exportedValues(t, b, ref))
+
+	// Records that are out of sequence or refer to resources that don't exist are errors.
+	require.NoError(t, b.writeJournalRecord(ctx, ref, nil, &journalRecord{Base: chk.Revision, Sequence: 2}))
+	_, err = b.getCheckpoint(ctx, ref)
+	assert.ErrorContains(t, err, "out of sequence")
+
+	require.NoError(t, b.writeJournalRecord(ctx, ref, nil, &journalRecord{
+		Base:     chk.Revision,
+		Sequence: 1,
+		Spans:    []journalSpan{{Start: 0, Count: 2}},
+	}))
+	_, err = b.getCheckpoint(ctx, ref)
+	assert.ErrorContains(t, err, "refers to resources [0, 2) of 1")
+}
+
+func TestJournalConcurrentModification(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := "file://" + filepath.ToSlash(t.TempDir())
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, &localBackendOptions{
+		Getenv: mapGetenv(map[string]string{"PULUMI_SELF_MANAGED_STATE_JOURNAL": "true"}),
+	})
+	require.NoError(t, err)
+	ref, err := b.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	sm := b64.NewBase64SecretsManager()
+	persister, ok := b.newSnapshotPersister(ctx, ref, sm).(*localJournalPersister)
+	require.True(t, ok)
+	snap := deploy.NewSnapshot(deploy.Manifest{}, sm, nil, nil)
+	require.NoError(t, persister.Save(snap))
+
+	// Another process replaces the checkpoint, so journaling on top of the old one must fail.
+	other, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, nil)
+	require.NoError(t, err)
+	_, _, err = other.getStack(ctx, ref)
+	require.NoError(t, err)
+	_, err = other.saveStack(ctx, ref, snap, sm)
+	require.NoError(t, err)
+
+	err = persister.SaveDelta(snap, backend.SnapshotDelta{})
+	var cmErr *ConcurrentModificationError
+	assert.True(t, errors.As(err, &cmErr), "expected a concurrent modification error, got %v", err)
+}
+
+func TestJournalWithoutETags(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, err := newLocalBackend(ctx, diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()), nil,
+		&localBackendOptions{
+			Getenv: mapGetenv(map[string]string{"PULUMI_SELF_MANAGED_STATE_JOURNAL": "true"}),
+		})
+	require.NoError(t, err)
+	ref, err := b.parseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = b.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	sm := b64.NewBase64SecretsManager()
+	persister, ok := b.newSnapshotPersister(ctx, ref, sm).(*localJournalPersister)
+	require.True(t, ok)
+	snap := deploy.NewSnapshot(deploy.Manifest{}, sm, nil, nil)
+	require.NoError(t, persister.Save(snap))
+
+	// Pretend that the storage provider has no ETags.
+	stackKey := ref.FullyQualifiedName().String()
+	rev, ok := b.observedCheckpoint(stackKey)
+	require.True(t, ok)
+	rev.etag = ""
+	b.observeCheckpoint(stackKey, &rev)
+
+	// Journal records are then written on top of the revision that was last saved, without reading the whole
+	// checkpoint back for each of them.
+	require.NoError(t, b.bucket.Delete(ctx, rev.file))
+	require.NoError(t, persister.SaveDelta(snap, backend.SnapshotDelta{}))
+	require.NoError(t, persister.SaveDelta(snap, backend.SnapshotDelta{}))
+	assert.Equal(t, 2, persister.sequence)
+}
+
+func TestInvalidJournalCompaction(t *testing.T) {
+	t.Parallel()
+
+	_, err := newLocalBackend(context.Background(), diagtest.LogSink(t), "file://"+filepath.ToSlash(t.TempDir()),
+		nil, &localBackendOptions{
+			Getenv: mapGetenv(map[string]string{
+				"PULUMI_SELF_MANAGED_STATE_JOURNAL":            "true",
+				"PULUMI_SELF_MANAGED_STATE_JOURNAL_COMPACTION": "0",
+			}),
+		})
+	assert.ErrorContains(t, err, "PULUMI_SELF_MANAGED_STATE_JOURNAL_COMPACTION")
+}
@@ -23,24 +23,37 @@ import (
 	"os/user"
 	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/shirou/gopsutil/v3/process"
+	"gocloud.dev/gcerrors"
+
 	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/fsutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
+// defaultLockTTL is how long a lock may go without being renewed before it is considered stale, unless overridden by
+// PulumiFilestateLockTTLEnvVar.
+const defaultLockTTL = 5 * time.Minute
+
 type lockContent struct {
 	Pid       int       `json:"pid"`
 	Username  string    `json:"username"`
 	Hostname  string    `json:"hostname"`
 	Timestamp time.Time `json:"timestamp"`
+	// Expires is the time after which the lock is stale unless it has been renewed. Locks written by older versions
+	// of the CLI, or with expiry disabled, never expire.
+	Expires time.Time `json:"expires,omitempty"`
 }
 
-func newLockContent() (*lockContent, error) {
+func newLockContent(ttl time.Duration) (*lockContent, error) {
 	u, err := user.Current()
 	if err != nil {
 		return nil, err
@@ -49,51 +62,121 @@ func newLockContent() (*lockContent, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &lockContent{
+	now := time.Now()
+	content := &lockContent{
 		Pid:       os.Getpid(),
 		Username:  u.Username,
 		Hostname:  hostname,
-		Timestamp: time.Now(),
-	}, nil
+		Timestamp: now,
+	}
+	if ttl > 0 {
+		content.Expires = now.Add(ttl)
+	}
+	return content, nil
 }
 
-// checkForLock looks for any existing locks for this stack, and returns a helpful diagnostic if there is one.
-func (b *localBackend) checkForLock(ctx context.Context, stackRef backend.StackReference) error {
+// sameHolder returns true if both lock contents were written for the same acquisition of a lock, regardless of how
+// often it has been renewed since.
+func (l *lockContent) sameHolder(other *lockContent) bool {
+	return l.Pid == other.Pid && l.Username == other.Username && l.Hostname == other.Hostname &&
+		l.Timestamp.Equal(other.Timestamp)
+}
+
+// staleReason returns a description of why the lock is stale, or the empty string if it may still be held by a live
+// process. A lock is stale if it has expired, or if it was acquired on this machine by a process that has exited.
+func (l *lockContent) staleReason(now time.Time) string {
+	if !l.Expires.IsZero() && now.After(l.Expires) {
+		return fmt.Sprintf("the lock expired at %v without being renewed", l.Expires.Format(time.RFC3339))
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname == l.Hostname && l.Pid > 0 {
+		if exists, err := process.PidExists(int32(l.Pid)); err == nil && !exists {
+			return fmt.Sprintf("process %v on this machine is no longer running", l.Pid)
+		}
+	}
+	return ""
+}
+
+// lockAuditDir returns the directory in which records of broken locks are kept for the given stack.
+func lockAuditDir(stack tokens.QName) string {
+	contract.Requiref(stack != "", "stack", "must not be empty")
+	return path.Join(workspace.BookkeepingDir, "lock-audit", fsutil.QnamePath(stack))
+}
+
+// lockAuditRecord records that a lock was broken, by whom and why.
+type lockAuditRecord struct {
+	Lock     lockContent `json:"lock"`
+	LockID   string      `json:"lockID"`
+	Reason   string      `json:"reason"`
+	Username string      `json:"username"`
+	Hostname string      `json:"hostname"`
+	BrokenAt time.Time   `json:"brokenAt"`
+}
+
+// readStackLocks reads every lock held on the given stack other than the lock held by this backend, keyed by path.
+func (b *localBackend) readStackLocks(ctx context.Context, stackRef backend.StackReference,
+) (map[string]*lockContent, error) {
 	stackName := stackRef.FullyQualifiedName()
 	allFiles, err := listBucket(ctx, b.bucket, stackLockDir(stackName))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// lockPath may return a path with backslashes (\) on Windows.
 	// We need to convert it to a slash path (/) to compare it to
 	// the keys in the bucket which are always slash paths.
 	wantLock := filepath.ToSlash(b.lockPath(stackRef))
-	var lockKeys []string
+	locks := make(map[string]*lockContent)
 	for _, file := range allFiles {
-		if file.IsDir {
+		if file.IsDir || file.Key == wantLock {
 			continue
 		}
-		if file.Key != wantLock {
-			lockKeys = append(lockKeys, file.Key)
+
+		content, err := b.bucket.ReadAll(ctx, file.Key)
+		if err != nil {
+			// The lock may have been released since we listed the directory.
+			if gcerrors.Code(err) == gcerrors.NotFound {
+				continue
+			}
+			return nil, err
+		}
+		l := &lockContent{}
+		if err = json.Unmarshal(content, &l); err != nil {
+			return nil, err
 		}
+		locks[file.Key] = l
 	}
+	return locks, nil
+}
 
-	if len(lockKeys) > 0 {
-		errorString := fmt.Sprintf("the stack is currently locked by %v lock(s). Either wait for the other "+
-			"process(es) to end or delete the lock file with `pulumi cancel`.", len(lockKeys))
+// checkForLock looks for any existing locks for this stack, and returns a helpful diagnostic if there is one. Stale
+// locks are removed with a warning.
+func (b *localBackend) checkForLock(ctx context.Context, stackRef backend.StackReference) error {
+	locks, err := b.readStackLocks(ctx, stackRef)
+	if err != nil {
+		return err
+	}
 
-		for _, lock := range lockKeys {
-			content, err := b.bucket.ReadAll(ctx, lock)
-			if err != nil {
-				return err
-			}
-			l := &lockContent{}
-			err = json.Unmarshal(content, &l)
-			if err != nil {
+	now := time.Now()
+	lockKeys := make([]string, 0, len(locks))
+	for key, l := range locks {
+		if reason := l.staleReason(now); reason != "" {
+			b.d.Warningf(diag.Message("", "removing stale lock %v created by %v@%v (pid %v): %v"),
+				b.url+"/"+key, l.Username, l.Hostname, l.Pid, reason)
+			if err := b.bucket.Delete(ctx, key); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
 				return err
 			}
+			continue
+		}
+		lockKeys = append(lockKeys, key)
+	}
+	sort.Strings(lockKeys)
+
+	if len(lockKeys) > 0 {
+		errorString := fmt.Sprintf("the stack is currently locked by %v lock(s). Either wait for the other "+
+			"process(es) to end or break the lock with `pulumi stack lock break`.", len(lockKeys))
 
+		for _, lock := range lockKeys {
+			l := locks[lock]
 			errorString += fmt.Sprintf("\n  %v: created by %v@%v (pid %v) at %v",
 				b.url+"/"+lock,
 				l.Username,
@@ -114,7 +197,7 @@ func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference
 	if err != nil {
 		return err
 	}
-	lockContent, err := newLockContent()
+	lockContent, err := newLockContent(b.lockTTL)
 	if err != nil {
 		return err
 	}
@@ -131,10 +214,112 @@ func (b *localBackend) Lock(ctx context.Context, stackRef backend.StackReference
 		b.Unlock(ctx, stackRef)
 		return err
 	}
+	b.startLockHeartbeat(stackRef, lockContent)
 	return nil
 }
 
+// startLockHeartbeat periodically renews the lock held by this backend on the given stack so that it does not expire
+// while the operation holding it is running. The heartbeat stops when the lock is released or removed.
+func (b *localBackend) startLockHeartbeat(stackRef backend.StackReference, content *lockContent) {
+	if b.lockTTL <= 0 {
+		return
+	}
+
+	lockPath := b.lockPath(stackRef)
+	done, stopped := make(chan struct{}), make(chan struct{})
+	stop := func() {
+		close(done)
+		<-stopped
+	}
+
+	b.heartbeatsLock.Lock()
+	previous := b.heartbeats[lockPath]
+	b.heartbeats[lockPath] = stop
+	b.heartbeatsLock.Unlock()
+	if previous != nil {
+		previous()
+	}
+
+	go func() {
+		defer close(stopped)
+
+		// The heartbeat must outlive the context of the operation that acquired the lock.
+		ctx := context.Background()
+		ticker := time.NewTicker(b.lockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			if !b.renewLock(ctx, lockPath, content) {
+				b.d.Warningf(diag.Message("", "the lock at %v was removed by another process"),
+					path.Join(b.url, lockPath))
+				return
+			}
+		}
+	}()
+}
+
+// renewLock extends the expiry of the lock at lockPath, which this backend acquired with the given content. It returns
+// false if the lock has been removed or replaced, in which case it is left alone rather than resurrected.
+//
+// The lock is re-read before it is renewed, and where the storage provider supports conditional writes the renewal is
+// conditional on the lock being unchanged since, so that a lock broken in the meantime is not written back. Elsewhere
+// the check is best effort.
+func (b *localBackend) renewLock(ctx context.Context, lockPath string, content *lockContent) bool {
+	rev, err := b.checkpointAttributes(ctx, lockPath)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return false
+		}
+		logging.V(5).Infof("checking lock %v: %v", lockPath, err)
+		return true
+	}
+	current, err := b.bucket.ReadAll(ctx, lockPath)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return false
+		}
+		logging.V(5).Infof("reading lock %v: %v", lockPath, err)
+		return true
+	}
+	var stored lockContent
+	if err := json.Unmarshal(current, &stored); err != nil || !stored.sameHolder(content) {
+		return false
+	}
+
+	content.Expires = time.Now().Add(b.lockTTL)
+	renewed, err := json.Marshal(content)
+	contract.AssertNoErrorf(err, "marshalling lock content")
+	if err := b.bucket.WriteAll(ctx, lockPath, renewed, conditionalWriteOptions(*rev)); err != nil {
+		if code := gcerrors.Code(err); code == gcerrors.FailedPrecondition || code == gcerrors.NotFound {
+			return false
+		}
+		logging.V(5).Infof("renewing lock %v: %v", lockPath, err)
+	}
+	return true
+}
+
+// stopLockHeartbeat stops renewing the lock held by this backend on the given stack, if any.
+func (b *localBackend) stopLockHeartbeat(stackRef backend.StackReference) {
+	lockPath := b.lockPath(stackRef)
+
+	b.heartbeatsLock.Lock()
+	stop := b.heartbeats[lockPath]
+	delete(b.heartbeats, lockPath)
+	b.heartbeatsLock.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+}
+
 func (b *localBackend) Unlock(ctx context.Context, stackRef backend.StackReference) {
+	b.stopLockHeartbeat(stackRef)
+
 	err := b.bucket.Delete(ctx, b.lockPath(stackRef))
 	if err != nil {
 		b.d.Errorf(
@@ -144,6 +329,80 @@ func (b *localBackend) Unlock(ctx context.Context, stackRef backend.StackReferen
 	}
 }
 
+// ListStackLocks returns the locks held on the given stack by other processes, including stale locks.
+func (b *localBackend) ListStackLocks(ctx context.Context, stackRef backend.StackReference,
+) ([]backend.StackLock, error) {
+	locks, err := b.readStackLocks(ctx, stackRef)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]backend.StackLock, 0, len(locks))
+	for key, l := range locks {
+		result = append(result, backend.StackLock{
+			ID:          strings.TrimSuffix(path.Base(key), ".json"),
+			Username:    l.Username,
+			Hostname:    l.Hostname,
+			Pid:         l.Pid,
+			Acquired:    l.Timestamp,
+			Expires:     l.Expires,
+			StaleReason: l.staleReason(now),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Acquired.Before(result[j].Acquired)
+	})
+	return result, nil
+}
+
+// BreakStackLock removes the lock with the given ID from the given stack, and writes a record of who broke the lock
+// and why alongside the stack's other bookkeeping files.
+func (b *localBackend) BreakStackLock(ctx context.Context, stackRef backend.StackReference, id, reason string) error {
+	stackName := stackRef.FullyQualifiedName()
+	lockPath := path.Join(stackLockDir(stackName), id+".json")
+	content, err := b.bucket.ReadAll(ctx, lockPath)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return fmt.Errorf("stack %v has no lock with ID %v", stackRef, id)
+		}
+		return err
+	}
+	var l lockContent
+	if err = json.Unmarshal(content, &l); err != nil {
+		return err
+	}
+
+	breaker, err := newLockContent(0)
+	if err != nil {
+		return err
+	}
+	record, err := json.MarshalIndent(lockAuditRecord{
+		Lock:     l,
+		LockID:   id,
+		Reason:   reason,
+		Username: breaker.Username,
+		Hostname: breaker.Hostname,
+		BrokenAt: breaker.Timestamp,
+	}, "", "    ")
+	if err != nil {
+		return err
+	}
+	auditPath := path.Join(lockAuditDir(stackName),
+		fmt.Sprintf("%v-%v.json", breaker.Timestamp.UnixNano(), id))
+	if err = b.bucket.WriteAll(ctx, auditPath, record, nil); err != nil {
+		return fmt.Errorf("recording broken lock: %w", err)
+	}
+
+	if err = b.bucket.Delete(ctx, lockPath); err != nil && gcerrors.Code(err) != gcerrors.NotFound {
+		return err
+	}
+	return nil
+}
+
 func lockDir() string {
 	return path.Join(workspace.BookkeepingDir, workspace.LockDir)
 }
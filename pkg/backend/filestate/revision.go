@@ -0,0 +1,212 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"gocloud.dev/blob"
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+)
+
+// Checkpoints are written with compare-and-swap semantics, so that two processes that both bypass the stack lock
+// cannot silently overwrite each other's changes.
+//
+// Each checkpoint records a revision that is incremented every time it is written. When this backend loads a stack,
+// it remembers the revision it saw, and before writing a new checkpoint it checks that the stored revision has not
+// changed in the meantime. Where the storage provider supports conditional writes (generation preconditions on Google
+// Cloud Storage, ETag preconditions on Azure Blob Storage, and WriteConditions for storage drivers) the write itself
+// is made conditional on the object being unchanged, which closes the window between the check and the write.
+// Elsewhere the revision check is best effort.
+
+// ConcurrentModificationError is returned when a checkpoint cannot be saved because it was modified by another
+// process after it was loaded.
+type ConcurrentModificationError struct {
+	// File is the path of the checkpoint file.
+	File string
+	// Expected is the revision of the checkpoint when it was loaded.
+	Expected int64
+	// Actual is the revision of the checkpoint when it was about to be overwritten, or -1 if it is unknown.
+	Actual int64
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	msg := fmt.Sprintf("the checkpoint %v was modified by another process since it was loaded", e.File)
+	if e.Actual >= 0 {
+		msg += fmt.Sprintf(" (expected revision %d, found %d)", e.Expected, e.Actual)
+	}
+	return msg + "; refusing to overwrite it. Make sure that only one update runs against the stack at a time, " +
+		"then run `pulumi refresh` to reconcile the stack's state"
+}
+
+// checkpointRevision records the state of a checkpoint file as it was last loaded or saved by this backend.
+type checkpointRevision struct {
+	// file is the path of the checkpoint file.
+	file string
+	// revision is the revision recorded in the checkpoint.
+	revision int64
+	// generation is the Google Cloud Storage generation of the object, or zero if unknown.
+	generation int64
+	// etag is the ETag of the object, or empty if unknown.
+	etag string
+}
+
+// readCheckpointRevision reads the revision of the checkpoint file at the given path along with the attributes needed
+// for a conditional write. It returns nil if the file does not exist. If the file's ETag shows that it is unchanged
+// since it was observed, the observed revision is returned without reading the file.
+func (b *localBackend) readCheckpointRevision(ctx context.Context, file string,
+	observed *checkpointRevision,
+) (*checkpointRevision, error) {
+	rev, err := b.checkpointAttributes(ctx, file)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if observed != nil && observed.file == file && rev.etag != "" &&
+		rev.etag == observed.etag && rev.generation == observed.generation {
+		rev.revision = observed.revision
+		return rev, nil
+	}
+
+	bytes, err := b.readState(ctx, file)
+	if err != nil {
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	m := encoding.JSON
+	if encoding.IsCompressed(bytes) {
+		m = encoding.Gzip(m)
+	}
+	var versioned apitype.VersionedCheckpoint
+	if err := m.Unmarshal(bytes, &versioned); err != nil {
+		return nil, err
+	}
+	var chk struct {
+		Revision int64 `json:"revision"`
+	}
+	if len(versioned.Checkpoint) > 0 {
+		if err := json.Unmarshal(versioned.Checkpoint, &chk); err != nil {
+			return nil, err
+		}
+	}
+	rev.revision = chk.Revision
+	return rev, nil
+}
+
+// checkpointAttributes reads the generation and ETag of the checkpoint file at the given path.
+func (b *localBackend) checkpointAttributes(ctx context.Context, file string) (*checkpointRevision, error) {
+	attrs, err := b.bucket.Attributes(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	rev := &checkpointRevision{file: file, etag: attrs.ETag}
+	var gcsAttrs storage.ObjectAttrs
+	if attrs.As(&gcsAttrs) {
+		rev.generation = gcsAttrs.Generation
+	}
+	return rev, nil
+}
+
+// observeCheckpoint records the revision of a stack's checkpoint as it was loaded. Later writes of the stack's
+// checkpoint fail if it has been modified since. A nil revision forgets what was previously recorded.
+func (b *localBackend) observeCheckpoint(stack string, rev *checkpointRevision) {
+	b.revisionsLock.Lock()
+	defer b.revisionsLock.Unlock()
+
+	if rev == nil {
+		delete(b.revisions, stack)
+		return
+	}
+	b.revisions[stack] = *rev
+}
+
+// observedCheckpoint returns the revision of a stack's checkpoint as it was last loaded or saved, if any.
+func (b *localBackend) observedCheckpoint(stack string) (checkpointRevision, bool) {
+	b.revisionsLock.Lock()
+	defer b.revisionsLock.Unlock()
+
+	rev, ok := b.revisions[stack]
+	return rev, ok
+}
+
+// setCheckpointRevision returns a copy of the checkpoint with its revision set to the given value.
+func setCheckpointRevision(
+	checkpoint *apitype.VersionedCheckpoint, revision int64,
+) (*apitype.VersionedCheckpoint, error) {
+	if checkpoint.Version != apitype.DeploymentSchemaVersionCurrent {
+		// Older checkpoint formats do not record a revision.
+		return checkpoint, nil
+	}
+
+	// Work with the raw fields of the checkpoint so that the deployment does not need to be deserialized.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(checkpoint.Checkpoint, &fields); err != nil {
+		return nil, err
+	}
+	rev, err := json.Marshal(revision)
+	if err != nil {
+		return nil, err
+	}
+	fields["revision"] = rev
+	raw, err := encoding.JSON.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &apitype.VersionedCheckpoint{
+		Version:    checkpoint.Version,
+		Checkpoint: raw,
+	}, nil
+}
+
+// conditionalWriteOptions returns writer options that make a write of the checkpoint file conditional on it being
+// unchanged since it was observed, for storage providers that support conditional writes.
+func conditionalWriteOptions(observed checkpointRevision) *blob.WriterOptions {
+	return &blob.WriterOptions{
+		BeforeWrite: func(asFunc func(interface{}) bool) error {
+			var obj **storage.ObjectHandle
+			if observed.generation != 0 && asFunc(&obj) {
+				*obj = (*obj).If(storage.Conditions{GenerationMatch: observed.generation})
+				return nil
+			}
+
+			var uploadOpts *azblob.UploadStreamOptions
+			if observed.etag != "" && asFunc(&uploadOpts) {
+				etag := observed.etag
+				uploadOpts.BlobAccessConditions = &azblob.BlobAccessConditions{
+					ModifiedAccessConditions: &azblob.ModifiedAccessConditions{IfMatch: &etag},
+				}
+				return nil
+			}
+
+			var conditions *WriteConditions
+			if observed.etag != "" && asFunc(&conditions) {
+				conditions.IfMatch = observed.etag
+			}
+			return nil
+		},
+	}
+}
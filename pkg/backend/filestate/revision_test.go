@@ -0,0 +1,105 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filestate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+)
+
+func TestConcurrentCheckpointModification(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := "file://" + filepath.ToSlash(t.TempDir())
+	first, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, nil)
+	require.NoError(t, err)
+	second, err := newLocalBackend(ctx, diagtest.LogSink(t), dir, nil, nil)
+	require.NoError(t, err)
+
+	ref, err := first.parseStackReference("organization/project/a")
+	require.NoError(t, err)
+	_, err = first.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	revision := func() int64 {
+		chk, err := first.getCheckpoint(ctx, ref)
+		require.NoError(t, err)
+		return chk.Revision
+	}
+	assert.Equal(t, int64(1), revision())
+
+	// Both backends load the stack, then both try to save it.
+	snap, _, err := first.getStack(ctx, ref)
+	require.NoError(t, err)
+	_, _, err = second.getStack(ctx, ref)
+	require.NoError(t, err)
+
+	_, err = second.saveStack(ctx, ref, snap, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), revision())
+
+	_, err = first.saveStack(ctx, ref, snap, nil)
+	var cmErr *ConcurrentModificationError
+	require.True(t, errors.As(err, &cmErr), "expected a concurrent modification error, got %v", err)
+	assert.Equal(t, int64(1), cmErr.Expected)
+	assert.Equal(t, int64(2), cmErr.Actual)
+	assert.Equal(t, int64(2), revision())
+
+	// The backend that saved last can keep saving, and the other can once it has reloaded the stack.
+	_, err = second.saveStack(ctx, ref, snap, nil)
+	require.NoError(t, err)
+	_, _, err = first.getStack(ctx, ref)
+	require.NoError(t, err)
+	_, err = first.saveStack(ctx, ref, snap, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), revision())
+
+	// Removing the stack forgets its revision, so that it can be created again.
+	require.NoError(t, first.removeStack(ctx, ref))
+	_, err = first.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+}
+
+func TestConditionalWriteOptions(t *testing.T) {
+	t.Parallel()
+
+	opts := conditionalWriteOptions(checkpointRevision{etag: `"0x1234"`})
+
+	var uploadOpts azblob.UploadStreamOptions
+	err := opts.BeforeWrite(func(i interface{}) bool {
+		p, ok := i.(**azblob.UploadStreamOptions)
+		if ok {
+			*p = &uploadOpts
+		}
+		return ok
+	})
+	require.NoError(t, err)
+	require.NotNil(t, uploadOpts.BlobAccessConditions)
+	require.NotNil(t, uploadOpts.BlobAccessConditions.ModifiedAccessConditions)
+	assert.Equal(t, `"0x1234"`, *uploadOpts.BlobAccessConditions.ModifiedAccessConditions.IfMatch)
+
+	// Providers that don't support conditional writes are left alone.
+	err = opts.BeforeWrite(func(i interface{}) bool { return false })
+	assert.NoError(t, err)
+}
@@ -16,9 +16,15 @@ package filestate
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/pulumi/pulumi/pkg/v3/backend"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
 	"github.com/pulumi/pulumi/pkg/v3/secrets"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 )
 
 // localSnapshotManager is a simple SnapshotManager implementation that persists snapshots
@@ -42,10 +48,143 @@ func (sp *localSnapshotPersister) Save(snapshot *deploy.Snapshot) error {
 	return err
 }
 
+// localJournalPersister is a snapshot persister that writes snapshots as delta records in the stack's checkpoint
+// journal, and compacts them into a full checkpoint periodically.
+type localJournalPersister struct {
+	*localSnapshotPersister
+
+	base     int64            // The revision of the checkpoint that the journal is based on.
+	sequence int              // The number of records in the journal.
+	last     *deploy.Snapshot // The last snapshot that was persisted.
+	enc      config.Encrypter // The encrypter for secrets in the journal, created on first use.
+
+	// Whether the base checkpoint's revision is known to be current, because this persister wrote it or has read it
+	// back since. Without ETags checking the revision means reading the whole checkpoint, so it is only done once.
+	verified bool
+
+	// The secrets providers with which journal records are encrypted at rest, if enabled.
+	providers *apitype.SecretsProvidersV1
+}
+
+var _ backend.JournalPersister = (*localJournalPersister)(nil)
+
+func (jp *localJournalPersister) Save(snapshot *deploy.Snapshot) error {
+	if err := jp.localSnapshotPersister.Save(snapshot); err != nil {
+		return err
+	}
+
+	// The new checkpoint starts a new journal, so the records of the old one can go.
+	rev, _ := jp.backend.observedCheckpoint(jp.ref.FullyQualifiedName().String())
+	jp.base, jp.sequence, jp.last, jp.verified = rev.revision, 0, snapshot, true
+	if err := jp.backend.removeJournal(jp.ctx, jp.ref); err != nil {
+		logging.V(5).Infof("removing journal of %s: %v", jp.ref.FullyQualifiedName(), err)
+	}
+	return nil
+}
+
+func (jp *localJournalPersister) SaveDelta(snapshot *deploy.Snapshot, delta backend.SnapshotDelta) error {
+	if jp.sequence >= jp.backend.journalCompaction {
+		return jp.Save(snapshot)
+	}
+
+	// Journal records are only replayed on top of the checkpoint they were based on, so make sure that it has not
+	// been replaced in the meantime.
+	stackKey := jp.ref.FullyQualifiedName().String()
+	observed, has := jp.backend.observedCheckpoint(stackKey)
+	if !has || observed.revision != jp.base {
+		return jp.Save(snapshot)
+	}
+	if observed.etag != "" || !jp.verified {
+		current, err := jp.backend.readCheckpointRevision(jp.ctx, observed.file, &observed)
+		if err != nil {
+			return fmt.Errorf("reading the current checkpoint: %w", err)
+		}
+		if current == nil || current.revision != observed.revision {
+			actual := int64(-1)
+			if current != nil {
+				actual = current.revision
+			}
+			return &ConcurrentModificationError{File: observed.file, Expected: observed.revision, Actual: actual}
+		}
+		jp.verified = true
+	}
+
+	var err error
+	if jp.enc == nil {
+		sm := jp.sm
+		if sm == nil {
+			sm = snapshot.SecretsManager
+		}
+		if sm == nil {
+			jp.enc = config.NewPanicCrypter()
+		} else if jp.enc, err = sm.Encrypter(); err != nil {
+			return fmt.Errorf("getting encrypter for journal: %w", err)
+		}
+		if jp.providers, err = secretsProvidersOf(sm); err != nil {
+			return fmt.Errorf("getting secrets providers for journal: %w", err)
+		}
+	}
+
+	rec := &journalRecord{
+		Base:     jp.base,
+		Sequence: jp.sequence + 1,
+		Manifest: snapshot.Manifest.Serialize(),
+	}
+	for _, e := range delta.Entries {
+		rec.Entries = append(rec.Entries, journalEntry{Kind: e.Kind, Op: e.Step.Op(), URN: e.Step.URN()})
+	}
+	for _, span := range delta.Spans {
+		if span.Persisted {
+			rec.Spans = append(rec.Spans, journalSpan{Start: span.Start, Count: span.Count})
+			continue
+		}
+		resources := make([]apitype.ResourceV3, 0, span.Count)
+		for _, res := range snapshot.Resources[span.Start : span.Start+span.Count] {
+			sres, err := stack.SerializeResource(res, jp.enc, false /* showSecrets */)
+			if err != nil {
+				return fmt.Errorf("serializing resources: %w", err)
+			}
+			resources = append(resources, sres)
+		}
+		rec.Spans = append(rec.Spans, journalSpan{Resources: resources})
+	}
+	for _, op := range snapshot.PendingOperations {
+		sop, err := stack.SerializeOperation(op, jp.enc, false /* showSecrets */)
+		if err != nil {
+			return err
+		}
+		rec.PendingOperations = append(rec.PendingOperations, sop)
+	}
+
+	if err = jp.backend.writeJournalRecord(jp.ctx, jp.ref, jp.providers, rec); err != nil {
+		return err
+	}
+	jp.sequence, jp.last = rec.Sequence, snapshot
+
+	if !DisableIntegrityChecking {
+		if verifyerr := snapshot.VerifyIntegrity(); verifyerr != nil {
+			return fmt.Errorf("snapshot integrity failure; it was already journaled, but is invalid: %w", verifyerr)
+		}
+	}
+	return nil
+}
+
+// compact writes the last snapshot that was persisted as a full checkpoint, if it was journaled.
+func (jp *localJournalPersister) compact() error {
+	if jp.sequence == 0 {
+		return nil
+	}
+	return jp.Save(jp.last)
+}
+
 func (b *localBackend) newSnapshotPersister(
 	ctx context.Context,
 	ref *localBackendReference,
 	sm secrets.Manager,
-) *localSnapshotPersister {
-	return &localSnapshotPersister{ctx: ctx, ref: ref, backend: b, sm: sm}
+) backend.SnapshotPersister {
+	persister := &localSnapshotPersister{ctx: ctx, ref: ref, backend: b, sm: sm}
+	if b.journalCompaction > 0 {
+		return &localJournalPersister{localSnapshotPersister: persister}
+	}
+	return persister
 }
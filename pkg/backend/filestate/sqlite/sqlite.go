@@ -0,0 +1,482 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package sqlite
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // MD5 is used as a checksum of the content, as other blob drivers do.
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	_ "github.com/mattn/go-sqlite3" // database/sql driver for sqlite3
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/driver"
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// OpenBucket opens the SQLite database at the given path as a bucket, creating it if it does not exist.
+func OpenBucket(ctx context.Context, path string) (*blob.Bucket, error) {
+	b, err := openBucket(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return blob.NewBucket(b), nil
+}
+
+// The schema of the database. Times are stored as nanoseconds since the Unix epoch.
+const schema = `
+CREATE TABLE IF NOT EXISTS objects (
+	key          TEXT PRIMARY KEY NOT NULL,
+	data         BLOB NOT NULL,
+	size         INTEGER NOT NULL,
+	content_type TEXT NOT NULL,
+	attributes   TEXT NOT NULL,
+	md5          BLOB NOT NULL,
+	etag         TEXT NOT NULL,
+	created      INTEGER NOT NULL,
+	modified     INTEGER NOT NULL
+);
+
+CREATE VIEW IF NOT EXISTS updates AS
+SELECT
+	key,
+	json_extract(CAST(data AS TEXT), '$.kind') AS kind,
+	json_extract(CAST(data AS TEXT), '$.result') AS result,
+	json_extract(CAST(data AS TEXT), '$.message') AS message,
+	json_extract(CAST(data AS TEXT), '$.version') AS version,
+	json_extract(CAST(data AS TEXT), '$.startTime') AS start_time,
+	json_extract(CAST(data AS TEXT), '$.endTime') AS end_time
+FROM objects
+WHERE key LIKE '.pulumi/history/%.history.json';
+`
+
+const defaultPageSize = 1000
+
+var (
+	errNotFound           = errors.New("blob not found")
+	errNotImplemented     = errors.New("not implemented")
+	errPreconditionFailed = errors.New("blob was modified; precondition failed")
+)
+
+type bucket struct {
+	db *sql.DB
+}
+
+func openBucket(ctx context.Context, path string) (*bucket, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+
+	// Wait for other processes rather than failing when the database is busy, and take the write lock at the start of
+	// each transaction so that transactions that read before they write cannot deadlock.
+	dsn := "file:" + filepath.ToSlash(path) + "?_busy_timeout=10000&_journal_mode=WAL&_txlock=immediate"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		contract.IgnoreClose(db)
+		return nil, fmt.Errorf("initializing %s: %w", path, err)
+	}
+	return &bucket{db: db}, nil
+}
+
+func (b *bucket) Close() error {
+	return b.db.Close()
+}
+
+func (b *bucket) ErrorCode(err error) gcerrors.ErrorCode {
+	switch {
+	case errors.Is(err, errNotFound):
+		return gcerrors.NotFound
+	case errors.Is(err, errNotImplemented):
+		return gcerrors.Unimplemented
+	case errors.Is(err, errPreconditionFailed):
+		return gcerrors.FailedPrecondition
+	default:
+		return gcerrors.Unknown
+	}
+}
+
+// As exposes the underlying *sql.DB.
+func (b *bucket) As(i interface{}) bool {
+	p, ok := i.(**sql.DB)
+	if ok {
+		*p = b.db
+	}
+	return ok
+}
+
+func (b *bucket) ErrorAs(err error, i interface{}) bool { return false }
+
+// objectAttributes are the attributes of an object that are stored as JSON in the attributes column.
+type objectAttributes struct {
+	CacheControl       string            `json:"cacheControl,omitempty"`
+	ContentDisposition string            `json:"contentDisposition,omitempty"`
+	ContentEncoding    string            `json:"contentEncoding,omitempty"`
+	ContentLanguage    string            `json:"contentLanguage,omitempty"`
+	Metadata           map[string]string `json:"metadata,omitempty"`
+}
+
+func (b *bucket) Attributes(ctx context.Context, key string) (*driver.Attributes, error) {
+	var contentType, attributes, etag string
+	var size, created, modified int64
+	var md5sum []byte
+	err := b.db.QueryRowContext(ctx,
+		"SELECT size, content_type, attributes, md5, etag, created, modified FROM objects WHERE key = ?", key,
+	).Scan(&size, &contentType, &attributes, &md5sum, &etag, &created, &modified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var attrs objectAttributes
+	if err := json.Unmarshal([]byte(attributes), &attrs); err != nil {
+		return nil, fmt.Errorf("reading attributes of %s: %w", key, err)
+	}
+	return &driver.Attributes{
+		CacheControl:       attrs.CacheControl,
+		ContentDisposition: attrs.ContentDisposition,
+		ContentEncoding:    attrs.ContentEncoding,
+		ContentLanguage:    attrs.ContentLanguage,
+		ContentType:        contentType,
+		Metadata:           attrs.Metadata,
+		CreateTime:         time.Unix(0, created),
+		ModTime:            time.Unix(0, modified),
+		Size:               size,
+		MD5:                md5sum,
+		ETag:               etag,
+	}, nil
+}
+
+// ListPaged mirrors the implementation of the memblob driver: keys are listed in order, "directories" are collapsed
+// when a delimiter is given, and the page token is the last key of the previous page.
+func (b *bucket) ListPaged(ctx context.Context, opts *driver.ListOptions) (*driver.ListPage, error) {
+	if opts.BeforeList != nil {
+		if err := opts.BeforeList(func(interface{}) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+
+	pageToken := string(opts.PageToken)
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+	}
+
+	// SQLite compares text keys bytewise, so every key with the prefix sorts at or after the prefix.
+	start := opts.Prefix
+	if pageToken > start {
+		start = pageToken
+	}
+	rows, err := b.db.QueryContext(ctx,
+		"SELECT key, size, md5, modified FROM objects WHERE key >= ? ORDER BY key", start)
+	if err != nil {
+		return nil, err
+	}
+	defer contract.IgnoreClose(rows)
+
+	var lastPrefix string
+	var result driver.ListPage
+	for rows.Next() {
+		var key string
+		var size, modified int64
+		var md5sum []byte
+		if err := rows.Scan(&key, &size, &md5sum, &modified); err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(key, opts.Prefix) {
+			break
+		}
+
+		obj := &driver.ListObject{
+			Key:     key,
+			ModTime: time.Unix(0, modified),
+			Size:    size,
+			MD5:     md5sum,
+		}
+
+		// If using Delimiter, collapse "directories".
+		if opts.Delimiter != "" {
+			keyWithoutPrefix := key[len(opts.Prefix):]
+			if idx := strings.Index(keyWithoutPrefix, opts.Delimiter); idx != -1 {
+				prefix := opts.Prefix + keyWithoutPrefix[0:idx+len(opts.Delimiter)]
+				if prefix == lastPrefix {
+					continue
+				}
+				obj = &driver.ListObject{
+					Key:   prefix,
+					IsDir: true,
+				}
+				lastPrefix = prefix
+			}
+		}
+
+		if pageToken != "" && obj.Key <= pageToken {
+			continue
+		}
+
+		if len(result.Objects) == pageSize {
+			result.NextPageToken = []byte(result.Objects[pageSize-1].Key)
+			return &result, nil
+		}
+		result.Objects = append(result.Objects, obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (b *bucket) NewRangeReader(ctx context.Context, key string, offset, length int64,
+	opts *driver.ReaderOptions,
+) (driver.Reader, error) {
+	if opts.BeforeRead != nil {
+		if err := opts.BeforeRead(func(interface{}) bool { return false }); err != nil {
+			return nil, err
+		}
+	}
+
+	var data []byte
+	var contentType string
+	var modified int64
+	err := b.db.QueryRowContext(ctx,
+		"SELECT data, content_type, modified FROM objects WHERE key = ?", key,
+	).Scan(&data, &contentType, &modified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	size := int64(len(data))
+	if offset > size {
+		offset = size
+	}
+	content := data[offset:]
+	if length >= 0 && length < int64(len(content)) {
+		content = content[:length]
+	}
+	return &reader{
+		r: bytes.NewReader(content),
+		attrs: driver.ReaderAttributes{
+			ContentType: contentType,
+			ModTime:     time.Unix(0, modified),
+			Size:        size,
+		},
+	}, nil
+}
+
+type reader struct {
+	r     io.Reader
+	attrs driver.ReaderAttributes
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *reader) Close() error {
+	return nil
+}
+
+func (r *reader) Attributes() *driver.ReaderAttributes {
+	return &r.attrs
+}
+
+func (r *reader) As(i interface{}) bool { return false }
+
+// NewTypedWriter returns a writer that buffers the object's content and stores it when the writer is closed. Writes
+// can be made conditional with a *filestate.WriteConditions, which BeforeWrite can obtain through its asFunc.
+func (b *bucket) NewTypedWriter(ctx context.Context, key, contentType string,
+	opts *driver.WriterOptions,
+) (driver.Writer, error) {
+	if key == "" {
+		return nil, errors.New("invalid key (empty string)")
+	}
+
+	var conditions filestate.WriteConditions
+	if opts.BeforeWrite != nil {
+		asFunc := func(i interface{}) bool {
+			p, ok := i.(**filestate.WriteConditions)
+			if ok {
+				*p = &conditions
+			}
+			return ok
+		}
+		if err := opts.BeforeWrite(asFunc); err != nil {
+			return nil, err
+		}
+	}
+
+	attributes, err := json.Marshal(objectAttributes{
+		CacheControl:       opts.CacheControl,
+		ContentDisposition: opts.ContentDisposition,
+		ContentEncoding:    opts.ContentEncoding,
+		ContentLanguage:    opts.ContentLanguage,
+		Metadata:           opts.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &writer{
+		ctx:         ctx,
+		b:           b,
+		key:         key,
+		contentType: contentType,
+		attributes:  string(attributes),
+		conditions:  &conditions,
+	}, nil
+}
+
+type writer struct {
+	ctx         context.Context
+	b           *bucket
+	key         string
+	contentType string
+	attributes  string
+	conditions  *filestate.WriteConditions
+	buf         bytes.Buffer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *writer) Close() error {
+	// Check if the write was cancelled.
+	if err := w.ctx.Err(); err != nil {
+		return err
+	}
+
+	// A nil slice would be stored as NULL rather than as an empty blob.
+	content := w.buf.Bytes()
+	if content == nil {
+		content = []byte{}
+	}
+	md5sum := md5.Sum(content) //nolint:gosec
+	etag, err := newETag()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+
+	if w.conditions.IfMatch != "" {
+		res, err := w.b.db.ExecContext(w.ctx, `
+			UPDATE objects
+			SET data = ?, size = ?, content_type = ?, attributes = ?, md5 = ?, etag = ?, modified = ?
+			WHERE key = ? AND etag = ?`,
+			content, len(content), w.contentType, w.attributes, md5sum[:], etag, now, w.key, w.conditions.IfMatch)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return errPreconditionFailed
+		}
+		return nil
+	}
+
+	_, err = w.b.db.ExecContext(w.ctx, `
+		INSERT INTO objects (key, data, size, content_type, attributes, md5, etag, created, modified)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			data = excluded.data, size = excluded.size, content_type = excluded.content_type,
+			attributes = excluded.attributes, md5 = excluded.md5, etag = excluded.etag, modified = excluded.modified`,
+		w.key, content, len(content), w.contentType, w.attributes, md5sum[:], etag, now, now)
+	return err
+}
+
+func (b *bucket) Copy(ctx context.Context, dstKey, srcKey string, opts *driver.CopyOptions) error {
+	if opts.BeforeCopy != nil {
+		if err := opts.BeforeCopy(func(interface{}) bool { return false }); err != nil {
+			return err
+		}
+	}
+
+	etag, err := newETag()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixNano()
+
+	// The WHERE clause is required for SQLite to parse the upsert clause of an INSERT ... SELECT.
+	res, err := b.db.ExecContext(ctx, `
+		INSERT INTO objects (key, data, size, content_type, attributes, md5, etag, created, modified)
+		SELECT ?, data, size, content_type, attributes, md5, ?, ?, ? FROM objects WHERE key = ?
+		ON CONFLICT (key) DO UPDATE SET
+			data = excluded.data, size = excluded.size, content_type = excluded.content_type,
+			attributes = excluded.attributes, md5 = excluded.md5, etag = excluded.etag, modified = excluded.modified`,
+		dstKey, etag, now, now, srcKey)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (b *bucket) Delete(ctx context.Context, key string) error {
+	res, err := b.db.ExecContext(ctx, "DELETE FROM objects WHERE key = ?", key)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (b *bucket) SignedURL(ctx context.Context, key string, opts *driver.SignedURLOptions) (string, error) {
+	return "", errNotImplemented
+}
+
+// newETag returns a new, unique ETag. ETags are random rather than derived from the content, so that an object that
+// is rewritten with the same content still fails conditional writes made against its old ETag.
+func newETag() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%q", id.String()), nil
+}
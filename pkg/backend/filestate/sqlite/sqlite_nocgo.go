@@ -0,0 +1,29 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+
+package sqlite
+
+import (
+	"context"
+
+	"gocloud.dev/blob"
+)
+
+// OpenBucket opens the SQLite database at the given path as a bucket. This binary was built without cgo, which the
+// SQLite driver requires, so it always fails with ErrCgoRequired.
+func OpenBucket(ctx context.Context, path string) (*blob.Bucket, error) {
+	return nil, ErrCgoRequired
+}
@@ -0,0 +1,38 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !cgo
+
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+)
+
+func TestBackendWithoutCgo(t *testing.T) {
+	t.Parallel()
+
+	url := "sqlite://" + filepath.ToSlash(filepath.Join(t.TempDir(), "state.db"))
+	assert.True(t, filestate.IsFileStateBackendURL(url))
+
+	_, err := filestate.New(context.Background(), diagtest.LogSink(t), url, nil)
+	assert.ErrorIs(t, err, ErrCgoRequired)
+}
@@ -0,0 +1,170 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	"gocloud.dev/blob/driver"
+	"gocloud.dev/blob/drivertest"
+	"gocloud.dev/gcerrors"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+)
+
+type harness struct {
+	path string
+}
+
+func (h *harness) MakeDriver(ctx context.Context) (driver.Bucket, error) {
+	return openBucket(ctx, h.path)
+}
+
+func (h *harness) MakeDriverForNonexistentBucket(ctx context.Context) (driver.Bucket, error) {
+	// The database is created on demand, so there is no such thing as a nonexistent bucket.
+	return nil, nil
+}
+
+func (h *harness) HTTPClient() *http.Client { return nil }
+
+func (h *harness) Close() {}
+
+//nolint:paralleltest // the conformance tests are run in parallel by drivertest.
+func TestConformance(t *testing.T) {
+	drivertest.RunConformanceTests(t, func(ctx context.Context, t *testing.T) (drivertest.Harness, error) {
+		return &harness{path: filepath.Join(t.TempDir(), "state.db")}, nil
+	}, []drivertest.AsTest{verifyAs{}})
+}
+
+// verifyAs checks that the bucket exposes its database.
+type verifyAs struct{}
+
+func (verifyAs) Name() string { return "verify As types for sqlite" }
+
+func (verifyAs) BucketCheck(b *blob.Bucket) error {
+	var db *sql.DB
+	if !b.As(&db) {
+		return errors.New("Bucket.As failed")
+	}
+	return nil
+}
+
+func (verifyAs) ErrorCheck(b *blob.Bucket, err error) error   { return nil }
+func (verifyAs) BeforeRead(as func(interface{}) bool) error   { return nil }
+func (verifyAs) BeforeWrite(as func(interface{}) bool) error  { return nil }
+func (verifyAs) BeforeCopy(as func(interface{}) bool) error   { return nil }
+func (verifyAs) BeforeList(as func(interface{}) bool) error   { return nil }
+func (verifyAs) BeforeSign(as func(interface{}) bool) error   { return nil }
+func (verifyAs) AttributesCheck(attrs *blob.Attributes) error { return nil }
+func (verifyAs) ReaderCheck(r *blob.Reader) error             { return nil }
+func (verifyAs) ListObjectCheck(o *blob.ListObject) error     { return nil }
+
+func TestConditionalWrite(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	b, err := OpenBucket(ctx, filepath.Join(t.TempDir(), "state.db"))
+	require.NoError(t, err)
+	defer b.Close()
+
+	require.NoError(t, b.WriteAll(ctx, "key", []byte("v1"), nil))
+	attrs, err := b.Attributes(ctx, "key")
+	require.NoError(t, err)
+
+	ifMatch := func(etag string) *blob.WriterOptions {
+		return &blob.WriterOptions{
+			BeforeWrite: func(asFunc func(interface{}) bool) error {
+				var conditions *filestate.WriteConditions
+				require.True(t, asFunc(&conditions))
+				conditions.IfMatch = etag
+				return nil
+			},
+		}
+	}
+
+	// A write against the current ETag succeeds, and changes the ETag even though the content is the same.
+	require.NoError(t, b.WriteAll(ctx, "key", []byte("v1"), ifMatch(attrs.ETag)))
+	newAttrs, err := b.Attributes(ctx, "key")
+	require.NoError(t, err)
+	assert.NotEqual(t, attrs.ETag, newAttrs.ETag)
+
+	// A write against the old ETag fails, and leaves the object alone.
+	err = b.WriteAll(ctx, "key", []byte("v2"), ifMatch(attrs.ETag))
+	assert.Equal(t, gcerrors.FailedPrecondition, gcerrors.Code(err))
+	data, err := b.ReadAll(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "v1", string(data))
+
+	// So does a conditional write of an object that does not exist.
+	err = b.WriteAll(ctx, "missing", []byte("v1"), ifMatch(attrs.ETag))
+	assert.Equal(t, gcerrors.FailedPrecondition, gcerrors.Code(err))
+}
+
+func TestBackend(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "state.db")
+	url := "sqlite://" + filepath.ToSlash(path)
+	assert.True(t, filestate.IsFileStateBackendURL(url))
+
+	be, err := filestate.New(ctx, diagtest.LogSink(t), url, nil)
+	require.NoError(t, err)
+
+	ref, err := be.ParseStackReference("organization/project/dev")
+	require.NoError(t, err)
+	_, err = be.CreateStack(ctx, ref, "", nil)
+	require.NoError(t, err)
+
+	// A second backend sharing the database sees the stack.
+	other, err := filestate.New(ctx, diagtest.LogSink(t), url, nil)
+	require.NoError(t, err)
+	s, err := other.GetStack(ctx, ref)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	// The stack's checkpoint is stored in the objects table.
+	var b *blob.Bucket
+	b, err = OpenBucket(ctx, path)
+	require.NoError(t, err)
+	defer b.Close()
+	var db *sql.DB
+	require.True(t, b.As(&db))
+	var count int
+	require.NoError(t, db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM objects WHERE key = '.pulumi/stacks/project/dev.json'").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// History files can be queried through the updates view.
+	require.NoError(t, b.WriteAll(ctx, ".pulumi/history/project/dev/dev-1.history.json",
+		[]byte(`{"kind":"update","result":"succeeded","version":1}`), nil))
+	var kind, result string
+	var version int
+	require.NoError(t, db.QueryRowContext(ctx, "SELECT kind, result, version FROM updates").Scan(&kind, &result, &version))
+	assert.Equal(t, "update", kind)
+	assert.Equal(t, "succeeded", result)
+	assert.Equal(t, 1, version)
+}
@@ -0,0 +1,85 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite provides a filestate storage driver that keeps all of the backend's state in a single SQLite
+// database file. Importing the package registers the driver for "sqlite://" URLs, for example:
+//
+//	$ pulumi login sqlite://~/.pulumi/state.db
+//
+// Every operation runs in a transaction, and checkpoint writes are made conditional on the checkpoint being
+// unchanged since it was loaded, so that several processes on the same machine can share the database safely.
+//
+// Each file that the filestate backend stores is a row of the objects table, keyed by its path. The updates view
+// lists the recorded history of every stack, so that it can be queried with SQL:
+//
+//	$ sqlite3 ~/.pulumi/state.db "SELECT key, kind, result, version FROM updates ORDER BY start_time"
+//
+// The driver uses cgo. In binaries built with CGO_ENABLED=0 the scheme is still recognized, but opening a database
+// fails with ErrCgoRequired.
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gocloud.dev/blob"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/filestate"
+)
+
+// Scheme is the URL scheme that the driver is registered for.
+const Scheme = "sqlite"
+
+func init() {
+	filestate.RegisterStorageDriver(Scheme, &URLOpener{})
+}
+
+// URLOpener opens URLs like "sqlite:///path/to/state.db". A path that starts with "~" is relative to the user's home
+// directory, and any other relative path is relative to the current directory. The database is created if it does
+// not exist. No query parameters are supported.
+type URLOpener struct{}
+
+// OpenBucketURL opens the SQLite database named by the given URL as a bucket.
+func (*URLOpener) OpenBucketURL(ctx context.Context, u *url.URL) (*blob.Bucket, error) {
+	for param := range u.Query() {
+		return nil, fmt.Errorf("open bucket %v: invalid query parameter %q", u, param)
+	}
+
+	path := u.Host + u.Path
+	if path == "" {
+		return nil, fmt.Errorf("open bucket %v: missing database path", u)
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("open bucket %v: %w", u, err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	path, err := filepath.Abs(filepath.FromSlash(path))
+	if err != nil {
+		return nil, fmt.Errorf("open bucket %v: %w", u, err)
+	}
+
+	return OpenBucket(ctx, path)
+}
+
+// ErrCgoRequired is returned when opening a database in a binary built without cgo, which the driver requires.
+var ErrCgoRequired = errors.New("the sqlite:// storage driver requires cgo, and this build of pulumi was built " +
+	"without it; use a pulumi binary built with CGO_ENABLED=1, or store state elsewhere")
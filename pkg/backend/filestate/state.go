@@ -160,13 +160,23 @@ func (b *localBackend) getStack(
 		}
 	}
 
+	// Remember the revision of the checkpoint so that saving it fails if another process modifies it in the meantime.
+	// The attributes are read after the checkpoint so that a concurrent write is detected rather than missed.
+	rev, err := b.checkpointAttributes(ctx, file)
+	if err != nil {
+		logging.V(5).Infof("reading attributes of %s: %v", file, err)
+	} else {
+		rev.revision = chk.Revision
+	}
+	b.observeCheckpoint(ref.FullyQualifiedName().String(), rev)
+
 	return snapshot, file, nil
 }
 
 // GetCheckpoint loads a checkpoint file for the given stack in this project, from the current project workspace.
 func (b *localBackend) getCheckpoint(ctx context.Context, ref *localBackendReference) (*apitype.CheckpointV3, error) {
 	chkpath := b.stackPath(ctx, ref)
-	bytes, err := b.bucket.ReadAll(ctx, chkpath)
+	bytes, err := b.readState(ctx, chkpath)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +185,16 @@ func (b *localBackend) getCheckpoint(ctx context.Context, ref *localBackendRefer
 		m = encoding.Gzip(m)
 	}
 
-	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(m, bytes)
+	chk, err := stack.UnmarshalVersionedCheckpointToLatestCheckpoint(m, bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply any delta records that were journaled since the checkpoint was written.
+	if err = b.replayJournal(ctx, ref, chk); err != nil {
+		return nil, fmt.Errorf("replaying checkpoint journal: %w", err)
+	}
+	return chk, nil
 }
 
 func (b *localBackend) saveCheckpoint(
@@ -201,10 +220,46 @@ func (b *localBackend) saveCheckpoint(
 		file = strings.TrimSuffix(file, ".gz")
 	}
 
+	// Check that the checkpoint has not been modified since it was loaded, and work out its next revision.
+	stackKey := ref.FullyQualifiedName().String()
+	observed, haveObserved := b.observedCheckpoint(stackKey)
+	currentFile, observedRev := file, (*checkpointRevision)(nil)
+	if haveObserved {
+		currentFile, observedRev = observed.file, &observed
+	}
+	current, err := b.readCheckpointRevision(ctx, currentFile, observedRev)
+	if err != nil {
+		return "", "", fmt.Errorf("reading the current checkpoint: %w", err)
+	}
+	var revision int64
+	if current != nil {
+		revision = current.revision
+	}
+	if haveObserved && (current == nil || current.revision != observed.revision) {
+		actual := int64(-1)
+		if current != nil {
+			actual = current.revision
+		}
+		return "", "", &ConcurrentModificationError{File: currentFile, Expected: observed.revision, Actual: actual}
+	}
+	revision++
+	if checkpoint, err = setCheckpointRevision(checkpoint, revision); err != nil {
+		return "", "", fmt.Errorf("An IO error occurred while marshalling the checkpoint: %w", err)
+	}
+
 	byts, err := m.Marshal(checkpoint)
 	if err != nil {
 		return "", "", fmt.Errorf("An IO error occurred while marshalling the checkpoint: %w", err)
 	}
+	if b.encrypt {
+		providers, err := checkpointSecretsProviders(checkpoint)
+		if err != nil {
+			return "", "", fmt.Errorf("reading the checkpoint's secrets providers: %w", err)
+		}
+		if byts, err = b.encryptState(ctx, providers, byts); err != nil {
+			return "", "", err
+		}
+	}
 
 	// Back up the existing file if it already exists. Don't delete the original, the following WriteAll will
 	// atomically replace it anyway and various other bits of the system depend on being able to find the
@@ -222,8 +277,23 @@ func (b *localBackend) saveCheckpoint(
 		backupFile = bckPlain
 	}
 
+	// Where the storage provider supports it, only replace the file if it is unchanged since it was loaded.
+	var writeOpts *blob.WriterOptions
+	if haveObserved && observed.file == file {
+		writeOpts = conditionalWriteOptions(observed)
+	}
+	concurrentModification := func(err error) error {
+		if gcerrors.Code(err) != gcerrors.FailedPrecondition {
+			return nil
+		}
+		return &ConcurrentModificationError{File: file, Expected: observed.revision, Actual: -1}
+	}
+
 	// And now write out the new snapshot file, overwriting that location.
-	if err = b.bucket.WriteAll(ctx, file, byts, nil); err != nil {
+	if err = b.bucket.WriteAll(ctx, file, byts, writeOpts); err != nil {
+		if cmErr := concurrentModification(err); cmErr != nil {
+			return backupFile, "", cmErr
+		}
 
 		b.mutex.Lock()
 		defer b.mutex.Unlock()
@@ -240,9 +310,12 @@ func (b *localBackend) saveCheckpoint(
 			Backoff:  &backoff,
 			Accept: func(try int, nextRetryTime time.Duration) (bool, interface{}, error) {
 				// And now write out the new snapshot file, overwriting that location.
-				err := b.bucket.WriteAll(ctx, file, byts, nil)
+				err := b.bucket.WriteAll(ctx, file, byts, writeOpts)
 				if err != nil {
 					logging.V(7).Infof("Error while writing snapshot to: %s (attempt=%d, error=%s)", file, try, err)
+					if cmErr := concurrentModification(err); cmErr != nil {
+						return false, nil, cmErr
+					}
 					if try > 10 {
 						return false, nil, fmt.Errorf("An IO error occurred while writing the new snapshot file: %w", err)
 					}
@@ -256,6 +329,15 @@ func (b *localBackend) saveCheckpoint(
 		}
 	}
 
+	// Record the revision that was just written, so that the next save can check that it is still current.
+	rev, err := b.checkpointAttributes(ctx, file)
+	if err != nil {
+		logging.V(5).Infof("reading attributes of %s: %v", file, err)
+		rev = &checkpointRevision{file: file}
+	}
+	rev.revision = revision
+	b.observeCheckpoint(stackKey, rev)
+
 	logging.V(7).Infof("Saved stack %s checkpoint to: %s (backup=%s)", ref.FullyQualifiedName(), file, backupFile)
 
 	// And if we are retaining historical checkpoint information, write it out again
@@ -305,6 +387,10 @@ func (b *localBackend) removeStack(ctx context.Context, ref *localBackendReferen
 	// Just make a backup of the file and don't write out anything new.
 	file := b.stackPath(ctx, ref)
 	backupTarget(ctx, b.bucket, file, false)
+	b.observeCheckpoint(ref.FullyQualifiedName().String(), nil)
+	if err := b.removeJournal(ctx, ref); err != nil {
+		return err
+	}
 
 	historyDir := ref.HistoryDir()
 	return removeAllByPrefix(ctx, b.bucket, historyDir)
@@ -417,34 +503,15 @@ func (b *localBackend) getHistory(
 ) ([]backend.UpdateInfo, error) {
 	contract.Requiref(stack != nil, "stack", "must not be nil")
 
-	dir := stack.HistoryDir()
-	// TODO: we could consider optimizing the list operation using `page` and `pageSize`.
-	// Unfortunately, this is mildly invasive given the gocloud List API.
-	allFiles, err := listBucket(ctx, b.bucket, dir)
+	historyFiles, err := b.listHistoryFiles(ctx, stack)
 	if err != nil {
-		// History doesn't exist until a stack has been updated.
-		if gcerrors.Code(err) == gcerrors.NotFound {
-			return nil, nil
-		}
 		return nil, err
 	}
 
-	var historyEntries []*blob.ListObject
-
-	// filter down to just history entries, reversing list to be in most recent order.
-	// listBucket returns the array sorted by file name, but because of how we name files, older updates come before
-	// newer ones.
-	for i := len(allFiles) - 1; i >= 0; i-- {
-		file := allFiles[i]
-		filepath := file.Key
-
-		// ignore checkpoints
-		if !strings.HasSuffix(filepath, ".history.json") &&
-			!strings.HasSuffix(filepath, ".history.json.gz") {
-			continue
-		}
-
-		historyEntries = append(historyEntries, file)
+	// Reverse the list to be in most recent order.
+	historyEntries := make([]*blob.ListObject, 0, len(historyFiles))
+	for i := len(historyFiles) - 1; i >= 0; i-- {
+		historyEntries = append(historyEntries, historyFiles[i])
 	}
 
 	start := 0
@@ -467,7 +534,7 @@ func (b *localBackend) getHistory(
 		filepath := file.Key
 
 		var update backend.UpdateInfo
-		b, err := b.bucket.ReadAll(ctx, filepath)
+		b, err := b.readState(ctx, filepath)
 		if err != nil {
 			return nil, fmt.Errorf("reading history file %s: %w", filepath, err)
 		}
@@ -479,6 +546,8 @@ func (b *localBackend) getHistory(
 		if err != nil {
 			return nil, fmt.Errorf("reading history file %s: %w", filepath, err)
 		}
+		// Updates are numbered from 1 in the order in which they happened, matching the versions used by the service.
+		update.Version = len(historyEntries) - i
 
 		updates = append(updates, update)
 	}
@@ -486,6 +555,67 @@ func (b *localBackend) getHistory(
 	return updates, nil
 }
 
+// listHistoryFiles returns the update history files of the given stack, oldest first. Each history file has a
+// matching checkpoint file recording the state of the stack after the update; see historyCheckpointPath.
+func (b *localBackend) listHistoryFiles(ctx context.Context, ref *localBackendReference) ([]*blob.ListObject, error) {
+	dir := ref.HistoryDir()
+	// TODO: we could consider optimizing the list operation using `page` and `pageSize`.
+	// Unfortunately, this is mildly invasive given the gocloud List API.
+	allFiles, err := listBucket(ctx, b.bucket, dir)
+	if err != nil {
+		// History doesn't exist until a stack has been updated.
+		if gcerrors.Code(err) == gcerrors.NotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	// Filter down to just history entries. listBucket returns the array sorted by file name, and because of how we
+	// name files, older updates come before newer ones.
+	var historyFiles []*blob.ListObject
+	for _, file := range allFiles {
+		// ignore checkpoints
+		if !strings.HasSuffix(file.Key, ".history.json") &&
+			!strings.HasSuffix(file.Key, ".history.json.gz") {
+			continue
+		}
+		historyFiles = append(historyFiles, file)
+	}
+	return historyFiles, nil
+}
+
+// historyCheckpointPath returns the path of the checkpoint that addToHistory saved alongside the given history file.
+func historyCheckpointPath(historyFile string) string {
+	return strings.Replace(historyFile, ".history.", ".checkpoint.", 1)
+}
+
+// getHistoricalCheckpoint loads the checkpoint of the stack as it was after the update with the given version, where
+// the first update of a stack has version 1.
+func (b *localBackend) getHistoricalCheckpoint(
+	ctx context.Context, ref *localBackendReference, version int,
+) (*apitype.CheckpointV3, error) {
+	historyFiles, err := b.listHistoryFiles(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > len(historyFiles) {
+		return nil, fmt.Errorf("version %d of stack %s does not exist; the stack has %d updates in its history",
+			version, ref.FullyQualifiedName(), len(historyFiles))
+	}
+
+	chkpath := historyCheckpointPath(historyFiles[version-1].Key)
+	bytes, err := b.readState(ctx, chkpath)
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint for version %d: %w", version, err)
+	}
+	m := encoding.JSON
+	if encoding.IsCompressed(bytes) {
+		m = encoding.Gzip(m)
+	}
+
+	return stack.UnmarshalVersionedCheckpointToLatestCheckpoint(m, bytes)
+}
+
 func (b *localBackend) renameHistory(ctx context.Context, oldName, newName *localBackendReference) error {
 	contract.Requiref(oldName != nil, "oldName", "must not be nil")
 	contract.Requiref(newName != nil, "newName", "must not be nil")
@@ -544,11 +674,20 @@ func (b *localBackend) addToHistory(ctx context.Context, ref *localBackendRefere
 		ext += ".gz"
 	}
 
-	// Save the history file.
+	// Save the history file, encrypted in the same way as the checkpoint.
 	byts, err := m.Marshal(&update)
 	if err != nil {
 		return err
 	}
+	if b.encrypt {
+		providers, err := b.stateSecretsProviders(ctx, b.stackPath(ctx, ref))
+		if err != nil {
+			return err
+		}
+		if byts, err = b.encryptState(ctx, providers, byts); err != nil {
+			return err
+		}
+	}
 
 	historyFile := fmt.Sprintf("%s.history.%s", pathPrefix, ext)
 	if err = b.bucket.WriteAll(ctx, historyFile, byts, nil); err != nil {
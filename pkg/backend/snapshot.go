@@ -41,6 +41,37 @@ type SnapshotPersister interface {
 	SecretsManager() secrets.Manager
 }
 
+// JournalPersister is implemented by snapshot persisters that can persist a snapshot as a delta from the snapshot
+// that they last persisted, rather than rewriting the whole snapshot after every mutation. The first snapshot of a
+// SnapshotManager is always persisted with Save, as are snapshots whose changes cannot be described as a delta.
+type JournalPersister interface {
+	SnapshotPersister
+
+	// SaveDelta persists the given snapshot, whose resources are described by the given delta from the last snapshot
+	// that was persisted. Returns an error if the persistence failed.
+	SaveDelta(snapshot *deploy.Snapshot, delta SnapshotDelta) error
+}
+
+// SnapshotDelta describes the resources of a snapshot in terms of the resources of the last persisted snapshot.
+type SnapshotDelta struct {
+	// Entries are the journal entries for the mutations made since the last snapshot was persisted.
+	Entries []engine.JournalEntry
+	// Spans make up the resources of the snapshot, in order.
+	Spans []SnapshotSpan
+}
+
+// SnapshotSpan is a run of consecutive resources in a snapshot.
+type SnapshotSpan struct {
+	// Persisted is true if the resources are unchanged since the last snapshot was persisted, in which case Start is
+	// the index of the first resource in the last persisted snapshot. Otherwise, Start is the index of the first
+	// resource in the new snapshot.
+	Persisted bool
+	// Start is the index of the first resource of the span.
+	Start int
+	// Count is the number of resources in the span.
+	Count int
+}
+
 // SnapshotManager is an implementation of engine.SnapshotManager that inspects steps and performs
 // mutations on the global snapshot object serially. This implementation maintains two bits of state: the "base"
 // snapshot, which is completely immutable and represents the state of the world prior to the application
@@ -58,6 +89,9 @@ type SnapshotPersister interface {
 // that it creates and expects those mutations to be persisted directly to the snapshot.
 type SnapshotManager struct {
 	persister        SnapshotPersister        // The persister responsible for invalidating and persisting the snapshot
+	journal          JournalPersister         // The persister, if it can persist deltas
+	entries          []engine.JournalEntry    // The journal entries for mutations made since the last persist
+	persisted        []*resource.State        // The resources of the last persisted snapshot, if journaling
 	baseSnapshot     *deploy.Snapshot         // The base snapshot for this plan
 	resources        []*resource.State        // The list of resources operated upon by this plan
 	operations       []resource.Operation     // The set of operations known to be outstanding in this plan
@@ -72,6 +106,7 @@ type SnapshotManager struct {
 var _ engine.SnapshotManager = (*SnapshotManager)(nil)
 
 type mutationRequest struct {
+	entry   engine.JournalEntry
 	mutator func() bool
 	result  chan<- error
 }
@@ -96,12 +131,15 @@ func (sm *SnapshotManager) Close() error {
 // meaningful changes (see sameSnapshotMutation.mustWrite for details). Any elided writes
 // are flushed by the next non-elided write or the next call to Close.
 //
+// Each mutation is described by a journal entry, which persisters that support journaling use
+// to persist only the parts of the snapshot that have changed.
+//
 // You should never observe or mutate the global snapshot without using this function unless
 // you have a very good justification.
-func (sm *SnapshotManager) mutate(mutator func() bool) error {
+func (sm *SnapshotManager) mutate(entry engine.JournalEntry, mutator func() bool) error {
 	result := make(chan error)
 	select {
-	case sm.mutationRequests <- mutationRequest{mutator: mutator, result: result}:
+	case sm.mutationRequests <- mutationRequest{entry: entry, mutator: mutator, result: result}:
 		return <-result
 	case <-sm.cancel:
 		return errors.New("snapshot manager closed")
@@ -120,7 +158,7 @@ func (sm *SnapshotManager) mutate(mutator func() bool) error {
 // Note that this is completely not thread-safe and defeats the purpose of having a `mutate` callback
 // entirely, but the hope is that this state of things will not be permament.
 func (sm *SnapshotManager) RegisterResourceOutputs(step deploy.Step) error {
-	return sm.mutate(func() bool { return true })
+	return sm.mutate(engine.JournalEntry{Kind: engine.JournalEntryOutputs, Step: step}, func() bool { return true })
 }
 
 // BeginMutation signals to the SnapshotManager that the engine intends to mutate the global snapshot
@@ -282,7 +320,7 @@ func (ssm *sameSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Requiref(step.Op() == deploy.OpSame, "step.Op()", "must be %q, got %q", deploy.OpSame, step.Op())
 	contract.Assertf(successful, "expected mutation to be successful")
 	logging.V(9).Infof("SnapshotManager: sameSnapshotMutation.End(..., %v)", successful)
-	return ssm.manager.mutate(func() bool {
+	return ssm.manager.mutate(endEntry(step, successful), func() bool {
 		sameStep := step.(*deploy.SameStep)
 
 		ssm.manager.markDone(step.Old())
@@ -314,7 +352,7 @@ func (ssm *sameSnapshotMutation) End(step deploy.Step, successful bool) error {
 
 func (sm *SnapshotManager) doCreate(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doCreate(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(engine.JournalEntry{Kind: engine.JournalEntryBegin, Step: step}, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeCreating)
 		return true
 	})
@@ -332,7 +370,7 @@ type createSnapshotMutation struct {
 func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Requiref(step != nil, "step", "must not be nil")
 	logging.V(9).Infof("SnapshotManager: createSnapshotMutation.End(..., %v)", successful)
-	return csm.manager.mutate(func() bool {
+	return csm.manager.mutate(endEntry(step, successful), func() bool {
 		csm.manager.markOperationComplete(step.New())
 		if successful {
 			// There is some very subtle behind-the-scenes magic here that
@@ -358,7 +396,7 @@ func (csm *createSnapshotMutation) End(step deploy.Step, successful bool) error
 
 func (sm *SnapshotManager) doUpdate(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doUpdate(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(engine.JournalEntry{Kind: engine.JournalEntryBegin, Step: step}, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeUpdating)
 		return true
 	})
@@ -376,7 +414,7 @@ type updateSnapshotMutation struct {
 func (usm *updateSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Requiref(step != nil, "step", "must not be nil")
 	logging.V(9).Infof("SnapshotManager: updateSnapshotMutation.End(..., %v)", successful)
-	return usm.manager.mutate(func() bool {
+	return usm.manager.mutate(endEntry(step, successful), func() bool {
 		usm.manager.markOperationComplete(step.New())
 		if successful {
 			usm.manager.markDone(step.Old())
@@ -388,7 +426,7 @@ func (usm *updateSnapshotMutation) End(step deploy.Step, successful bool) error
 
 func (sm *SnapshotManager) doDelete(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doDelete(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(engine.JournalEntry{Kind: engine.JournalEntryBegin, Step: step}, func() bool {
 		sm.markOperationPending(step.Old(), resource.OperationTypeDeleting)
 		return true
 	})
@@ -406,7 +444,7 @@ type deleteSnapshotMutation struct {
 func (dsm *deleteSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Requiref(step != nil, "step", "must not be nil")
 	logging.V(9).Infof("SnapshotManager: deleteSnapshotMutation.End(..., %v)", successful)
-	return dsm.manager.mutate(func() bool {
+	return dsm.manager.mutate(endEntry(step, successful), func() bool {
 		dsm.manager.markOperationComplete(step.Old())
 		if successful {
 			contract.Assertf(
@@ -435,7 +473,7 @@ func (rsm *replaceSnapshotMutation) End(step deploy.Step, successful bool) error
 
 func (sm *SnapshotManager) doRead(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doRead(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(engine.JournalEntry{Kind: engine.JournalEntryBegin, Step: step}, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeReading)
 		return true
 	})
@@ -453,7 +491,7 @@ type readSnapshotMutation struct {
 func (rsm *readSnapshotMutation) End(step deploy.Step, successful bool) error {
 	contract.Requiref(step != nil, "step", "must not be nil")
 	logging.V(9).Infof("SnapshotManager: readSnapshotMutation.End(..., %v)", successful)
-	return rsm.manager.mutate(func() bool {
+	return rsm.manager.mutate(endEntry(step, successful), func() bool {
 		rsm.manager.markOperationComplete(step.New())
 		if successful {
 			if step.Old() != nil {
@@ -474,7 +512,7 @@ func (rsm *refreshSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Requiref(step != nil, "step", "must not be nil")
 	contract.Requiref(step.Op() == deploy.OpRefresh, "step.Op", "must be %q, got %q", deploy.OpRefresh, step.Op())
 	logging.V(9).Infof("SnapshotManager: refreshSnapshotMutation.End(..., %v)", successful)
-	return rsm.manager.mutate(func() bool {
+	return rsm.manager.mutate(endEntry(step, successful), func() bool {
 		// We always elide refreshes. The expectation is that all of these run before any actual mutations and that
 		// some other component will rewrite the base snapshot in-memory, so there's no action the snapshot
 		// manager needs to take other than to remember that the base snapshot--and therefore the actual snapshot--may
@@ -491,7 +529,7 @@ func (rsm *removePendingReplaceSnapshotMutation) End(step deploy.Step, successfu
 	contract.Requiref(step != nil, "step", "must not be nil")
 	contract.Requiref(step.Op() == deploy.OpRemovePendingReplace, "step.Op",
 		"must be %q, got %q", deploy.OpRemovePendingReplace, step.Op())
-	return rsm.manager.mutate(func() bool {
+	return rsm.manager.mutate(endEntry(step, successful), func() bool {
 		res := step.Old()
 		contract.Assertf(res.PendingReplacement, "resource %q must be pending replacement", res.URN)
 		rsm.manager.markDone(res)
@@ -501,7 +539,7 @@ func (rsm *removePendingReplaceSnapshotMutation) End(step deploy.Step, successfu
 
 func (sm *SnapshotManager) doImport(step deploy.Step) (engine.SnapshotMutation, error) {
 	logging.V(9).Infof("SnapshotManager.doImport(%s)", step.URN())
-	err := sm.mutate(func() bool {
+	err := sm.mutate(engine.JournalEntry{Kind: engine.JournalEntryBegin, Step: step}, func() bool {
 		sm.markOperationPending(step.New(), resource.OperationTypeImporting)
 		return true
 	})
@@ -521,7 +559,7 @@ func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error
 	contract.Requiref(step.Op() == deploy.OpImport || step.Op() == deploy.OpImportReplacement, "step.Op",
 		"must be %q or %q, got %q", deploy.OpImport, deploy.OpImportReplacement, step.Op())
 
-	return ism.manager.mutate(func() bool {
+	return ism.manager.mutate(endEntry(step, successful), func() bool {
 		ism.manager.markOperationComplete(step.New())
 		if successful {
 			ism.manager.markNew(step.New())
@@ -530,6 +568,15 @@ func (ism *importSnapshotMutation) End(step deploy.Step, successful bool) error
 	})
 }
 
+// endEntry returns the journal entry that records the end of the given step.
+func endEntry(step deploy.Step, successful bool) engine.JournalEntry {
+	kind := engine.JournalEntryFailure
+	if successful {
+		kind = engine.JournalEntrySuccess
+	}
+	return engine.JournalEntry{Kind: kind, Step: step}
+}
+
 // markDone marks a resource as having been processed. Resources that have been marked
 // in this manner won't be persisted in the snapshot.
 func (sm *SnapshotManager) markDone(state *resource.State) {
@@ -637,13 +684,22 @@ func (sm *SnapshotManager) snap() *deploy.Snapshot {
 
 // saveSnapshot persists the current snapshot and optionally verifies it afterwards.
 func (sm *SnapshotManager) saveSnapshot() error {
-	snap, err := sm.snap().NormalizeURNReferences()
+	current := sm.snap()
+	snap, err := current.NormalizeURNReferences()
 	if err != nil {
 		return fmt.Errorf("failed to normalize URN references: %w", err)
 	}
-	if err := sm.persister.Save(snap); err != nil {
+	if delta, ok := sm.delta(current.Resources); ok {
+		err = sm.journal.SaveDelta(snap, delta)
+	} else {
+		err = sm.persister.Save(snap)
+	}
+	if err != nil {
 		return fmt.Errorf("failed to save snapshot: %w", err)
 	}
+	if sm.journal != nil {
+		sm.persisted, sm.entries = current.Resources, nil
+	}
 	if sm.doVerify {
 		if err := snap.VerifyIntegrity(); err != nil {
 			return fmt.Errorf("failed to verify snapshot: %w", err)
@@ -652,6 +708,63 @@ func (sm *SnapshotManager) saveSnapshot() error {
 	return nil
 }
 
+// delta describes the given resources in terms of the resources of the last persisted snapshot, for persisters that
+// support journaling. It returns false if the snapshot must be persisted in full.
+//
+// Resources are matched by identity. The engine mutates the states of the resources that a step operates on in place,
+// so any resource that is the old or new state of a step that was journaled since the last persist is treated as
+// changed, wherever it appears in the snapshot.
+func (sm *SnapshotManager) delta(resources []*resource.State) (SnapshotDelta, bool) {
+	if sm.journal == nil || sm.persisted == nil {
+		return SnapshotDelta{}, false
+	}
+
+	touched := make(map[*resource.State]bool)
+	for _, e := range sm.entries {
+		// Refreshes rewrite the dependencies of arbitrary resources in the base snapshot in place.
+		if e.Step.Op() == deploy.OpRefresh {
+			return SnapshotDelta{}, false
+		}
+		if old := e.Step.Old(); old != nil {
+			touched[old] = true
+		}
+		if new := e.Step.New(); new != nil {
+			touched[new] = true
+		}
+	}
+
+	persisted := make(map[*resource.State]int, len(sm.persisted))
+	for i, res := range sm.persisted {
+		persisted[res] = i
+	}
+
+	var spans []SnapshotSpan
+	for i, res := range resources {
+		j, has := persisted[res]
+		unchanged := has && !touched[res]
+
+		// The aliases of a resource affect how references to it are normalized throughout the snapshot, so a change
+		// to a resource with aliases may change resources that are otherwise unchanged.
+		if !unchanged && len(res.Aliases) > 0 {
+			return SnapshotDelta{}, false
+		}
+
+		if n := len(spans); n > 0 {
+			last := &spans[n-1]
+			if unchanged && last.Persisted && last.Start+last.Count == j || !unchanged && !last.Persisted {
+				last.Count++
+				continue
+			}
+		}
+		span := SnapshotSpan{Persisted: unchanged, Start: i, Count: 1}
+		if unchanged {
+			span.Start = j
+		}
+		spans = append(spans, span)
+	}
+	return SnapshotDelta{Entries: sm.entries, Spans: spans}, true
+}
+
 // defaultServiceLoop saves a Snapshot whenever a mutation occurs
 func (sm *SnapshotManager) defaultServiceLoop(mutationRequests chan mutationRequest, done chan error) {
 	// True if we have elided writes since the last actual write.
@@ -663,7 +776,9 @@ serviceLoop:
 		select {
 		case request := <-mutationRequests:
 			var err error
-			if request.mutator() {
+			mustWrite := request.mutator()
+			sm.recordEntry(request.entry)
+			if mustWrite {
 				err = sm.saveSnapshot()
 				hasElidedWrites = false
 			} else {
@@ -684,6 +799,13 @@ serviceLoop:
 	done <- err
 }
 
+// recordEntry records the journal entry of a mutation, if the persister supports journaling.
+func (sm *SnapshotManager) recordEntry(entry engine.JournalEntry) {
+	if sm.journal != nil {
+		sm.entries = append(sm.entries, entry)
+	}
+}
+
 // unsafeServiceLoop doesn't save Snapshots when mutations occur and instead saves Snapshots when
 // SnapshotManager.Close() is invoked. It trades reliability for speed as every mutation does not
 // cause a Snapshot to be serialized to the user's state backend.
@@ -692,6 +814,7 @@ func (sm *SnapshotManager) unsafeServiceLoop(mutationRequests chan mutationReque
 		select {
 		case request := <-mutationRequests:
 			request.mutator()
+			sm.recordEntry(request.entry)
 			request.result <- nil
 		case <-sm.cancel:
 			done <- sm.saveSnapshot()
@@ -709,8 +832,10 @@ func (sm *SnapshotManager) unsafeServiceLoop(mutationRequests chan mutationReque
 func NewSnapshotManager(persister SnapshotPersister, baseSnap *deploy.Snapshot) *SnapshotManager {
 	mutationRequests, cancel, done := make(chan mutationRequest), make(chan bool), make(chan error)
 
+	journal, _ := persister.(JournalPersister)
 	manager := &SnapshotManager{
 		persister:        persister,
+		journal:          journal,
 		baseSnapshot:     baseSnap,
 		dones:            make(map[*resource.State]bool),
 		completeOps:      make(map[*resource.State]bool),
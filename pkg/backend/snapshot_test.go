@@ -19,17 +19,20 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
 	"github.com/pulumi/pulumi/pkg/v3/secrets"
 	"github.com/pulumi/pulumi/pkg/v3/secrets/b64"
 	"github.com/pulumi/pulumi/pkg/v3/version"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/env"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 )
 
 type MockRegisterResourceEvent struct {
@@ -1048,3 +1051,129 @@ func TestRegisterOutputs(t *testing.T) {
 	assert.Len(t, lastSnap.Resources, 1)
 	assert.Equal(t, resourceA.URN, lastSnap.Resources[0].URN)
 }
+
+// MockJournalPersister is a MockStackPersister that supports journaling. It keeps its own copy of the persisted
+// resources, which it updates by replaying each delta that it is given.
+type MockJournalPersister struct {
+	MockStackPersister
+	Deltas    []SnapshotDelta
+	Resources []apitype.ResourceV3
+}
+
+func (m *MockJournalPersister) Save(snap *deploy.Snapshot) error {
+	m.SavedSnapshots = append(m.SavedSnapshots, snap)
+	m.Resources = serializeResources(snap.Resources)
+	return nil
+}
+
+func (m *MockJournalPersister) SaveDelta(snap *deploy.Snapshot, delta SnapshotDelta) error {
+	m.SavedSnapshots = append(m.SavedSnapshots, snap)
+	m.Deltas = append(m.Deltas, delta)
+	var resources []apitype.ResourceV3
+	for _, span := range delta.Spans {
+		if span.Persisted {
+			resources = append(resources, m.Resources[span.Start:span.Start+span.Count]...)
+		} else {
+			resources = append(resources, serializeResources(snap.Resources[span.Start:span.Start+span.Count])...)
+		}
+	}
+	m.Resources = resources
+	return nil
+}
+
+func serializeResources(resources []*resource.State) []apitype.ResourceV3 {
+	serialized := make([]apitype.ResourceV3, 0, len(resources))
+	for _, res := range resources {
+		sres, err := stack.SerializeResource(res, config.BlindingCrypter, false)
+		contract.AssertNoErrorf(err, "serializing %v", res.URN)
+		serialized = append(serialized, sres)
+	}
+	return serialized
+}
+
+func TestJournalDeltas(t *testing.T) {
+	t.Parallel()
+
+	a := NewResource("a")
+	b := NewResource("b", a.URN)
+	c := NewResource("c", a.URN, b.URN)
+	d := NewResource("d", c.URN)
+	e := NewResource("e", c.URN)
+	snap := NewSnapshot([]*resource.State{a, b, c, d, e})
+	require.NoError(t, snap.VerifyIntegrity())
+
+	sp := &MockJournalPersister{}
+	manager := NewSnapshotManager(sp, snap)
+
+	// After every step, the resources replayed from the deltas must match the resources of the snapshot.
+	checkReplay := func() {
+		if len(sp.SavedSnapshots) > 0 {
+			assert.Equal(t, serializeResources(sp.LastSnap().Resources), sp.Resources)
+		}
+	}
+	applyStep := func(step deploy.Step) {
+		mutation, err := manager.BeginMutation(step)
+		require.NoError(t, err)
+		checkReplay()
+		require.NoError(t, mutation.End(step, true))
+		checkReplay()
+	}
+
+	bPrime := NewResource(string(b.URN))
+	applyStep(deploy.NewSameStep(nil, MockRegisterResourceEvent{}, b, bPrime))
+
+	cPrime := NewResource(string(c.URN), bPrime.URN)
+	createReplacement := deploy.NewCreateReplacementStep(nil, MockRegisterResourceEvent{}, c, cPrime, nil, nil, nil, true)
+	replace := deploy.NewReplaceStep(nil, c, cPrime, nil, nil, nil, true)
+	c.Delete = true
+	applyStep(createReplacement)
+	applyStep(replace)
+
+	dPrime := NewResource(string(d.URN), cPrime.URN)
+	applyStep(deploy.NewUpdateStep(nil, MockRegisterResourceEvent{}, d, dPrime, nil, nil, nil, nil))
+
+	// The engine mutates the outputs of a resource in place before registering them.
+	dPrime.Outputs = resource.PropertyMap{"foo": resource.NewStringProperty("bar")}
+	require.NoError(t, manager.RegisterResourceOutputs(deploy.NewSameStep(nil, nil, dPrime, dPrime)))
+	checkReplay()
+
+	applyStep(deploy.NewDeleteReplacementStep(nil, map[resource.URN]bool{}, c, false))
+	applyStep(deploy.NewDeleteStep(nil, map[resource.URN]bool{}, e))
+	require.NoError(t, manager.Close())
+	checkReplay()
+
+	// Only the first snapshot was persisted in full, and every delta reused some of the persisted resources.
+	require.Len(t, sp.Deltas, len(sp.SavedSnapshots)-1)
+	for _, delta := range sp.Deltas {
+		assert.NotEmpty(t, delta.Entries)
+		persisted := 0
+		for _, span := range delta.Spans {
+			if span.Persisted {
+				persisted += span.Count
+			}
+		}
+		assert.NotZero(t, persisted)
+	}
+}
+
+func TestJournalRefreshSavesInFull(t *testing.T) {
+	t.Parallel()
+
+	a := NewResource("a")
+	snap := NewSnapshot([]*resource.State{a})
+	sp := &MockJournalPersister{}
+	manager := NewSnapshotManager(sp, snap)
+
+	step := deploy.NewSameStep(nil, MockRegisterResourceEvent{}, a, a)
+	require.NoError(t, manager.RegisterResourceOutputs(step))
+	assert.Len(t, sp.SavedSnapshots, 1)
+
+	// Refreshes rewrite the base snapshot in place, so the next snapshot is persisted in full.
+	refresh := deploy.NewRefreshStep(nil, a, nil)
+	mutation, err := manager.BeginMutation(refresh)
+	require.NoError(t, err)
+	require.NoError(t, mutation.End(refresh, true))
+	require.NoError(t, manager.RegisterResourceOutputs(step))
+	assert.Len(t, sp.Deltas, 0)
+	assert.Len(t, sp.SavedSnapshots, 2)
+}
@@ -0,0 +1,196 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optwatch"
+)
+
+// automationAPIOptions maps each flag of the commands that the Go Automation API provides to the option that sets it.
+// Referring to the options themselves, rather than naming them, means that this fails to compile if one is removed.
+var automationAPIOptions = map[string]map[string]interface{}{
+	"up": {
+		"config-file":            optup.ConfigFile,
+		"continue-on-error":      optup.ContinueOnError,
+		"debug":                  optup.DebugLogging,
+		"diff":                   optup.Diff,
+		"exclude":                optup.Exclude,
+		"exclude-dependents":     optup.ExcludeDependents,
+		"exec-agent":             optup.UserAgent,
+		"expect-no-changes":      optup.ExpectNoChanges,
+		"message":                optup.Message,
+		"parallel":               optup.Parallel,
+		"plan":                   optup.Plan,
+		"policy-pack":            optup.PolicyPacks,
+		"policy-pack-config":     optup.PolicyPackConfigs,
+		"refresh":                optup.Refresh,
+		"replace":                optup.Replace,
+		"show-config":            optup.ShowConfig,
+		"show-full-output":       optup.ShowFullOutput,
+		"show-reads":             optup.ShowReads,
+		"show-replacement-steps": optup.ShowReplacementSteps,
+		"show-sames":             optup.ShowSames,
+		"suppress-outputs":       optup.SuppressOutputs,
+		"suppress-permalink":     optup.SuppressPermalink,
+		"target":                 optup.Target,
+		"target-dependents":      optup.TargetDependents,
+		"target-replace":         optup.TargetReplace,
+		"timeout":                optup.Timeout,
+		"timeout-grace-period":   optup.TimeoutGracePeriod,
+	},
+	"preview": {
+		"config-file":            optpreview.ConfigFile,
+		"debug":                  optpreview.DebugLogging,
+		"diff":                   optpreview.Diff,
+		"exclude":                optpreview.Exclude,
+		"exclude-dependents":     optpreview.ExcludeDependents,
+		"exec-agent":             optpreview.UserAgent,
+		"expect-no-changes":      optpreview.ExpectNoChanges,
+		"message":                optpreview.Message,
+		"parallel":               optpreview.Parallel,
+		"plan":                   optpreview.ValidatePlan,
+		"policy-pack":            optpreview.PolicyPacks,
+		"policy-pack-config":     optpreview.PolicyPackConfigs,
+		"refresh":                optpreview.Refresh,
+		"replace":                optpreview.Replace,
+		"save-plan":              optpreview.Plan,
+		"show-config":            optpreview.ShowConfig,
+		"show-reads":             optpreview.ShowReads,
+		"show-replacement-steps": optpreview.ShowReplacementSteps,
+		"show-sames":             optpreview.ShowSames,
+		"show-secrets":           optpreview.ShowSecrets,
+		"suppress-outputs":       optpreview.SuppressOutputs,
+		"suppress-permalink":     optpreview.SuppressPermalink,
+		"target":                 optpreview.Target,
+		"target-dependents":      optpreview.TargetDependents,
+		"target-replace":         optpreview.TargetReplace,
+	},
+	"refresh": {
+		"clear-pending-creates":  optrefresh.ClearPendingCreates,
+		"config-file":            optrefresh.ConfigFile,
+		"debug":                  optrefresh.DebugLogging,
+		"diff":                   optrefresh.Diff,
+		"exclude":                optrefresh.Exclude,
+		"exclude-dependents":     optrefresh.ExcludeDependents,
+		"exec-agent":             optrefresh.UserAgent,
+		"expect-no-changes":      optrefresh.ExpectNoChanges,
+		"import-pending-creates": optrefresh.ImportPendingCreates,
+		"message":                optrefresh.Message,
+		"parallel":               optrefresh.Parallel,
+		"show-replacement-steps": optrefresh.ShowReplacementSteps,
+		"show-sames":             optrefresh.ShowSames,
+		"skip-pending-creates":   optrefresh.SkipPendingCreates,
+		"suppress-outputs":       optrefresh.SuppressOutputs,
+		"suppress-permalink":     optrefresh.SuppressPermalink,
+		"target":                 optrefresh.Target,
+		"timeout":                optrefresh.Timeout,
+		"timeout-grace-period":   optrefresh.TimeoutGracePeriod,
+	},
+	"destroy": {
+		"config-file":            optdestroy.ConfigFile,
+		"continue-on-error":      optdestroy.ContinueOnError,
+		"debug":                  optdestroy.DebugLogging,
+		"diff":                   optdestroy.Diff,
+		"exclude":                optdestroy.Exclude,
+		"exclude-dependents":     optdestroy.ExcludeDependents,
+		"exclude-protected":      optdestroy.ExcludeProtected,
+		"exec-agent":             optdestroy.UserAgent,
+		"message":                optdestroy.Message,
+		"parallel":               optdestroy.Parallel,
+		"refresh":                optdestroy.Refresh,
+		"remove":                 optdestroy.Remove,
+		"show-config":            optdestroy.ShowConfig,
+		"show-replacement-steps": optdestroy.ShowReplacementSteps,
+		"show-sames":             optdestroy.ShowSames,
+		"suppress-outputs":       optdestroy.SuppressOutputs,
+		"suppress-permalink":     optdestroy.SuppressPermalink,
+		"target":                 optdestroy.Target,
+		"target-dependents":      optdestroy.TargetDependents,
+		"timeout":                optdestroy.Timeout,
+		"timeout-grace-period":   optdestroy.TimeoutGracePeriod,
+	},
+	"watch": {
+		"config-file":            optwatch.ConfigFile,
+		"debug":                  optwatch.DebugLogging,
+		"message":                optwatch.Message,
+		"parallel":               optwatch.Parallel,
+		"path":                   optwatch.Paths,
+		"policy-pack":            optwatch.PolicyPacks,
+		"policy-pack-config":     optwatch.PolicyPackConfigs,
+		"refresh":                optwatch.Refresh,
+		"show-config":            optwatch.ShowConfig,
+		"show-replacement-steps": optwatch.ShowReplacementSteps,
+		"show-sames":             optwatch.ShowSames,
+	},
+}
+
+// automationAPIUnexposedFlags lists the flags that the Go Automation API deliberately has no option for, and why.
+var automationAPIUnexposedFlags = map[string]string{
+	"client":           "the Automation API sets it to run inline programs",
+	"config":           "config is set with Stack.SetConfig",
+	"config-path":      "config is set with Stack.SetConfigWithOptions",
+	"event-log":        "the Automation API sets it to stream engine events",
+	"exec-kind":        "the Automation API sets it to the kind of program it runs",
+	"json":             "the Automation API reports results from the engine events instead",
+	"secrets-provider": "the secrets provider is set with the SecretsProvider workspace option",
+	"skip-preview":     "the Automation API always sets it; use Stack.Preview to preview",
+	"stack":            "the Automation API always sets it to the stack being operated on",
+	"yes":              "the Automation API always sets it",
+}
+
+func TestAutomationAPIOptionParity(t *testing.T) {
+	t.Parallel()
+
+	commands := map[string]*cobra.Command{
+		"up":      newUpCmd(),
+		"preview": newPreviewCmd(),
+		"refresh": newRefreshCmd(),
+		"destroy": newDestroyCmd(),
+		"watch":   newWatchCmd(),
+	}
+	for name, cmd := range commands {
+		options := automationAPIOptions[name]
+
+		flags := make(map[string]bool)
+		cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+			flags[f.Name] = true
+
+			// Remote operations are driven through RemoteWorkspace rather than options.
+			if strings.HasPrefix(f.Name, "remote") {
+				return
+			}
+			_, hasOption := options[f.Name]
+			_, unexposed := automationAPIUnexposedFlags[f.Name]
+			assert.True(t, hasOption || unexposed,
+				"`pulumi %s --%s` has no Go Automation API equivalent: add an option for it, "+
+					"or list it in automationAPIUnexposedFlags", name, f.Name)
+		})
+
+		for flag := range options {
+			assert.True(t, flags[flag], "`pulumi %s` has no --%s flag for its Automation API option", name, flag)
+		}
+	}
+}
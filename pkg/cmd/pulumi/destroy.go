@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -67,6 +68,11 @@ func newDestroyCmd() *cobra.Command {
 	var targets *[]string
 	var targetDependents bool
 	var excludeProtected bool
+	var excludes []string
+	var excludeDependents bool
+	var continueOnError bool
+	var timeout time.Duration
+	var timeoutGracePeriod time.Duration
 
 	use, cmdArgs := "destroy", cmdutil.NoArgs
 	if remoteSupported() {
@@ -89,9 +95,15 @@ func newDestroyCmd() *cobra.Command {
 			"\n" +
 			"Warning: this command is generally irreversible and should be used with great care.",
 		Args: cmdArgs,
-		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+		Run: runWithDeadline(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 
+			// The deadline covers both the preview and the destroy.
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+
 			// Remote implies we're skipping previews.
 			if remoteArgs.remote {
 				skipPreview = true
@@ -245,11 +257,16 @@ func newDestroyCmd() *cobra.Command {
 				Refresh:                   refreshOption,
 				DestroyTargets:            deploy.NewUrnTargets(targetUrns),
 				TargetDependents:          targetDependents,
+				ExcludeTargets:            deploy.NewUrnTargets(excludes),
+				ExcludeDependents:         excludeDependents,
 				UseLegacyDiff:             useLegacyDiff(),
 				DisableProviderPreview:    disableProviderPreview(),
 				DisableResourceReferences: disableResourceReferences(),
 				DisableOutputValues:       disableOutputValues(),
 				Experimental:              hasExperimentalCommands(),
+				ContinueOnError:           continueOnError,
+				Deadline:                  deadline,
+				DeadlineGracePeriod:       timeoutGracePeriod,
 			}
 
 			_, res := s.Destroy(ctx, backend.UpdateOperation{
@@ -319,6 +336,14 @@ func newDestroyCmd() *cobra.Command {
 		"Allows destroying of dependent targets discovered but not specified in --target list")
 	cmd.PersistentFlags().BoolVar(&excludeProtected, "exclude-protected", false, "Do not destroy protected resources."+
 		" Destroy all other resources.")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to ignore. These resources will not be destroyed."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also ignore the resources that depend on or are children of the resources in the --exclude list")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().BoolVar(
@@ -330,6 +355,16 @@ func newDestroyCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue destroying the resources that no failed resource depends on, and report all failures at the end")
+	cmd.PersistentFlags().DurationVar(
+		&timeout, "timeout", 0,
+		"Stop the destroy if it hasn't finished after this long (e.g. 30m). Operations still running are given the "+
+			"grace period to finish, and are recorded as pending operations otherwise")
+	cmd.PersistentFlags().DurationVar(
+		&timeoutGracePeriod, "timeout-grace-period", defaultTimeoutGracePeriod,
+		"How long to wait for running operations to finish once the --timeout has passed")
 	cmd.PersistentFlags().StringVarP(
 		&refresh, "refresh", "r", "",
 		"Refresh the state of the stack's resources before this update")
@@ -0,0 +1,220 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// driftExitCode is the code that `pulumi drift` exits with when it detects drift. It is distinct from the code used
+// for errors (255) and from the code of a Go runtime panic (2).
+const driftExitCode = 3
+
+func newDriftCmd() *cobra.Command {
+	var debug bool
+	var message string
+	var execKind string
+	var execAgent string
+	var stackName string
+
+	var jsonDisplay bool
+	var eventLogPath string
+	var parallel int
+	var suppressPermalink string
+	var targets []string
+	var excludes []string
+	var excludeDependents bool
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "Detect resources that have drifted from the stack's state",
+		Long: "Detect resources that have drifted from the stack's state.\n" +
+			"\n" +
+			"This command reads the current state of each resource in the stack from its provider, as\n" +
+			"`pulumi refresh` does, but never modifies the stack's state. Each resource is reported as\n" +
+			"in sync, drifted (along with the properties that changed), or deleted out-of-band.\n" +
+			"\n" +
+			"The command exits with code 0 if no drift was detected, and with code 3 if it was, so that it\n" +
+			"can be used to monitor stacks from scheduled jobs. Any other non-zero exit code indicates an\n" +
+			"error. Use `--json` to emit the report in a machine-readable format.",
+		Args: cmdutil.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var drifted bool
+			cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+				ctx := commandContext()
+
+				opts := backend.UpdateOptions{
+					AutoApprove: true,
+					PreviewOnly: true,
+				}
+				opts.Display = display.Options{
+					Color:         cmdutil.GetGlobalColorization(),
+					IsInteractive: cmdutil.Interactive(),
+					Type:          display.DisplayDrift,
+					EventLogPath:  eventLogPath,
+					Debug:         debug,
+					JSONDisplay:   jsonDisplay,
+				}
+
+				filestateBackend, err := isFilestateBackend(opts.Display)
+				if err != nil {
+					return result.FromError(err)
+				}
+
+				// As with refresh, permalinks are suppressed by default when using self-managed backends.
+				opts.Display.SuppressPermalink = suppressPermalink == "true" ||
+					(suppressPermalink != "false" && filestateBackend)
+
+				s, err := requireStack(ctx, stackName, stackLoadOnly, opts.Display)
+				if err != nil {
+					return result.FromError(err)
+				}
+
+				proj, root, err := readProject()
+				if err != nil {
+					return result.FromError(err)
+				}
+
+				m, err := getUpdateMetadata(message, root, execKind, execAgent, false, cmd.Flags())
+				if err != nil {
+					return result.FromError(fmt.Errorf("gathering environment metadata: %w", err))
+				}
+
+				cfg, sm, err := getStackConfiguration(ctx, s, proj, nil)
+				if err != nil {
+					return result.FromError(fmt.Errorf("getting stack configuration: %w", err))
+				}
+
+				decrypter, err := sm.Decrypter()
+				if err != nil {
+					return result.FromError(fmt.Errorf("getting stack decrypter: %w", err))
+				}
+
+				stackName := s.Ref().Name().String()
+				configErr := workspace.ValidateStackConfigAndApplyProjectConfig(stackName, proj, cfg.Config, decrypter)
+				if configErr != nil {
+					return result.FromError(fmt.Errorf("validating stack config: %w", configErr))
+				}
+
+				opts.Engine = engine.UpdateOptions{
+					Parallel:                  parallel,
+					Debug:                     debug,
+					UseLegacyDiff:             useLegacyDiff(),
+					DisableProviderPreview:    disableProviderPreview(),
+					DisableResourceReferences: disableResourceReferences(),
+					DisableOutputValues:       disableOutputValues(),
+					RefreshTargets:            deploy.NewUrnTargets(targets),
+					ExcludeTargets:            deploy.NewUrnTargets(excludes),
+					ExcludeDependents:         excludeDependents,
+					Experimental:              hasExperimentalCommands(),
+				}
+
+				changes, res := s.Refresh(ctx, backend.UpdateOperation{
+					Proj:               proj,
+					Root:               root,
+					M:                  m,
+					Opts:               opts,
+					StackConfiguration: cfg,
+					SecretsManager:     sm,
+					SecretsProvider:    stack.DefaultSecretsProvider,
+					Scopes:             cancellationScopes,
+				})
+
+				switch {
+				case res != nil && res.Error() == context.Canceled:
+					return result.FromError(errors.New("drift detection cancelled"))
+				case res != nil:
+					return PrintEngineResult(res)
+				default:
+					drifted = changes[deploy.OpUpdate] > 0 || changes[deploy.OpDelete] > 0
+					return nil
+				}
+			})(cmd, args)
+
+			// RunResultFunc has already exited the process if there was an error, and everything has been cleaned up
+			// by now, so it's safe to exit directly.
+			if drifted {
+				os.Exit(driftExitCode)
+			}
+		},
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&debug, "debug", "d", false,
+		"Print detailed debugging output during resource operations")
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().StringVar(
+		&stackConfigFile, "config-file", "",
+		"Use the configuration values in the specified file rather than detecting the file name")
+	cmd.PersistentFlags().StringVarP(
+		&message, "message", "m", "",
+		"Optional message to associate with the drift detection operation")
+
+	cmd.PersistentFlags().StringArrayVarP(
+		&targets, "target", "t", []string{},
+		"Specify a single resource URN to check for drift. Multiple resources can be specified using:"+
+			" --target urn1 --target urn2")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to ignore. These resources will not be checked for drift."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also ignore the resources that depend on or are children of the resources in the --exclude list")
+
+	cmd.Flags().BoolVarP(
+		&jsonDisplay, "json", "j", false,
+		"Serialize the drift report as JSON")
+	cmd.PersistentFlags().IntVarP(
+		&parallel, "parallel", "p", defaultParallel,
+		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().StringVar(
+		&suppressPermalink, "suppress-permalink", "",
+		"Suppress display of the state permalink")
+	cmd.Flag("suppress-permalink").NoOptDefVal = "false"
+
+	if hasDebugCommands() {
+		cmd.PersistentFlags().StringVar(
+			&eventLogPath, "event-log", "",
+			"Log events to a file at this path")
+	}
+
+	// internal flags
+	cmd.PersistentFlags().StringVar(&execKind, "exec-kind", "", "")
+	// ignore err, only happens if flag does not exist
+	_ = cmd.PersistentFlags().MarkHidden("exec-kind")
+	cmd.PersistentFlags().StringVar(&execAgent, "exec-agent", "", "")
+	// ignore err, only happens if flag does not exist
+	_ = cmd.PersistentFlags().MarkHidden("exec-agent")
+
+	return cmd
+}
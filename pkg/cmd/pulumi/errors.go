@@ -3,8 +3,12 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"os"
+
+	"github.com/spf13/cobra"
 
 	"github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
@@ -51,6 +55,54 @@ func printDecryptError(e engine.DecryptError) {
 	cmdutil.Diag().Errorf(diag.RawMessage("" /*urn*/, buf.String()))
 }
 
+// deadlineExitCode is the code that commands exit with when their deployment doesn't finish before the deadline set by
+// --timeout. It's the code that timeout(1) exits with.
+const deadlineExitCode = 124
+
+// runWithDeadline wraps the implementation of a command that supports --timeout like cmdutil.RunResultFunc does. If the
+// command's deployment doesn't finish before its deadline, the operations that were left pending are reported and the
+// command exits with deadlineExitCode.
+func runWithDeadline(run func(cmd *cobra.Command, args []string) result.Result) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		var exceeded bool
+		cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			res := run(cmd, args)
+			var deadlineErr engine.DeadlineExceededError
+			if res != nil && !res.IsBail() && errors.As(res.Error(), &deadlineErr) {
+				printDeadlineExceededError(deadlineErr)
+				exceeded = true
+				return nil
+			}
+			return res
+		})(cmd, args)
+
+		// RunResultFunc has already exited the process if there was another error, so it's safe to exit directly.
+		if exceeded {
+			os.Exit(deadlineExitCode)
+		}
+	}
+}
+
+func printDeadlineExceededError(e engine.DeadlineExceededError) {
+	var buf bytes.Buffer
+	writer := bufio.NewWriter(&buf)
+	fprintf(writer, "%s\n", e.Error())
+	if len(e.InFlight) > 0 {
+		fprintf(writer, ""+
+			"\n"+
+			"The following operations were still running when the deployment ended, and are recorded as pending "+
+			"operations in the stack's state:\n")
+		for _, step := range e.InFlight {
+			fprintf(writer, "  * %s: %s\n", step.Op(), step.URN())
+		}
+		fprintf(writer, ""+
+			"\n"+
+			"Run `pulumi refresh` to reconcile the state of these resources before the next update.\n")
+	}
+	contract.IgnoreError(writer.Flush())
+	cmdutil.Diag().Errorf(diag.RawMessage("" /*urn*/, buf.String()))
+}
+
 // Quick and dirty utility function for printing to writers that we know will never fail.
 func fprintf(writer io.Writer, msg string, args ...interface{}) {
 	_, err := fmt.Fprintf(writer, msg, args...)
@@ -85,7 +85,13 @@ func newLoginCmd() *cobra.Command {
 			"\n" +
 			"Azure Blob:\n" +
 			"\n" +
-			"    $ pulumi login azblob://my-pulumi-state-bucket\n",
+			"    $ pulumi login azblob://my-pulumi-state-bucket\n" +
+			"\n" +
+			"[PREVIEW] Or you may keep all of your state in a single SQLite database file, which can be safely shared\n" +
+			"by several processes on the same computer and queried with SQL. This requires a build of pulumi with cgo\n" +
+			"enabled:\n" +
+			"\n" +
+			"    $ pulumi login sqlite://~/.pulumi/state.db\n",
 		Args: cmdutil.MaximumNArgs(1),
 		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
 			ctx := commandContext()
@@ -183,6 +189,11 @@ func newLoginCmd() *cobra.Command {
 }
 
 func validateCloudBackendType(typ string) error {
+	// URLs handled by registered filestate storage drivers are supported too.
+	if filestate.IsFileStateBackendURL(typ) {
+		return nil
+	}
+
 	kind := strings.SplitN(typ, ":", 2)[0]
 	supportedKinds := []string{"azblob", "gs", "s3", "file", "https", "http"}
 	for _, supportedKind := range supportedKinds {
@@ -0,0 +1,117 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+)
+
+func newPlanCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Inspect saved update plans",
+		Long: "[EXPERIMENTAL] Inspect saved update plans\n" +
+			"\n" +
+			"Subcommands of this command can be used to inspect the plans saved by `pulumi preview --save-plan`,\n" +
+			"without needing access to the stack that they were made for. Secret values are never shown.",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.AddCommand(newPlanShowCmd())
+	cmd.AddCommand(newPlanDiffCmd())
+	return cmd
+}
+
+func newPlanShowCmd() *cobra.Command {
+	var showSames bool
+
+	cmd := &cobra.Command{
+		Use:   "show <file>",
+		Short: "Show a saved update plan",
+		Long: "Show a saved update plan\n" +
+			"\n" +
+			"This command shows the configuration that a plan was made with, the operations that it expects to\n" +
+			"perform on each resource, and the changes that it expects to make to each resource's inputs.",
+		Args: cmdutil.ExactArgs(1),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			plan, err := readBlindedPlan(args[0])
+			if err != nil {
+				return err
+			}
+
+			opts := display.Options{
+				Color:             cmdutil.GetGlobalColorization(),
+				ShowSameResources: showSames,
+			}
+			fmt.Print(display.RenderPlan(plan, opts))
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVar(
+		&showSames, "show-sames", false,
+		"Show resources that the plan doesn't expect to change")
+	return cmd
+}
+
+func newPlanDiffCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <file1> <file2>",
+		Short: "Show the differences between two saved update plans",
+		Long: "Show the differences between two saved update plans\n" +
+			"\n" +
+			"This command shows the configuration values that differ between two plans, the resources that only\n" +
+			"one of the plans expects to change, and the resources for which the plans expect different operations\n" +
+			"or changes to their inputs.",
+		Args: cmdutil.ExactArgs(2),
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			a, err := readBlindedPlan(args[0])
+			if err != nil {
+				return err
+			}
+			b, err := readBlindedPlan(args[1])
+			if err != nil {
+				return err
+			}
+
+			diff := display.RenderPlanDiff(a, b, display.Options{Color: cmdutil.GetGlobalColorization()})
+			if diff == "" {
+				fmt.Println("The plans are the same.")
+				return nil
+			}
+			fmt.Print(diff)
+			return nil
+		}),
+	}
+
+	return cmd
+}
+
+// readBlindedPlan reads the plan saved at the given path, replacing any secrets that it contains with "[secret]" so
+// that no stack is needed to decrypt them.
+func readBlindedPlan(path string) (*deploy.Plan, error) {
+	plan, err := readPlan(path, config.BlindingCrypter, config.BlindingCrypter)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan %q: %w", path, err)
+	}
+	return plan, nil
+}
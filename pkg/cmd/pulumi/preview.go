@@ -45,6 +45,7 @@ func newPreviewCmd() *cobra.Command {
 	var configPath bool
 	var client string
 	var planFilePath string
+	var checkPlanFilePath string
 	var showSecrets bool
 
 	// Flags for remote operations.
@@ -68,6 +69,8 @@ func newPreviewCmd() *cobra.Command {
 	var replaces []string
 	var targetReplaces []string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
 
 	use, cmdArgs := "preview", cmdutil.NoArgs
 	if remoteSupported() {
@@ -220,6 +223,8 @@ func newPreviewCmd() *cobra.Command {
 					DisableOutputValues:       disableOutputValues(),
 					UpdateTargets:             deploy.NewUrnTargets(targetURNs),
 					TargetDependents:          targetDependents,
+					ExcludeTargets:            deploy.NewUrnTargets(excludes),
+					ExcludeDependents:         excludeDependents,
 					// If we're trying to save a plan then we _need_ to generate it. We also turn this on in
 					// experimental mode to just get more testing of it.
 					GeneratePlan: hasExperimentalCommands() || planFilePath != "",
@@ -228,6 +233,20 @@ func newPreviewCmd() *cobra.Command {
 				Display: displayOpts,
 			}
 
+			// If we're checking the program against a plan, report every divergence from it.
+			if checkPlanFilePath != "" {
+				encrypter, err := sm.Encrypter()
+				if err != nil {
+					return result.FromError(err)
+				}
+				plan, err := readPlan(checkPlanFilePath, decrypter, encrypter)
+				if err != nil {
+					return result.FromError(err)
+				}
+				opts.Engine.Plan = plan
+				opts.Engine.ValidatePlan = true
+			}
+
 			plan, changes, res := s.Preview(ctx, backend.UpdateOperation{
 				Proj:               proj,
 				Root:               root,
@@ -296,6 +315,13 @@ func newPreviewCmd() *cobra.Command {
 	}
 	cmd.Flags().BoolVarP(
 		&showSecrets, "show-secrets", "", false, "Emit secrets in plaintext in the plan file. Defaults to `false`")
+	cmd.PersistentFlags().StringVar(
+		&checkPlanFilePath, "plan", "",
+		"[EXPERIMENTAL] Check the preview against a plan file at the given path, reporting every operation and "+
+			"resource that diverges from it")
+	if !hasExperimentalCommands() {
+		contract.AssertNoErrorf(cmd.PersistentFlags().MarkHidden("plan"), `Could not mark "plan" as hidden`)
+	}
 
 	cmd.PersistentFlags().StringVar(
 		&client, "client", "", "The address of an existing language runtime host to connect to")
@@ -319,6 +345,14 @@ func newPreviewCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows updating of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to ignore. These resources will not be updated."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also ignore the resources that depend on or are children of the resources in the --exclude list")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().StringSliceVar(
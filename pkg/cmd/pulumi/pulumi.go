@@ -293,6 +293,7 @@ func NewPulumiCmd() *cobra.Command {
 				newConsoleCmd(),
 				newImportCmd(),
 				newRefreshCmd(),
+				newDriftCmd(),
 				newStateCmd(),
 			},
 		},
@@ -355,6 +356,7 @@ func NewPulumiCmd() *cobra.Command {
 				newWatchCmd(),
 				newLogsCmd(),
 				newEnvCmd(),
+				newPlanCmd(),
 			},
 		},
 		// We have a set of options that are useful for developers of pulumi
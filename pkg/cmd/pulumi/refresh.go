@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	survey "github.com/AlecAivazis/survey/v2"
 	terminal "github.com/AlecAivazis/survey/v2/terminal"
@@ -62,6 +63,10 @@ func newRefreshCmd() *cobra.Command {
 	var suppressPermalink string
 	var yes bool
 	var targets *[]string
+	var excludes []string
+	var excludeDependents bool
+	var timeout time.Duration
+	var timeoutGracePeriod time.Duration
 
 	// Flags for handling pending creates
 	var skipPendingCreates bool
@@ -86,9 +91,15 @@ func newRefreshCmd() *cobra.Command {
 			"The program to run is loaded from the project in the current directory. Use the `-C` or\n" +
 			"`--cwd` flag to use a different directory.",
 		Args: cmdArgs,
-		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+		Run: runWithDeadline(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 
+			// The deadline covers both the preview and the refresh.
+			var deadline time.Time
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
+
 			// Remote implies we're skipping previews.
 			if remoteArgs.remote {
 				skipPreview = true
@@ -251,7 +262,11 @@ func newRefreshCmd() *cobra.Command {
 				DisableResourceReferences: disableResourceReferences(),
 				DisableOutputValues:       disableOutputValues(),
 				RefreshTargets:            deploy.NewUrnTargets(targetUrns),
+				ExcludeTargets:            deploy.NewUrnTargets(excludes),
+				ExcludeDependents:         excludeDependents,
 				Experimental:              hasExperimentalCommands(),
+				Deadline:                  deadline,
+				DeadlineGracePeriod:       timeoutGracePeriod,
 			}
 
 			changes, res := s.Refresh(ctx, backend.UpdateOperation{
@@ -298,6 +313,14 @@ func newRefreshCmd() *cobra.Command {
 	targets = cmd.PersistentFlags().StringArrayP(
 		"target", "t", []string{},
 		"Specify a single resource URN to refresh. Multiple resource can be specified using: --target urn1 --target urn2")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to ignore. These resources will not be refreshed."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also ignore the resources that depend on or are children of the resources in the --exclude list")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().BoolVar(
@@ -309,6 +332,13 @@ func newRefreshCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().DurationVar(
+		&timeout, "timeout", 0,
+		"Stop the refresh if it hasn't finished after this long (e.g. 30m). Operations still running are given the "+
+			"grace period to finish, and are recorded as pending operations otherwise")
+	cmd.PersistentFlags().DurationVar(
+		&timeoutGracePeriod, "timeout-grace-period", defaultTimeoutGracePeriod,
+		"How long to wait for running operations to finish once the --timeout has passed")
 	cmd.PersistentFlags().BoolVar(
 		&showReplacementSteps, "show-replacement-steps", false,
 		"Show detailed resource replacement creates and deletes instead of a single step")
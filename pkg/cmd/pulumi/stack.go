@@ -191,6 +191,9 @@ func newStackCmd() *cobra.Command {
 	cmd.AddCommand(newStackRenameCmd())
 	cmd.AddCommand(newStackChangeSecretsProviderCmd())
 	cmd.AddCommand(newStackHistoryCmd())
+	cmd.AddCommand(newStackRollbackCmd())
+	cmd.AddCommand(newStackDiffCmd())
+	cmd.AddCommand(newStackLockCmd())
 	cmd.AddCommand(newStackUnselectCmd())
 
 	return cmd
@@ -0,0 +1,261 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+func newStackDiffCmd() *cobra.Command {
+	var stackName string
+	var jsonOut bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <from> [<to>]",
+		Args:  cmdutil.RangeArgs(1, 2),
+		Short: "Compare the state of two stacks, or of two versions of a stack",
+		Long: "Compare the state of two stacks, or of two versions of a stack.\n" +
+			"\n" +
+			"Each of <from> and <to> names a deployment to compare, and may be one of:\n" +
+			"\n" +
+			"  <stack>              the current state of the given stack\n" +
+			"  <stack>@<version>    the state of the given stack at the end of the given update\n" +
+			"  @<version>           the state of the selected stack at the end of the given update\n" +
+			"  file:<path>          a deployment exported by `pulumi stack export`\n" +
+			"\n" +
+			"If <to> is omitted, <from> is compared with the current state of the selected stack. Versions are\n" +
+			"those shown by `pulumi stack history`.\n" +
+			"\n" +
+			"Resources are matched by URN, ignoring the stack and project that the URN names so that different\n" +
+			"stacks can be compared. A resource that has been renamed is matched through its aliases.\n" +
+			"\n" +
+			"For example, to see what changed between updates 41 and 47 of the selected stack:\n" +
+			"\n" +
+			"    pulumi stack diff @41 @47\n" +
+			"\n" +
+			"Or to see how the staging stack differs from the prod stack:\n" +
+			"\n" +
+			"    pulumi stack diff staging prod",
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			ctx := commandContext()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			from, err := loadStackDiffDeployment(ctx, args[0], stackName, opts)
+			if err != nil {
+				return err
+			}
+			toSpec := stackName
+			if len(args) > 1 {
+				toSpec = args[1]
+			}
+			to, err := loadStackDiffDeployment(ctx, toSpec, stackName, opts)
+			if err != nil {
+				return err
+			}
+
+			diffs := edit.DiffSnapshotsWithOptions(from.snap, to.snap, edit.DiffOptions{
+				IgnoreStack:   true,
+				FollowAliases: true,
+			})
+
+			if jsonOut {
+				return printJSON(stackDiffToJSON(from.name, to.name, diffs))
+			}
+
+			if len(diffs) == 0 {
+				fmt.Printf("No differences between %s and %s.\n", from.name, to.name)
+				return nil
+			}
+			var b bytes.Buffer
+			renderResourceDiffs(&b, diffs)
+			fmt.Print(opts.Color.Colorize(b.String()))
+			fmt.Printf("\n%s between %s and %s.\n", summarizeStackDiff(diffs), from.name, to.name)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+// stackDiffDeployment is a deployment loaded for comparison by `pulumi stack diff`.
+type stackDiffDeployment struct {
+	name string
+	snap *deploy.Snapshot
+}
+
+// loadStackDiffDeployment loads the deployment named by a `pulumi stack diff` argument. Stack names default to the
+// given stack, or to the current stack if that is empty.
+func loadStackDiffDeployment(ctx context.Context, spec, defaultStack string,
+	opts display.Options,
+) (stackDiffDeployment, error) {
+	var deployment *apitype.UntypedDeployment
+	var name string
+	if strings.HasPrefix(spec, "file:") {
+		path := strings.TrimPrefix(spec, "file:")
+		f, err := os.Open(path)
+		if err != nil {
+			return stackDiffDeployment{}, fmt.Errorf("could not open file: %w", err)
+		}
+		defer contract.IgnoreClose(f)
+
+		var dep apitype.UntypedDeployment
+		if err = json.NewDecoder(f).Decode(&dep); err != nil {
+			return stackDiffDeployment{}, fmt.Errorf("could not read deployment from %s: %w", path, err)
+		}
+		deployment, name = &dep, path
+	} else {
+		stackName, version, hasVersion := strings.Cut(spec, "@")
+		if stackName == "" {
+			stackName = defaultStack
+		}
+		if hasVersion && version == "" {
+			return stackDiffDeployment{}, fmt.Errorf("missing version in %q", spec)
+		}
+
+		s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+		if err != nil {
+			return stackDiffDeployment{}, err
+		}
+		name = s.Ref().String()
+
+		if !hasVersion {
+			if deployment, err = s.ExportDeployment(ctx); err != nil {
+				return stackDiffDeployment{}, err
+			}
+		} else {
+			be := s.Backend()
+			specificExpBE, ok := be.(backend.SpecificDeploymentExporter)
+			if !ok {
+				return stackDiffDeployment{}, fmt.Errorf(
+					"the current backend (%s) does not provide the ability to export previous deployments", be.Name())
+			}
+			if deployment, err = specificExpBE.ExportDeploymentForVersion(ctx, s, version); err != nil {
+				return stackDiffDeployment{}, err
+			}
+			name += "@" + version
+		}
+	}
+
+	snap, err := stack.DeserializeUntypedDeployment(ctx, deployment, stack.DefaultSecretsProvider)
+	if err != nil {
+		return stackDiffDeployment{}, checkDeploymentVersionError(err, name)
+	}
+	return stackDiffDeployment{name: name, snap: snap}, nil
+}
+
+// summarizeStackDiff returns a one-line summary of the number of resources of each kind of difference.
+func summarizeStackDiff(diffs []edit.ResourceDiff) string {
+	counts := map[edit.ResourceDiffKind]int{}
+	for _, diff := range diffs {
+		counts[diff.Kind]++
+	}
+	var parts []string
+	for _, kind := range []edit.ResourceDiffKind{edit.ResourceAdded, edit.ResourceChanged, edit.ResourceRemoved} {
+		if n := counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return fmt.Sprintf("%d resources differ (%s)", len(diffs), strings.Join(parts, ", "))
+}
+
+// stackDiffJSON is the shape of the --json output of `pulumi stack diff`. While we can add fields to this structure
+// in the future, we should not change existing fields.
+type stackDiffJSON struct {
+	From      string             `json:"from"`
+	To        string             `json:"to"`
+	Resources []resourceDiffJSON `json:"resources"`
+}
+
+// resourceDiffJSON describes how a single resource differs in the --json output of `pulumi stack diff`. Inputs and
+// Outputs map the paths of the properties that differ to the kind of difference: "add", "delete" or "update". Secret
+// values in Old and New are always blinded.
+type resourceDiffJSON struct {
+	URN     resource.URN        `json:"urn"`
+	Kind    string              `json:"kind"`
+	OldURN  resource.URN        `json:"oldUrn,omitempty"`
+	Fields  []string            `json:"fields,omitempty"`
+	Inputs  map[string]string   `json:"inputs,omitempty"`
+	Outputs map[string]string   `json:"outputs,omitempty"`
+	Old     *apitype.ResourceV3 `json:"old,omitempty"`
+	New     *apitype.ResourceV3 `json:"new,omitempty"`
+}
+
+func stackDiffToJSON(from, to string, diffs []edit.ResourceDiff) stackDiffJSON {
+	result := stackDiffJSON{From: from, To: to, Resources: []resourceDiffJSON{}}
+	for _, diff := range diffs {
+		entry := resourceDiffJSON{
+			URN:     diff.URN,
+			Kind:    string(diff.Kind),
+			Fields:  diff.Fields,
+			Inputs:  propertyDiffKinds(diff.Inputs),
+			Outputs: propertyDiffKinds(diff.Outputs),
+			Old:     serializeResourceForDiff(diff.Old),
+			New:     serializeResourceForDiff(diff.New),
+		}
+		if diff.Old != nil && diff.New != nil && diff.Old.URN != diff.New.URN {
+			entry.OldURN = diff.Old.URN
+		}
+		result.Resources = append(result.Resources, entry)
+	}
+	return result
+}
+
+func propertyDiffKinds(diff *resource.ObjectDiff) map[string]string {
+	if !diff.AnyChanges() {
+		return nil
+	}
+	detailed := plugin.NewDetailedDiffFromObjectDiff(diff)
+	kinds := make(map[string]string, len(detailed))
+	for path, propertyDiff := range detailed {
+		kinds[path] = propertyDiff.Kind.String()
+	}
+	return kinds
+}
+
+func serializeResourceForDiff(res *resource.State) *apitype.ResourceV3 {
+	if res == nil {
+		return nil
+	}
+	serialized, err := stack.SerializeResource(res, config.BlindingCrypter, false /* showSecrets */)
+	contract.AssertNoErrorf(err, "serializing resource %v", res.URN)
+	return &serialized
+}
@@ -0,0 +1,241 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+)
+
+func newStackLockCmd() *cobra.Command {
+	var stack string
+
+	cmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Inspect and break stack locks",
+		Long: "Inspect and break stack locks\n" +
+			"\n" +
+			"While an operation such as `pulumi up` is running, it holds a lock on the stack so that other\n" +
+			"operations cannot modify the stack at the same time. The `status` command shows who holds the\n" +
+			"locks on a stack, and the `break` command forcibly releases a lock that was left behind by an\n" +
+			"operation that did not exit cleanly.\n",
+		Args: cmdutil.NoArgs,
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "", "The name of the stack to operate on. Defaults to the current stack")
+
+	cmd.AddCommand(newStackLockStatusCmd(&stack))
+	cmd.AddCommand(newStackLockBreakCmd(&stack))
+
+	return cmd
+}
+
+func newStackLockStatusCmd(stack *string) *cobra.Command {
+	var jsonOut bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the locks held on a stack",
+		Args:  cmdutil.NoArgs,
+		Run: cmdutil.RunFunc(func(cmd *cobra.Command, args []string) error {
+			ctx := commandContext()
+
+			s, lm, err := requireStackLockManager(ctx, *stack)
+			if err != nil {
+				return err
+			}
+			locks, err := lm.ListStackLocks(ctx, s.Ref())
+			if err != nil {
+				return err
+			}
+
+			if jsonOut {
+				return printJSON(stackLocksToJSON(locks))
+			}
+
+			if len(locks) == 0 {
+				fmt.Printf("Stack %s is not locked.\n", s.Ref())
+				return nil
+			}
+			printStackLocks(locks)
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().BoolVarP(
+		&jsonOut, "json", "j", false, "Emit output as JSON")
+
+	return cmd
+}
+
+func newStackLockBreakCmd(stack *string) *cobra.Command {
+	var reason string
+	var yes bool
+	cmd := &cobra.Command{
+		Use:   "break [lock-id]",
+		Short: "Forcibly release a lock held on a stack",
+		Long: "Forcibly release a lock held on a stack\n" +
+			"\n" +
+			"Breaks the lock with the given ID, as shown by `pulumi stack lock status`, or every lock held on\n" +
+			"the stack if no ID is given. A record of who broke each lock, and why, is kept in the backend.\n" +
+			"\n" +
+			"Only break a lock if you are sure that the operation holding it is no longer running; otherwise\n" +
+			"two operations may modify the stack at the same time and corrupt its state.",
+		Args: cmdutil.MaximumNArgs(1),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if !cmdutil.Interactive() && !yes {
+				return result.Error("--yes must be passed in to proceed when running in non-interactive mode")
+			}
+
+			s, lm, err := requireStackLockManager(ctx, *stack)
+			if err != nil {
+				return result.FromError(err)
+			}
+			locks, err := lm.ListStackLocks(ctx, s.Ref())
+			if err != nil {
+				return result.FromError(err)
+			}
+			if len(args) > 0 {
+				var selected []backend.StackLock
+				for _, l := range locks {
+					if l.ID == args[0] {
+						selected = append(selected, l)
+					}
+				}
+				if len(selected) == 0 {
+					return result.Errorf("stack %s has no lock with ID %s", s.Ref(), args[0])
+				}
+				locks = selected
+			}
+			if len(locks) == 0 {
+				fmt.Printf("Stack %s is not locked.\n", s.Ref())
+				return nil
+			}
+
+			printStackLocks(locks)
+			if !yes && !confirmStateEdit(opts, fmt.Sprintf("This will break %d lock(s) held on stack %s. Confirm?",
+				len(locks), s.Ref())) {
+				return result.Bail()
+			}
+
+			for _, l := range locks {
+				if err := lm.BreakStackLock(ctx, s.Ref(), l.ID, reason); err != nil {
+					return result.FromError(fmt.Errorf("breaking lock %s: %w", l.ID, err))
+				}
+				fmt.Printf("Broke lock %s held by %s@%s (pid %d)\n", l.ID, l.Username, l.Hostname, l.Pid)
+			}
+			return nil
+		}),
+	}
+
+	cmd.PersistentFlags().StringVar(
+		&reason, "reason", "", "The reason for breaking the lock, to be kept with the record of the broken lock")
+	cmd.PersistentFlags().BoolVarP(
+		&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+// requireStackLockManager loads the given stack, and checks that its backend supports inspecting and breaking locks.
+func requireStackLockManager(ctx context.Context, stackName string) (backend.Stack, backend.StackLockManager, error) {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+	s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := s.Backend()
+	lm, ok := b.(backend.StackLockManager)
+	if !ok {
+		return nil, nil, fmt.Errorf("the current backend (%s) does not support inspecting stack locks", b.Name())
+	}
+	return s, lm, nil
+}
+
+func printStackLocks(locks []backend.StackLock) {
+	rows := make([]cmdutil.TableRow, 0, len(locks))
+	for _, l := range locks {
+		expires := "never"
+		if !l.Expires.IsZero() {
+			expires = humanize.Time(l.Expires)
+		}
+		status := "active"
+		if l.StaleReason != "" {
+			status = "stale: " + l.StaleReason
+		}
+		rows = append(rows, cmdutil.TableRow{Columns: []string{
+			l.ID,
+			fmt.Sprintf("%s@%s (pid %d)", l.Username, l.Hostname, l.Pid),
+			humanize.Time(l.Acquired),
+			expires,
+			status,
+		}})
+	}
+
+	cmdutil.PrintTable(cmdutil.Table{
+		Headers: []string{"ID", "HOLDER", "ACQUIRED", "EXPIRES", "STATUS"},
+		Rows:    rows,
+	})
+}
+
+// stackLockJSON is the shape of the --json output of `pulumi stack lock status`. While we can add fields to this
+// structure in the future, we should not change existing fields.
+type stackLockJSON struct {
+	ID          string `json:"id"`
+	Username    string `json:"username"`
+	Hostname    string `json:"hostname"`
+	Pid         int    `json:"pid"`
+	Acquired    string `json:"acquired"`
+	Expires     string `json:"expires,omitempty"`
+	Stale       bool   `json:"stale"`
+	StaleReason string `json:"staleReason,omitempty"`
+}
+
+func stackLocksToJSON(locks []backend.StackLock) []stackLockJSON {
+	result := make([]stackLockJSON, 0, len(locks))
+	for _, l := range locks {
+		entry := stackLockJSON{
+			ID:          l.ID,
+			Username:    l.Username,
+			Hostname:    l.Hostname,
+			Pid:         l.Pid,
+			Acquired:    l.Acquired.UTC().Format(time.RFC3339),
+			Stale:       l.StaleReason != "",
+			StaleReason: l.StaleReason,
+		}
+		if !l.Expires.IsZero() {
+			entry.Expires = l.Expires.UTC().Format(time.RFC3339)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
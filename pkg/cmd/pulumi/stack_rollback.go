@@ -0,0 +1,224 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func newStackRollbackCmd() *cobra.Command {
+	var stackName string
+	var version string
+	var refresh bool
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "rollback --version <version>",
+		Args:  cmdutil.NoArgs,
+		Short: "Restore the state of a stack as of a previous update",
+		Long: "Restore the state of a stack as of a previous update.\n" +
+			"\n" +
+			"This command replaces the current state of the stack with the checkpoint that was recorded at the\n" +
+			"end of the given update (see `pulumi stack history` for the available versions). No resources are\n" +
+			"created, updated or deleted; only the stack's recorded state changes. A summary of the resources\n" +
+			"whose recorded state will change is shown for confirmation before the state is written.\n" +
+			"\n" +
+			"The restored state may no longer match the actual resources. Pass --refresh to run a refresh\n" +
+			"immediately afterwards so that the restored state is reconciled with reality.",
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			opts := display.Options{
+				Color: cmdutil.GetGlobalColorization(),
+			}
+
+			if version == "" {
+				return result.Error("must provide the version to roll back to with --version")
+			}
+			if !cmdutil.Interactive() && !yes {
+				return result.Error("--yes must be passed in to proceed when running in non-interactive mode")
+			}
+
+			s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			if res := rollbackStack(ctx, s, version, !yes, opts); res != nil {
+				return res
+			}
+			if !refresh {
+				return nil
+			}
+			return refreshStackAfterRollback(ctx, s, yes, cmd.Flags())
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().StringVar(
+		&version, "version", "", "The version of the stack, as shown by `pulumi stack history`, to roll back to")
+	cmd.Flags().BoolVar(
+		&refresh, "refresh", false, "Refresh the stack after its state has been restored")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+// rollbackStack replaces the current state of the given stack with the checkpoint recorded for the given version.
+func rollbackStack(ctx context.Context, s backend.Stack, version string, showPrompt bool,
+	opts display.Options,
+) result.Result {
+	be := s.Backend()
+	specificExpBE, ok := be.(backend.SpecificDeploymentExporter)
+	if !ok {
+		return result.Errorf("the current backend (%s) does not provide the ability to export previous deployments",
+			be.Name())
+	}
+
+	deployment, err := specificExpBE.ExportDeploymentForVersion(ctx, s, version)
+	if err != nil {
+		return result.FromError(err)
+	}
+	restored, err := stack.DeserializeUntypedDeployment(ctx, deployment, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(checkDeploymentVersionError(err, s.Ref().Name().String()))
+	}
+	if err := restored.VerifyIntegrity(); err != nil {
+		return result.Errorf("the state recorded for version %s is invalid and cannot be restored: %v", version, err)
+	}
+
+	current, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	// Secrets are re-encrypted with the stack's current secrets manager, in case it has changed since the version that
+	// is being restored.
+	if current != nil && current.SecretsManager != nil {
+		restored.SecretsManager = current.SecretsManager
+	}
+
+	// Operations that were pending at the end of an old update are meaningless now; clear them as `stack import` does.
+	for _, op := range restored.PendingOperations {
+		cmdutil.Diag().Warningf(diag.Message(op.Resource.URN, fmt.Sprintf(
+			"removing pending operation '%s' on '%s' from the restored state", op.Type, op.Resource.URN)))
+	}
+	restored.PendingOperations = nil
+
+	diffs := edit.DiffSnapshots(current, restored)
+	if len(diffs) == 0 {
+		fmt.Printf("The state of stack %s already matches version %s.\n", s.Ref(), version)
+		return nil
+	}
+
+	fmt.Printf("Restoring the state of stack %s to version %s changes the recorded state of %d resources:\n",
+		s.Ref(), version, len(diffs))
+	var b bytes.Buffer
+	renderResourceDiffs(&b, diffs)
+	fmt.Print(opts.Color.Colorize(b.String()))
+
+	if showPrompt && !confirmStateEdit(opts, "This command will replace your stack's state. Confirm?") {
+		return result.Bail()
+	}
+
+	if err := saveStateSnapshot(ctx, s, restored); err != nil {
+		return result.FromError(fmt.Errorf("restoring version %s: %w", version, err))
+	}
+	fmt.Printf("Restored the state of stack %s to version %s\n", s.Ref(), version)
+	return nil
+}
+
+// refreshStackAfterRollback refreshes the given stack with default options, so that the state restored by a rollback
+// is reconciled with the actual resources.
+func refreshStackAfterRollback(ctx context.Context, s backend.Stack, yes bool, flags *pflag.FlagSet) result.Result {
+	interactive := cmdutil.Interactive()
+	opts, err := updateFlagsToOptions(interactive, false /* skipPreview */, yes)
+	if err != nil {
+		return result.FromError(err)
+	}
+	opts.Display = display.Options{
+		Color:         cmdutil.GetGlobalColorization(),
+		IsInteractive: interactive,
+		Type:          display.DisplayProgress,
+	}
+
+	proj, root, err := readProject()
+	if err != nil {
+		return result.FromError(err)
+	}
+	m, err := getUpdateMetadata("", root, "", "", false, flags)
+	if err != nil {
+		return result.FromError(fmt.Errorf("gathering environment metadata: %w", err))
+	}
+	cfg, sm, err := getStackConfiguration(ctx, s, proj, nil)
+	if err != nil {
+		return result.FromError(fmt.Errorf("getting stack configuration: %w", err))
+	}
+	decrypter, err := sm.Decrypter()
+	if err != nil {
+		return result.FromError(fmt.Errorf("getting stack decrypter: %w", err))
+	}
+	configErr := workspace.ValidateStackConfigAndApplyProjectConfig(s.Ref().Name().String(), proj, cfg.Config, decrypter)
+	if configErr != nil {
+		return result.FromError(fmt.Errorf("validating stack config: %w", configErr))
+	}
+
+	opts.Engine = engine.UpdateOptions{
+		UseLegacyDiff:             useLegacyDiff(),
+		DisableProviderPreview:    disableProviderPreview(),
+		DisableResourceReferences: disableResourceReferences(),
+		DisableOutputValues:       disableOutputValues(),
+		RefreshTargets:            deploy.NewUrnTargets(nil),
+		Experimental:              hasExperimentalCommands(),
+	}
+
+	_, res := s.Refresh(ctx, backend.UpdateOperation{
+		Proj:               proj,
+		Root:               root,
+		M:                  m,
+		Opts:               opts,
+		StackConfiguration: cfg,
+		SecretsManager:     sm,
+		SecretsProvider:    stack.DefaultSecretsProvider,
+		Scopes:             cancellationScopes,
+	})
+	switch {
+	case res != nil && errors.Is(res.Error(), context.Canceled):
+		return result.FromError(errors.New("refresh cancelled"))
+	case res != nil:
+		return PrintEngineResult(res)
+	default:
+		return nil
+	}
+}
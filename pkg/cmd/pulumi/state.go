@@ -43,14 +43,21 @@ func newStateCmd() *cobra.Command {
 		Long: `Edit the current stack's state
 
 Subcommands of this command can be used to surgically edit parts of a stack's state. These can be useful when
-troubleshooting a stack or when performing specific edits that otherwise would require editing the state file by hand.`,
+troubleshooting a stack or when performing specific edits that otherwise would require editing the state file by hand.
+
+Most subcommands accept one or more --select flags to operate on every resource matching a selector, rather than on
+individual URNs. ` + edit.SelectorSyntax,
 		Args: cmdutil.NoArgs,
 	}
 
 	cmd.AddCommand(newStateDeleteCommand())
+	cmd.AddCommand(newStateProtectCommand())
 	cmd.AddCommand(newStateUnprotectCommand())
 	cmd.AddCommand(newStateRenameCommand())
 	cmd.AddCommand(newStateUpgradeCommand())
+	cmd.AddCommand(newStateMoveCommand())
+	cmd.AddCommand(newStateEditCommand())
+	cmd.AddCommand(newStatePendingCommand())
 	return cmd
 }
 
@@ -125,6 +132,96 @@ func runStateEdit(
 	})
 }
 
+// errStateEditDeclined is returned from state edit operations when the user declines the confirmation prompt.
+var errStateEditDeclined = errors.New("confirmation declined")
+
+// parseStateSelectors parses the values of a --select flag.
+func parseStateSelectors(texts []string) ([]*edit.Selector, error) {
+	selectors := make([]*edit.Selector, 0, len(texts))
+	for _, text := range texts {
+		s, err := edit.ParseSelector(text)
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, s)
+	}
+	return selectors, nil
+}
+
+// addStateSelectorFlag adds the --select flag that is shared by the state subcommands to the given command.
+func addStateSelectorFlag(cmd *cobra.Command, selectors *[]string) {
+	cmd.Flags().StringArrayVar(selectors, "select", nil,
+		"Operate on every resource matching the given selector. May be repeated to select the union of several "+
+			"selectors. See `pulumi state --help` for the selector syntax")
+}
+
+// locateStackResources resolves the resources named by the given URNs and selected by the given selectors. Each URN
+// must refer to a resource, and each selector must match at least one resource. The result is in snapshot order and
+// contains every resource at most once.
+func locateStackResources(opts display.Options, snap *deploy.Snapshot, urns []resource.URN,
+	selectors []*edit.Selector,
+) ([]*resource.State, error) {
+	located := map[*resource.State]bool{}
+	for _, urn := range urns {
+		res, err := locateStackResource(opts, snap, urn)
+		if err != nil {
+			return nil, err
+		}
+		located[res] = true
+	}
+	for _, s := range selectors {
+		selected := edit.SelectResources(snap, []*edit.Selector{s})
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("no resources match the selector %q", s)
+		}
+		for _, res := range selected {
+			located[res] = true
+		}
+	}
+
+	var resources []*resource.State
+	if snap != nil {
+		for _, res := range snap.Resources {
+			if located[res] {
+				resources = append(resources, res)
+			}
+		}
+	}
+	return resources, nil
+}
+
+// runSelectedStateEdit runs the given state edit function on all resources named by the given URNs and selected by the
+// given selectors. If showPrompt is true, the user is asked to confirm the edit once, after the resources have been
+// listed.
+func runSelectedStateEdit(
+	ctx context.Context, stackName string, showPrompt bool,
+	urns []resource.URN, selectors []*edit.Selector,
+	operation func(snap *deploy.Snapshot, resources []*resource.State) error,
+) result.Result {
+	res := runTotalStateEdit(ctx, stackName, false, func(opts display.Options, snap *deploy.Snapshot) error {
+		resources, err := locateStackResources(opts, snap, urns, selectors)
+		if err != nil {
+			return err
+		}
+
+		if showPrompt && len(selectors) > 0 {
+			fmt.Printf("The following %d resources are selected:\n", len(resources))
+			for _, res := range resources {
+				fmt.Printf("  - %s\n", res.URN)
+			}
+		}
+		if showPrompt && !confirmStateEdit(opts, "This command will edit your stack's state directly. Confirm?") {
+			return errStateEditDeclined
+		}
+
+		return operation(snap, resources)
+	})
+	if res != nil && errors.Is(res.Error(), errStateEditDeclined) {
+		return result.Bail()
+	}
+	return res
+}
+
 // runTotalStateEdit runs a snapshot-mutating function on the entirety of the given stack's snapshot.
 // Before mutating, the user may be prompted to for confirmation if the current session is interactive.
 func runTotalStateEdit(
@@ -151,17 +248,8 @@ func totalStateEdit(ctx context.Context, s backend.Stack, showPrompt bool, opts
 		return nil
 	}
 
-	if showPrompt && cmdutil.Interactive() {
-		confirm := false
-		surveycore.DisableColor = true
-		prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
-		prompt += "This command will edit your stack's state directly. Confirm?"
-		if err = survey.AskOne(&survey.Confirm{
-			Message: prompt,
-		}, &confirm, surveyIcons(opts.Color)); err != nil || !confirm {
-			fmt.Println("confirmation declined")
-			return result.Bail()
-		}
+	if showPrompt && !confirmStateEdit(opts, "This command will edit your stack's state directly. Confirm?") {
+		return result.Bail()
 	}
 
 	// The `operation` callback will mutate `snap` in-place. In order to validate the correctness of the transformation
@@ -177,19 +265,45 @@ func totalStateEdit(ctx context.Context, s backend.Stack, showPrompt bool, opts
 		contract.AssertNoErrorf(snap.VerifyIntegrity(), "state edit produced an invalid snapshot")
 	}
 
+	// Once we've mutated the snapshot, import it back into the backend so that it can be persisted.
+	return result.WrapIfNonNil(saveStateSnapshot(ctx, s, snap))
+}
+
+// confirmStateEdit asks the user to confirm a direct edit of stack state with the given message. It returns true
+// without prompting if the current session is not interactive.
+func confirmStateEdit(opts display.Options, message string) bool {
+	if !cmdutil.Interactive() {
+		return true
+	}
+
+	confirm := false
+	surveycore.DisableColor = true
+	prompt := opts.Color.Colorize(colors.Yellow + "warning" + colors.Reset + ": ")
+	prompt += message
+	if err := survey.AskOne(&survey.Confirm{
+		Message: prompt,
+	}, &confirm, surveyIcons(opts.Color)); err != nil || !confirm {
+		fmt.Println("confirmation declined")
+		return false
+	}
+	return true
+}
+
+// saveStateSnapshot serializes the given snapshot, encrypting secrets with the snapshot's secrets manager, and imports
+// it into the given stack.
+func saveStateSnapshot(ctx context.Context, s backend.Stack, snap *deploy.Snapshot) error {
 	sdep, err := stack.SerializeDeployment(snap, snap.SecretsManager, false /* showSecrets */)
 	if err != nil {
-		return result.FromError(fmt.Errorf("serializing deployment: %w", err))
+		return fmt.Errorf("serializing deployment: %w", err)
 	}
 
-	// Once we've mutated the snapshot, import it back into the backend so that it can be persisted.
 	bytes, err := json.Marshal(sdep)
 	if err != nil {
-		return result.FromError(err)
+		return err
 	}
 	dep := apitype.UntypedDeployment{
 		Version:    apitype.DeploymentSchemaVersionCurrent,
 		Deployment: bytes,
 	}
-	return result.WrapIfNonNil(s.ImportDeployment(ctx, &dep))
+	return s.ImportDeployment(ctx, &dep)
 }
@@ -32,14 +32,16 @@ func newStateDeleteCommand() *cobra.Command {
 	var stack string
 	var yes bool
 	var targetDepenedents bool
+	var selectors []string
 
 	cmd := &cobra.Command{
-		Use:   "delete <resource URN>",
+		Use:   "delete [resource URN...]",
 		Short: "Deletes a resource from a stack's state",
 		Long: `Deletes a resource from a stack's state
 
 This command deletes a resource from a stack's state, as long as it is safe to do so. The resource is specified
-by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it).
+by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it). Several resources can be deleted at once by
+passing more than one URN, or by choosing them with --select.
 
 Resources can't be deleted if there exist other resources that depend on it or are parented to it. Protected resources
 will not be deleted unless it is specifically requested using the --force flag.
@@ -48,26 +50,55 @@ Make sure that URNs are single-quoted to avoid having characters unexpectedly in
 
 Example:
 pulumi state delete 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:kubernetes::eks-provider'
+pulumi state delete --select 'parent=urn:pulumi:stage::demo::eks:index:Cluster::cluster'
 `,
-		Args: cmdutil.ExactArgs(1),
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 			yes = yes || skipConfirmations()
-			urn := resource.URN(args[0])
 			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
 			showPrompt := !yes
 
-			res := runStateEdit(ctx, stack, showPrompt, urn, func(snap *deploy.Snapshot, res *resource.State) error {
-				var handleProtected func(*resource.State) error
-				if force {
-					handleProtected = func(res *resource.State) error {
-						cmdutil.Diag().Warningf(diag.Message(res.URN,
-							"deleting protected resource %s due to presence of --force"), res.URN)
-						return edit.UnprotectResource(nil, res)
+			if len(args) == 0 && len(selectors) == 0 {
+				return result.Error("must provide a URN corresponding to a resource, or a selector")
+			}
+			sels, err := parseStateSelectors(selectors)
+			if err != nil {
+				return result.FromError(err)
+			}
+			urns := make([]resource.URN, len(args))
+			for i, arg := range args {
+				urns[i] = resource.URN(arg)
+			}
+
+			var count int
+			res := runSelectedStateEdit(ctx, stack, showPrompt, urns, sels,
+				func(snap *deploy.Snapshot, resources []*resource.State) error {
+					var handleProtected func(*resource.State) error
+					if force {
+						handleProtected = func(res *resource.State) error {
+							cmdutil.Diag().Warningf(diag.Message(res.URN,
+								"deleting protected resource %s due to presence of --force"), res.URN)
+							return edit.UnprotectResource(nil, res)
+						}
 					}
-				}
-				return edit.DeleteResource(snap, res, handleProtected, targetDepenedents)
-			})
+
+					// Delete in reverse snapshot order so that selected dependents are removed before the resources
+					// they depend on.
+					for i := len(resources) - 1; i >= 0; i-- {
+						res := resources[i]
+						if !snapshotContains(snap, res) {
+							// Already deleted as a dependent of another selected resource.
+							continue
+						}
+						before := len(snap.Resources)
+						if err := edit.DeleteResource(snap, res, handleProtected, targetDepenedents); err != nil {
+							return err
+						}
+						count += before - len(snap.Resources)
+					}
+					return nil
+				})
 			if res != nil {
 				switch e := res.Error().(type) {
 				case edit.ResourceHasDependenciesError:
@@ -87,7 +118,11 @@ pulumi state delete 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:
 					return res
 				}
 			}
-			fmt.Println("Resource deleted")
+			if count == 1 {
+				fmt.Println("Resource deleted")
+			} else {
+				fmt.Printf("%d resources deleted\n", count)
+			}
 			return nil
 		}),
 	}
@@ -98,5 +133,16 @@ pulumi state delete 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:
 	cmd.Flags().BoolVar(&force, "force", false, "Force deletion of protected resources")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
 	cmd.Flags().BoolVar(&targetDepenedents, "target-dependents", false, "Delete the URN and all its dependents")
+	addStateSelectorFlag(cmd, &selectors)
 	return cmd
 }
+
+// snapshotContains returns true if the given resource is still part of the snapshot.
+func snapshotContains(snap *deploy.Snapshot, res *resource.State) bool {
+	for _, r := range snap.Resources {
+		if r == res {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,288 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	survey "github.com/AlecAivazis/survey/v2"
+	surveycore "github.com/AlecAivazis/survey/v2/core"
+	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+	sdkDisplay "github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+
+	"github.com/spf13/cobra"
+)
+
+func newStateEditCommand() *cobra.Command {
+	var stackName string
+	var format string
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Edit the current stack's state in your editor",
+		Long: `Edit the current stack's state in your editor
+
+This command opens the stack's deployment in the editor named by the VISUAL or EDITOR environment variables.
+Secret values are decrypted so that they can be read and edited, and are re-encrypted with the stack's secrets
+provider once the editor exits.
+
+Before the edited state is written back, it is checked for integrity and a summary of the changes is shown for
+confirmation. If the edited state is invalid you are offered the chance to correct it.`,
+		Args: cmdutil.NoArgs,
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+
+			if format != "yaml" && format != "json" {
+				return result.Errorf("unsupported format %q; must be one of 'yaml' or 'json'", format)
+			}
+			if !cmdutil.Interactive() {
+				return result.Error("pulumi state edit must be run in an interactive terminal")
+			}
+
+			return runStateEditor(ctx, stackName, format, !yes)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stackName, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().StringVar(&format, "format", "yaml", "The format to edit the state in: 'yaml' or 'json'")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+
+	return cmd
+}
+
+func runStateEditor(ctx context.Context, stackName, format string, showPrompt bool) result.Result {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+	s, err := requireStack(ctx, stackName, stackLoadOnly, opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(err)
+	} else if snap == nil {
+		return result.Errorf("stack %s has no state to edit", s.Ref())
+	}
+
+	original, err := encodeStateForEditing(snap, format)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	f, err := os.CreateTemp("", "pulumi-state-*."+format)
+	if err != nil {
+		return result.FromError(err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+	if _, err = f.Write(original); err != nil {
+		contract.IgnoreClose(f)
+		return result.FromError(err)
+	}
+	if err = f.Close(); err != nil {
+		return result.FromError(err)
+	}
+
+	var newSnap *deploy.Snapshot
+	for {
+		if err = openInEditor(path); err != nil {
+			return result.FromError(err)
+		}
+
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return result.FromError(err)
+		}
+		if bytes.Equal(edited, original) {
+			fmt.Println("No changes were made to the state.")
+			return nil
+		}
+
+		newSnap, err = decodeEditedState(ctx, edited, format)
+		if err == nil {
+			if err = newSnap.VerifyIntegrity(); err != nil {
+				err = fmt.Errorf("the edited state is invalid: %w", err)
+			}
+		}
+		if err == nil {
+			break
+		}
+
+		fmt.Println(opts.Color.Colorize(colors.SpecError + "error: " + colors.Reset + err.Error()))
+		if !askToReopenEditor(opts) {
+			return result.Bail()
+		}
+	}
+
+	diffs := edit.DiffSnapshots(snap, newSnap)
+	if len(diffs) == 0 {
+		fmt.Println("No changes were made to the state.")
+		return nil
+	}
+
+	var b bytes.Buffer
+	renderResourceDiffs(&b, diffs)
+	fmt.Print(opts.Color.Colorize(b.String()))
+
+	if showPrompt && !confirmStateEdit(opts, "This command will write these changes to your stack's state. Confirm?") {
+		return result.Bail()
+	}
+
+	if err := saveStateSnapshot(ctx, s, newSnap); err != nil {
+		return result.FromError(err)
+	}
+	fmt.Println("State updated")
+	return nil
+}
+
+// encodeStateForEditing serializes a snapshot into the given format with all secret values in plaintext.
+func encodeStateForEditing(snap *deploy.Snapshot, format string) ([]byte, error) {
+	sdep, err := stack.SerializeDeployment(snap, snap.SecretsManager, true /* showSecrets */)
+	if err != nil {
+		return nil, fmt.Errorf("serializing deployment: %w", err)
+	}
+
+	jsonBytes, err := json.MarshalIndent(sdep, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+	if format == "json" {
+		return append(jsonBytes, '\n'), nil
+	}
+
+	// Round-trip through a generic value so that the YAML document uses the same field names as the JSON form.
+	var v interface{}
+	if err := json.Unmarshal(jsonBytes, &v); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(v)
+}
+
+// decodeEditedState parses a deployment produced by encodeStateForEditing and possibly edited by the user. Plaintext
+// secret values are encrypted using the secrets provider recorded in the deployment.
+func decodeEditedState(ctx context.Context, edited []byte, format string) (*deploy.Snapshot, error) {
+	jsonBytes := edited
+	if format == "yaml" {
+		var v interface{}
+		if err := yaml.Unmarshal(edited, &v); err != nil {
+			return nil, fmt.Errorf("parsing edited state: %w", err)
+		}
+		var err error
+		if jsonBytes, err = json.Marshal(v); err != nil {
+			return nil, fmt.Errorf("parsing edited state: %w", err)
+		}
+	}
+
+	var dep apitype.DeploymentV3
+	if err := json.Unmarshal(jsonBytes, &dep); err != nil {
+		return nil, fmt.Errorf("parsing edited state: %w", err)
+	}
+	return stack.DeserializeDeploymentV3(ctx, dep, stack.DefaultSecretsProvider)
+}
+
+// openInEditor opens the file at the given path in the user's editor and waits for the editor to exit.
+func openInEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		return errors.New("no editor is configured; set the VISUAL or EDITOR environment variable")
+	}
+	//nolint:gosec // The editor is deliberately taken from the user's environment.
+	cmd := exec.Command(args[0], append(args[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+func askToReopenEditor(opts display.Options) bool {
+	reopen := false
+	surveycore.DisableColor = true
+	if err := survey.AskOne(&survey.Confirm{
+		Message: "Do you want to correct the state in your editor?",
+		Default: true,
+	}, &reopen, surveyIcons(opts.Color)); err != nil {
+		return false
+	}
+	return reopen
+}
+
+// renderResourceDiffs renders a set of resource differences in the style of an update's detailed diff. The output
+// contains color tags and must be colorized before it is displayed.
+func renderResourceDiffs(b *bytes.Buffer, diffs []edit.ResourceDiff) {
+	for _, diff := range diffs {
+		switch diff.Kind {
+		case edit.ResourceAdded:
+			renderResourceDiffHeader(b, deploy.OpCreate, diff)
+			display.PrintObject(b, diff.New.Outputs, false, 2, deploy.OpCreate, true, false, false)
+		case edit.ResourceRemoved:
+			renderResourceDiffHeader(b, deploy.OpDelete, diff)
+			display.PrintObject(b, diff.Old.Outputs, false, 2, deploy.OpDelete, true, false, false)
+		case edit.ResourceChanged:
+			renderResourceDiffHeader(b, deploy.OpUpdate, diff)
+			for _, field := range diff.Fields {
+				fmt.Fprintf(b, "%s    ~ %s%s\n", deploy.Color(deploy.OpUpdate), field, colors.Reset)
+			}
+			if diff.Inputs.AnyChanges() {
+				fmt.Fprintf(b, "    inputs:\n")
+				display.PrintObjectDiff(b, *diff.Inputs, nil, false, 3, false, false, false)
+			}
+			if diff.Outputs.AnyChanges() {
+				fmt.Fprintf(b, "    outputs:\n")
+				display.PrintObjectDiff(b, *diff.Outputs, nil, false, 3, false, false, false)
+			}
+		}
+		b.WriteString(colors.Reset)
+	}
+}
+
+func renderResourceDiffHeader(b *bytes.Buffer, op sdkDisplay.StepOp, diff edit.ResourceDiff) {
+	fmt.Fprintf(b, "%s%s%s%s\n", deploy.Color(op), deploy.RawPrefix(op), diff.URN, colors.Reset)
+}
@@ -0,0 +1,260 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend"
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/pkg/v3/version"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+
+	"github.com/spf13/cobra"
+)
+
+func newStateMoveCommand() *cobra.Command {
+	var sourceStackName string
+	var destStackName string
+	var includeChildren bool
+	var includeDependencies bool
+	var yes bool
+	var selectors []string
+
+	cmd := &cobra.Command{
+		Use:   "move --dest <stack> [resource URN...]",
+		Short: "Move resources from one stack to another",
+		Long: `Move resources from one stack to another
+
+This command moves one or more resources from the state of a source stack into the state of a destination stack.
+The URNs of the moved resources are rewritten to belong to the destination stack and project, their secrets are
+re-encrypted with the destination stack's secrets provider, and the providers they use are carried along. Providers
+that are still used by resources remaining in the source stack are copied rather than moved.
+
+A resource can only be moved together with its children and the resources it depends on. Pass --include-children
+and --include-dependencies to move those along automatically.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+
+Example:
+pulumi state move --source dev --dest dev-network 'urn:pulumi:dev::demo::aws:ec2/vpc:Vpc::main'
+pulumi state move --source dev --dest dev-network --include-children --select 'type=aws:ec2/*'
+`,
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+
+			if destStackName == "" {
+				return result.Error("must provide a destination stack with --dest")
+			}
+			if len(args) == 0 && len(selectors) == 0 {
+				return result.Error("must provide a URN corresponding to a resource, or a selector")
+			}
+			sels, err := parseStateSelectors(selectors)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			urns := make([]resource.URN, len(args))
+			for i, arg := range args {
+				urns[i] = resource.URN(arg)
+				if !urns[i].IsValid() {
+					return result.Errorf("%q is not a valid URN", arg)
+				}
+			}
+
+			return runStateMove(ctx, sourceStackName, destStackName, urns, sels, edit.MoveOptions{
+				IncludeChildren:     includeChildren,
+				IncludeDependencies: includeDependencies,
+			}, !yes)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&sourceStackName, "source", "", "",
+		"The name of the stack to move resources from. Defaults to the current stack")
+	cmd.PersistentFlags().StringVarP(
+		&destStackName, "dest", "", "",
+		"The name of the stack to move resources to")
+	cmd.Flags().BoolVar(&includeChildren, "include-children", false,
+		"Also move all children of the given resources")
+	cmd.Flags().BoolVar(&includeDependencies, "include-dependencies", false,
+		"Also move all resources that the given resources depend on")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	addStateSelectorFlag(cmd, &selectors)
+
+	return cmd
+}
+
+func runStateMove(ctx context.Context, sourceStackName, destStackName string, urns []resource.URN,
+	selectors []*edit.Selector, moveOpts edit.MoveOptions, showPrompt bool,
+) result.Result {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+
+	source, err := requireStack(ctx, sourceStackName, stackLoadOnly, opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+	dest, err := requireStack(ctx, destStackName, stackLoadOnly, opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+	if source.Ref().FullyQualifiedName() == dest.Ref().FullyQualifiedName() {
+		return result.Error("the source and destination stacks must be different")
+	}
+
+	sourceSnap, err := source.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(err)
+	} else if sourceSnap == nil {
+		return result.Errorf("the source stack %s has no resources", source.Ref())
+	}
+	destSnap, err := loadMoveDestinationSnapshot(ctx, dest)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	if err := sourceSnap.VerifyIntegrity(); err != nil {
+		return result.Errorf("the source stack's state is invalid: %v", err)
+	}
+	if err := destSnap.VerifyIntegrity(); err != nil {
+		return result.Errorf("the destination stack's state is invalid: %v", err)
+	}
+
+	if len(selectors) > 0 {
+		selected, err := locateStackResources(opts, sourceSnap, nil, selectors)
+		if err != nil {
+			return result.FromError(err)
+		}
+		for _, res := range selected {
+			// Providers and the root stack resource are never moved directly, so skip them if a broad selector
+			// happened to match them.
+			if res.Type == resource.RootStackType || providers.IsProviderType(res.Type) {
+				continue
+			}
+			urns = append(urns, res.URN)
+		}
+	}
+	if len(urns) == 0 {
+		return result.Error("no movable resources were selected")
+	}
+
+	// Prefer the project recorded on the destination stack; older filestate backends don't record one, in which case
+	// the resources stay in the project they came from.
+	destProject := tokens.PackageName(dest.Ref().Project())
+	if destProject == "" {
+		destProject = urns[0].Project()
+	}
+
+	moved, err := edit.MoveResources(sourceSnap, destSnap, urns, dest.Ref().Name().Q(), destProject, moveOpts)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	fmt.Printf("The following resources will be moved from %s to %s:\n", source.Ref(), dest.Ref())
+	oldURNs := make([]resource.URN, 0, len(moved.Moved))
+	for oldURN := range moved.Moved {
+		oldURNs = append(oldURNs, oldURN)
+	}
+	sort.Slice(oldURNs, func(i, j int) bool { return oldURNs[i] < oldURNs[j] })
+	for _, oldURN := range oldURNs {
+		fmt.Printf("  - %s\n    => %s\n", oldURN, moved.Moved[oldURN])
+	}
+	for _, urn := range moved.CopiedProviders {
+		fmt.Printf("The provider %s is still in use in %s and will be copied.\n", urn, source.Ref())
+	}
+
+	if showPrompt && !confirmStateEdit(opts, "This command will edit the state of both stacks directly. Confirm?") {
+		return result.Bail()
+	}
+
+	// Write the destination first: if writing the source fails afterwards, the resources are duplicated rather than
+	// lost, and the duplicates can be removed again with `pulumi state delete`.
+	if err := saveStateSnapshot(ctx, dest, destSnap); err != nil {
+		return result.FromError(fmt.Errorf("writing destination stack %s: %w", dest.Ref(), err))
+	}
+	if err := saveStateSnapshot(ctx, source, sourceSnap); err != nil {
+		return result.FromError(fmt.Errorf("writing source stack %s (the resources have already been added to "+
+			"%s and should be removed from one of the stacks): %w", source.Ref(), dest.Ref(), err))
+	}
+
+	fmt.Printf("Successfully moved %d resources\n", len(moved.Moved))
+	return nil
+}
+
+// loadMoveDestinationSnapshot loads the snapshot of the destination stack of a move. If the stack has no state yet, an
+// empty snapshot is created that uses the stack's configured secrets manager. That configuration is read from the
+// project in the current directory, so the destination stack must belong to it.
+func loadMoveDestinationSnapshot(ctx context.Context, s backend.Stack) (*deploy.Snapshot, error) {
+	snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return nil, err
+	}
+	if snap != nil && snap.SecretsManager != nil {
+		return snap, nil
+	}
+
+	project, _, err := readProject()
+	if err != nil {
+		return nil, err
+	}
+	if destProject := s.Ref().Project(); destProject != "" && string(destProject) != string(project.Name) {
+		return nil, fmt.Errorf("the destination stack %s belongs to project %s, but the current project is %s; "+
+			"run this command from the directory of project %s to move resources into a stack without state",
+			s.Ref(), destProject, project.Name, destProject)
+	}
+	ps, err := loadProjectStack(project, s)
+	if err != nil {
+		return nil, err
+	}
+	sm, needsSave, err := getStackSecretsManager(s, ps)
+	if err != nil {
+		return nil, err
+	}
+	if needsSave {
+		if err := saveProjectStack(s, ps); err != nil {
+			return nil, err
+		}
+	}
+	if sm == nil {
+		return nil, errors.New("the destination stack has no secrets provider configured")
+	}
+
+	if snap != nil {
+		snap.SecretsManager = sm
+		return snap, nil
+	}
+
+	manifest := deploy.Manifest{
+		Time:    time.Now(),
+		Version: version.Version,
+	}
+	manifest.Magic = manifest.NewMagic()
+	return deploy.NewSnapshot(manifest, sm, nil, nil), nil
+}
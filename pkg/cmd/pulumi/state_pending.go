@@ -0,0 +1,342 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	survey "github.com/AlecAivazis/survey/v2"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/pkg/v3/resource/stack"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+
+	"github.com/spf13/cobra"
+)
+
+func newStatePendingCommand() *cobra.Command {
+	var stack string
+	var yes bool
+	var resolve string
+	var id string
+
+	cmd := &cobra.Command{
+		Use:   "pending [resource URN...]",
+		Short: "List and resolve the pending operations in a stack's state",
+		Long: `List and resolve the pending operations in a stack's state
+
+When an update is interrupted, the operations that were running are recorded in the stack's state as pending
+operations, because the resources they apply to are in an unknown state. This command lists the pending operations,
+or those of the given resources, and resolves them in one of the following ways:
+
+  adopt   Read the resource from its provider, and record its live state. If the resource doesn't exist, it is
+          removed from the state.
+  drop    Discard the operation, leaving the rest of the state as it is.
+  delete  Mark the resource for deletion, so that the next update deletes it.
+
+In an interactive terminal, the resolution of each operation is asked for in turn. Otherwise, the resolution of every
+listed operation is given by --resolve. The ID of a resource whose create was interrupted is unknown; it's asked for,
+or can be given with --id when a single URN is passed.
+
+Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
+
+Example:
+pulumi state pending
+pulumi state pending --resolve adopt --id i-0a1b2c3d 'urn:pulumi:dev::demo::aws:ec2/instance:Instance::web'
+`,
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			var resolution edit.PendingResolution
+			if resolve != "" {
+				r, err := edit.ParsePendingResolution(resolve)
+				if err != nil {
+					return result.FromError(err)
+				}
+				resolution = r
+			}
+			if id != "" && len(args) != 1 {
+				return result.Error("--id can only be given with a single URN")
+			}
+
+			urns := make([]resource.URN, len(args))
+			for i, arg := range args {
+				urns[i] = resource.URN(arg)
+			}
+			return runStatePending(ctx, stack, urns, resolution, resource.ID(id), showPrompt)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	cmd.Flags().StringVar(&resolve, "resolve", "",
+		"Resolve every listed pending operation in the given way: adopt, drop or delete")
+	cmd.Flags().StringVar(&id, "id", "",
+		"The ID of the resource of the pending operation, if it isn't known")
+	return cmd
+}
+
+func runStatePending(ctx context.Context, stackName string, urns []resource.URN,
+	resolution edit.PendingResolution, id resource.ID, showPrompt bool,
+) result.Result {
+	opts := display.Options{
+		Color: cmdutil.GetGlobalColorization(),
+	}
+	s, err := requireStack(ctx, stackName, stackOfferNew, opts)
+	if err != nil {
+		return result.FromError(err)
+	}
+	snap, err := s.Snapshot(ctx, stack.DefaultSecretsProvider)
+	if err != nil {
+		return result.FromError(err)
+	}
+
+	ops, err := selectPendingOperations(snap, urns)
+	if err != nil {
+		return result.FromError(err)
+	}
+	if len(ops) == 0 {
+		fmt.Println("There are no pending operations")
+		return nil
+	}
+	fmt.Printf("There are %d pending operations:\n", len(ops))
+	for _, op := range ops {
+		fmt.Printf("  * %s, interrupted while %s\n", op.Resource.URN, op.Type)
+	}
+
+	interactive := cmdutil.Interactive()
+	if resolution == "" && !interactive {
+		return nil
+	}
+	if resolution != "" && showPrompt &&
+		!confirmStateEdit(opts, fmt.Sprintf("This command will %s every listed operation. Confirm?", resolution)) {
+		return result.Bail()
+	}
+
+	var resolved int
+	res := totalStateEdit(ctx, s, false, opts, func(opts display.Options, snap *deploy.Snapshot) error {
+		ops, err := selectPendingOperations(snap, urns)
+		if err != nil {
+			return err
+		}
+		for _, op := range ops {
+			r := resolution
+			if r == "" {
+				if r, err = askPendingResolution(op); err != nil {
+					return err
+				} else if r == "" {
+					continue
+				}
+			}
+			if err := resolvePendingOperation(snap, op, r, id, interactive); err != nil {
+				return err
+			}
+			resolved++
+		}
+		if resolved == 0 {
+			return errStateEditDeclined
+		}
+
+		// Resolving the operations must leave the state valid, since it can't be repaired by a later update otherwise.
+		if err := snap.VerifyIntegrity(); err != nil {
+			return fmt.Errorf("resolving the pending operations would leave the stack's state invalid: %w", err)
+		}
+		return nil
+	})
+	if res != nil && errors.Is(res.Error(), errStateEditDeclined) {
+		fmt.Println("No pending operations were resolved")
+		return nil
+	} else if res != nil {
+		return res
+	}
+
+	if resolved == 1 {
+		fmt.Println("Pending operation resolved")
+	} else {
+		fmt.Printf("%d pending operations resolved\n", resolved)
+	}
+	return nil
+}
+
+// selectPendingOperations returns the pending operations of the snapshot that apply to the given resources, or all of
+// them if no URNs are given. Each URN must have a pending operation.
+func selectPendingOperations(snap *deploy.Snapshot, urns []resource.URN) ([]resource.Operation, error) {
+	if snap == nil {
+		if len(urns) > 0 {
+			return nil, fmt.Errorf("%q has no pending operation", urns[0])
+		}
+		return nil, nil
+	}
+	if len(urns) == 0 {
+		return snap.PendingOperations, nil
+	}
+
+	selected := map[resource.URN]bool{}
+	for _, urn := range urns {
+		selected[urn] = false
+	}
+	var ops []resource.Operation
+	for _, op := range snap.PendingOperations {
+		if _, ok := selected[op.Resource.URN]; ok {
+			selected[op.Resource.URN] = true
+			ops = append(ops, op)
+		}
+	}
+	for _, urn := range urns {
+		if !selected[urn] {
+			return nil, fmt.Errorf("%q has no pending operation", urn)
+		}
+	}
+	return ops, nil
+}
+
+// askPendingResolution asks the user how to resolve the given pending operation. It returns an empty resolution if the
+// operation should be skipped.
+func askPendingResolution(op resource.Operation) (edit.PendingResolution, error) {
+	options := []string{
+		"adopt (read the resource from its provider and record its live state)",
+		"drop (discard the operation, leaving the rest of the state as it is)",
+		"delete (mark the resource for deletion by the next update)",
+		"skip (do nothing)",
+	}
+	var option string
+	if err := survey.AskOne(&survey.Select{
+		Message: fmt.Sprintf("Options for pending %s of %s", op.Type, op.Resource.URN),
+		Options: options,
+	}, &option, nil); err != nil {
+		return "", fmt.Errorf("no option selected: %w", err)
+	}
+
+	switch option {
+	case options[0]:
+		return edit.PendingAdopt, nil
+	case options[1]:
+		return edit.PendingDrop, nil
+	case options[2]:
+		return edit.PendingDelete, nil
+	default:
+		return "", nil
+	}
+}
+
+// resolvePendingOperation resolves the given pending operation of the snapshot in the given way. If the ID of the
+// operation's resource is needed but unknown, id is used, or the user is asked for it if id is empty.
+func resolvePendingOperation(snap *deploy.Snapshot, op resource.Operation, resolution edit.PendingResolution,
+	id resource.ID, interactive bool,
+) error {
+	if resolution != edit.PendingDrop && op.Resource.ID == "" {
+		if id == "" && interactive {
+			var answer string
+			if err := survey.AskOne(&survey.Input{
+				Message: fmt.Sprintf("ID of %s: ", op.Resource.URN),
+			}, &answer, nil); err != nil {
+				return err
+			}
+			id = resource.ID(answer)
+		}
+		if id == "" {
+			return fmt.Errorf("the ID of %q is unknown; pass it with --id", op.Resource.URN)
+		}
+		op.Resource.ID = id
+	}
+
+	switch resolution {
+	case edit.PendingAdopt:
+		live, err := readPendingResource(snap, op)
+		if err != nil {
+			return err
+		}
+		return edit.AdoptPendingOperation(snap, op, live)
+	case edit.PendingDrop:
+		return edit.DropPendingOperation(snap, op)
+	case edit.PendingDelete:
+		return edit.DeletePendingOperation(snap, op)
+	default:
+		return fmt.Errorf("unknown resolution %q", resolution)
+	}
+}
+
+// readPendingResource reads the live state of the resource of a pending operation from the resource's provider. It
+// returns nil if the resource doesn't exist.
+func readPendingResource(snap *deploy.Snapshot, op resource.Operation) (*resource.State, error) {
+	res := op.Resource
+	ref, err := providers.ParseReference(res.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("parsing the provider of %q: %w", res.URN, err)
+	}
+	var provider *resource.State
+	for _, r := range snap.Resources {
+		if r.URN == ref.URN() && r.ID == ref.ID() {
+			provider = r
+		}
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("the provider of %q is not in the stack's state", res.URN)
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("get working directory: %w", err)
+	}
+	sink := cmdutil.Diag()
+	pCtx, err := plugin.NewContext(sink, sink, nil, nil, cwd, nil, true, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create plugin context: %w", err)
+	}
+	defer contract.IgnoreClose(pCtx)
+
+	reg, err := providers.NewRegistry(pCtx.Host, []*resource.State{provider}, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("loading the provider of %q: %w", res.URN, err)
+	}
+	prov, ok := reg.GetProvider(ref)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", ref)
+	}
+
+	read, _, err := prov.Read(res.URN, res.ID, res.Inputs, res.Outputs)
+	if err != nil {
+		return nil, fmt.Errorf("reading %q: %w", res.URN, err)
+	}
+	if read.Outputs == nil {
+		return nil, nil
+	}
+
+	live := *res
+	if read.ID != "" {
+		live.ID = read.ID
+	}
+	if read.Inputs != nil {
+		live.Inputs = read.Inputs
+	}
+	live.Outputs = read.Outputs
+	return &live, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/backend/display"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/edit"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/cmdutil"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+
+	"github.com/spf13/cobra"
+)
+
+func newStateProtectCommand() *cobra.Command {
+	var protectAll bool
+	var stack string
+	var yes bool
+	var selectors []string
+
+	cmd := &cobra.Command{
+		Use:   "protect [resource URN...]",
+		Short: "Protect resources in a stack's state",
+		Long: `Protect resources in a stack's state
+
+This command sets the 'protect' bit on one or more resources, preventing those resources from being deleted.
+Resources can be given by URN, chosen with --select, or all resources can be protected with --all.`,
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
+		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+			ctx := commandContext()
+			yes = yes || skipConfirmations()
+			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
+			showPrompt := !yes
+
+			if protectAll {
+				return protectAllResources(ctx, stack, showPrompt)
+			}
+
+			if len(args) == 0 && len(selectors) == 0 {
+				return result.Error("must provide a URN corresponding to a resource, or a selector")
+			}
+			sels, err := parseStateSelectors(selectors)
+			if err != nil {
+				return result.FromError(err)
+			}
+
+			urns := make([]resource.URN, len(args))
+			for i, arg := range args {
+				urns[i] = resource.URN(arg)
+			}
+			return protectResources(ctx, stack, urns, sels, showPrompt)
+		}),
+	}
+
+	cmd.PersistentFlags().StringVarP(
+		&stack, "stack", "s", "",
+		"The name of the stack to operate on. Defaults to the current stack")
+	cmd.Flags().BoolVar(&protectAll, "all", false, "Protect all resources in the checkpoint")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	addStateSelectorFlag(cmd, &selectors)
+
+	return cmd
+}
+
+func protectAllResources(ctx context.Context, stackName string, showPrompt bool) result.Result {
+	res := runTotalStateEdit(ctx, stackName, showPrompt, func(_ display.Options, snap *deploy.Snapshot) error {
+		// Protects against Panic when a user tries to protect non-existing resources
+		if snap == nil {
+			return fmt.Errorf("no resources found to protect")
+		}
+
+		for _, res := range snap.Resources {
+			err := edit.ProtectResource(snap, res)
+			contract.AssertNoErrorf(err, "Unable to protect resource %q", res.URN)
+		}
+
+		return nil
+	})
+
+	if res != nil {
+		return res
+	}
+	fmt.Println("All resources protected")
+	return nil
+}
+
+func protectResources(ctx context.Context, stackName string, urns []resource.URN, selectors []*edit.Selector,
+	showPrompt bool,
+) result.Result {
+	var count int
+	res := runSelectedStateEdit(ctx, stackName, showPrompt, urns, selectors,
+		func(snap *deploy.Snapshot, resources []*resource.State) error {
+			for _, res := range resources {
+				if err := edit.ProtectResource(snap, res); err != nil {
+					return err
+				}
+			}
+			count = len(resources)
+			return nil
+		})
+	if res != nil {
+		return res
+	}
+	if count == 1 {
+		fmt.Println("Resource protected")
+	} else {
+		fmt.Printf("%d resources protected\n", count)
+	}
+	return nil
+}
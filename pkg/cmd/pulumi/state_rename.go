@@ -114,34 +114,55 @@ func stateRenameOperation(urn resource.URN, newResourceName string, opts display
 func newStateRenameCommand() *cobra.Command {
 	var stack string
 	var yes bool
+	var selectors []string
 
 	cmd := &cobra.Command{
-		Use:   "rename <resource URN> <new name>",
+		Use:   "rename [resource URN] <new name>",
 		Short: "Renames a resource from a stack's state",
 		Long: `Renames a resource from a stack's state
 
 This command renames a resource from a stack's state. The resource is specified
-by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it) and the new name of the resource.
+by its Pulumi URN (use ` + "`pulumi stack --show-urns`" + ` to get it), or by a --select selector that
+matches exactly one resource, and the new name of the resource.
 
 Make sure that URNs are single-quoted to avoid having characters unexpectedly interpreted by the shell.
 
 Example:
 pulumi state rename 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:kubernetes::eks-provider' new-name-here
 `,
-		Args: cmdutil.ExactArgs(2),
+		Args: cmdutil.RangeArgs(1, 2),
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 			yes = yes || skipConfirmations()
-			urn := resource.URN(args[0])
-			newResourceName := args[1]
 			// Show the confirmation prompt if the user didn't pass the --yes parameter to skip it.
 			showPrompt := !yes
 
-			if !urn.IsValid() {
-				return result.Error("The provided input URN is not valid")
+			var urn resource.URN
+			var sels []*edit.Selector
+			switch {
+			case len(selectors) > 0 && len(args) == 1:
+				var err error
+				if sels, err = parseStateSelectors(selectors); err != nil {
+					return result.FromError(err)
+				}
+			case len(selectors) == 0 && len(args) == 2:
+				urn = resource.URN(args[0])
+				if !urn.IsValid() {
+					return result.Error("The provided input URN is not valid")
+				}
+			default:
+				return result.Error("must provide either a URN or a selector, followed by the new name")
 			}
+			newResourceName := args[len(args)-1]
 
 			res := runTotalStateEdit(ctx, stack, showPrompt, func(opts display.Options, snap *deploy.Snapshot) error {
+				if len(sels) > 0 {
+					selected := edit.SelectResources(snap, sels)
+					if len(selected) != 1 {
+						return fmt.Errorf("the selector must match exactly one resource, but it matched %d", len(selected))
+					}
+					urn = selected[0].URN
+				}
 				return stateRenameOperation(urn, newResourceName, opts, snap)
 			})
 
@@ -161,5 +182,6 @@ pulumi state rename 'urn:pulumi:stage::demo::eks:index:Cluster$pulumi:providers:
 		"The name of the stack to operate on. Defaults to the current stack")
 
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	addStateSelectorFlag(cmd, &selectors)
 	return cmd
 }
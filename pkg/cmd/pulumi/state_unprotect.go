@@ -33,14 +33,16 @@ func newStateUnprotectCommand() *cobra.Command {
 	var unprotectAll bool
 	var stack string
 	var yes bool
+	var selectors []string
 
 	cmd := &cobra.Command{
-		Use:   "unprotect <resource URN>",
+		Use:   "unprotect [resource URN...]",
 		Short: "Unprotect resources in a stack's state",
 		Long: `Unprotect resource in a stack's state
 
-This command clears the 'protect' bit on one or more resources, allowing those resources to be deleted.`,
-		Args: cmdutil.MaximumNArgs(1),
+This command clears the 'protect' bit on one or more resources, allowing those resources to be deleted.
+Resources can be given by URN, chosen with --select, or all resources can be unprotected with --all.`,
+		Args: cmdutil.ArgsFunc(cobra.ArbitraryArgs),
 		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
 			yes = yes || skipConfirmations()
@@ -51,12 +53,19 @@ This command clears the 'protect' bit on one or more resources, allowing those r
 				return unprotectAllResources(ctx, stack, showPrompt)
 			}
 
-			if len(args) != 1 {
-				return result.Error("must provide a URN corresponding to a resource")
+			if len(args) == 0 && len(selectors) == 0 {
+				return result.Error("must provide a URN corresponding to a resource, or a selector")
+			}
+			sels, err := parseStateSelectors(selectors)
+			if err != nil {
+				return result.FromError(err)
 			}
 
-			urn := resource.URN(args[0])
-			return unprotectResource(ctx, stack, urn, showPrompt)
+			urns := make([]resource.URN, len(args))
+			for i, arg := range args {
+				urns[i] = resource.URN(arg)
+			}
+			return unprotectResources(ctx, stack, urns, sels, showPrompt)
 		}),
 	}
 
@@ -65,6 +74,7 @@ This command clears the 'protect' bit on one or more resources, allowing those r
 		"The name of the stack to operate on. Defaults to the current stack")
 	cmd.Flags().BoolVar(&unprotectAll, "all", false, "Unprotect all resources in the checkpoint")
 	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Skip confirmation prompts")
+	addStateSelectorFlag(cmd, &selectors)
 
 	return cmd
 }
@@ -91,11 +101,27 @@ func unprotectAllResources(ctx context.Context, stackName string, showPrompt boo
 	return nil
 }
 
-func unprotectResource(ctx context.Context, stackName string, urn resource.URN, showPrompt bool) result.Result {
-	res := runStateEdit(ctx, stackName, showPrompt, urn, edit.UnprotectResource)
+func unprotectResources(ctx context.Context, stackName string, urns []resource.URN, selectors []*edit.Selector,
+	showPrompt bool,
+) result.Result {
+	var count int
+	res := runSelectedStateEdit(ctx, stackName, showPrompt, urns, selectors,
+		func(snap *deploy.Snapshot, resources []*resource.State) error {
+			for _, res := range resources {
+				if err := edit.UnprotectResource(snap, res); err != nil {
+					return err
+				}
+			}
+			count = len(resources)
+			return nil
+		})
 	if res != nil {
 		return res
 	}
-	fmt.Println("Resource unprotected")
+	if count == 1 {
+		fmt.Println("Resource unprotected")
+	} else {
+		fmt.Printf("%d resources unprotected\n", count)
+	}
 	return nil
 }
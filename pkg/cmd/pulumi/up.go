@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -39,6 +40,10 @@ import (
 
 const (
 	defaultParallel = math.MaxInt32
+
+	// defaultTimeoutGracePeriod is how long running operations are given to finish once an operation's --timeout
+	// has passed.
+	defaultTimeoutGracePeriod = 30 * time.Second
 )
 
 // intentionally disabling here for cleaner err declaration/assignment.
@@ -80,7 +85,16 @@ func newUpCmd() *cobra.Command {
 	var replaces []string
 	var targetReplaces []string
 	var targetDependents bool
+	var excludes []string
+	var excludeDependents bool
 	var planFilePath string
+	var continueOnError bool
+	var timeout time.Duration
+	var timeoutGracePeriod time.Duration
+
+	// The deadline of the update, if --timeout was passed. It's computed when the command starts, so that it covers
+	// both the preview and the update.
+	var deadline time.Time
 
 	// up implementation used when the source of the Pulumi program is in the current working directory.
 	upWorkingDirectory := func(ctx context.Context, opts backend.UpdateOptions, cmd *cobra.Command) result.Result {
@@ -146,10 +160,15 @@ func newUpCmd() *cobra.Command {
 			DisableOutputValues:       disableOutputValues(),
 			UpdateTargets:             deploy.NewUrnTargets(targetURNs),
 			TargetDependents:          targetDependents,
+			ExcludeTargets:            deploy.NewUrnTargets(excludes),
+			ExcludeDependents:         excludeDependents,
 			// Trigger a plan to be generated during the preview phase which can be constrained to during the
 			// update phase.
-			GeneratePlan: true,
-			Experimental: hasExperimentalCommands(),
+			GeneratePlan:        true,
+			Experimental:        hasExperimentalCommands(),
+			ContinueOnError:     continueOnError,
+			Deadline:            deadline,
+			DeadlineGracePeriod: timeoutGracePeriod,
 		}
 
 		if planFilePath != "" {
@@ -354,8 +373,11 @@ func newUpCmd() *cobra.Command {
 			Refresh:          refreshOption,
 			// If we're in experimental mode then we trigger a plan to be generated during the preview phase
 			// which will be constrained to during the update phase.
-			GeneratePlan: hasExperimentalCommands(),
-			Experimental: hasExperimentalCommands(),
+			GeneratePlan:        hasExperimentalCommands(),
+			Experimental:        hasExperimentalCommands(),
+			ContinueOnError:     continueOnError,
+			Deadline:            deadline,
+			DeadlineGracePeriod: timeoutGracePeriod,
 		}
 
 		// TODO for the URL case:
@@ -402,8 +424,11 @@ func newUpCmd() *cobra.Command {
 			"The program to run is loaded from the project in the current directory by default. Use the `-C` or\n" +
 			"`--cwd` flag to use a different directory.",
 		Args: cmdutil.MaximumNArgs(1),
-		Run: cmdutil.RunResultFunc(func(cmd *cobra.Command, args []string) result.Result {
+		Run: runWithDeadline(func(cmd *cobra.Command, args []string) result.Result {
 			ctx := commandContext()
+			if timeout > 0 {
+				deadline = time.Now().Add(timeout)
+			}
 
 			// Remote implies we're skipping previews.
 			if remoteArgs.remote {
@@ -537,6 +562,14 @@ func newUpCmd() *cobra.Command {
 	cmd.PersistentFlags().BoolVar(
 		&targetDependents, "target-dependents", false,
 		"Allows updating of dependent targets discovered but not specified in --target list")
+	cmd.PersistentFlags().StringArrayVar(
+		&excludes, "exclude", []string{},
+		"Specify a single resource URN to ignore. These resources will not be updated."+
+			" Multiple resources can be specified using --exclude urn1 --exclude urn2."+
+			" Wildcards (*, **) are also supported")
+	cmd.PersistentFlags().BoolVar(
+		&excludeDependents, "exclude-dependents", false,
+		"Also ignore the resources that depend on or are children of the resources in the --exclude list")
 
 	// Flags for engine.UpdateOptions.
 	cmd.PersistentFlags().StringSliceVar(
@@ -554,6 +587,16 @@ func newUpCmd() *cobra.Command {
 	cmd.PersistentFlags().IntVarP(
 		&parallel, "parallel", "p", defaultParallel,
 		"Allow P resource operations to run in parallel at once (1 for no parallelism). Defaults to unbounded.")
+	cmd.PersistentFlags().BoolVar(
+		&continueOnError, "continue-on-error", false,
+		"Continue updating the resources that don't depend on a failed resource, and report all failures at the end")
+	cmd.PersistentFlags().DurationVar(
+		&timeout, "timeout", 0,
+		"Stop the update if it hasn't finished after this long (e.g. 30m). Operations still running are given the "+
+			"grace period to finish, and are recorded as pending operations otherwise")
+	cmd.PersistentFlags().DurationVar(
+		&timeoutGracePeriod, "timeout-grace-period", defaultTimeoutGracePeriod,
+		"How long to wait for running operations to finish once the --timeout has passed")
 	cmd.PersistentFlags().StringVarP(
 		&refresh, "refresh", "r", "",
 		"Refresh the state of the stack's resources before this update")
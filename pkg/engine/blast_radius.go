@@ -0,0 +1,92 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// HighImpactDependents is the number of changed dependents from which the replacement of a resource is flagged as
+// high impact in the blast radius of a preview.
+const HighImpactDependents = 10
+
+// plannedOp is the logical operation planned for a resource, along with the prior state of the resource it applies
+// to, if any.
+type plannedOp struct {
+	op  display.StepOp
+	old *resource.State
+}
+
+// computeBlastRadius describes the resources affected by the replacements and deletions planned for the resources of
+// the given snapshot. planned holds the logical operation planned for each resource. For each resource that would be
+// replaced or deleted, the resources that depend on it, directly or indirectly, and that would be changed too are
+// listed. It returns nil if nothing would be replaced or deleted.
+func computeBlastRadius(prev *deploy.Snapshot, planned map[resource.URN]plannedOp) *display.BlastRadius {
+	radius := &display.BlastRadius{}
+	for _, p := range planned {
+		switch p.op {
+		case deploy.OpReplace:
+			radius.Replaces++
+		case deploy.OpDelete:
+			radius.Deletes++
+		}
+	}
+	if radius.Replaces == 0 && radius.Deletes == 0 {
+		return nil
+	}
+	if prev == nil {
+		return radius
+	}
+
+	// A snapshot can hold several resources with the same URN if some of them are pending deletion. Only the resource
+	// that an operation applies to is attributed to it.
+	plannedFor := func(res *resource.State) (display.StepOp, bool) {
+		p, has := planned[res.URN]
+		return p.op, has && p.old == res
+	}
+
+	dg := graph.NewDependencyGraph(prev.Resources)
+	for _, res := range prev.Resources {
+		op, has := plannedFor(res)
+		if !has || (op != deploy.OpReplace && op != deploy.OpDelete) {
+			continue
+		}
+
+		r := display.BlastRadiusResource{
+			URN:            res.URN,
+			Op:             op,
+			Protect:        res.Protect,
+			RetainOnDelete: res.RetainOnDelete,
+		}
+		for _, dep := range dg.DependingOn(res, nil, true /*includeChildren*/) {
+			depOp, has := plannedFor(dep)
+			if !has || depOp == deploy.OpSame {
+				continue
+			}
+			r.Dependents = append(r.Dependents, display.BlastRadiusDependent{
+				URN:            dep.URN,
+				Op:             depOp,
+				Protect:        dep.Protect,
+				RetainOnDelete: dep.RetainOnDelete,
+			})
+		}
+		r.HighImpact = op == deploy.OpReplace && len(r.Dependents) >= HighImpactDependents
+		radius.Resources = append(radius.Resources, r)
+	}
+	return radius
+}
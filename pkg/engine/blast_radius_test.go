@@ -0,0 +1,111 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+func blastRadiusURN(name string) resource.URN {
+	return resource.URN("urn:pulumi:stack::project::pkgA:m:typA::" + name)
+}
+
+func TestComputeBlastRadius(t *testing.T) {
+	t.Parallel()
+
+	// db <- app <- dns, with app protected and dns retained on delete. cache depends on nothing and is deleted, and
+	// log depends on db but is unchanged.
+	db := &resource.State{URN: blastRadiusURN("db")}
+	app := &resource.State{URN: blastRadiusURN("app"), Protect: true, Dependencies: []resource.URN{db.URN}}
+	dns := &resource.State{URN: blastRadiusURN("dns"), RetainOnDelete: true, Dependencies: []resource.URN{app.URN}}
+	log := &resource.State{URN: blastRadiusURN("log"), Dependencies: []resource.URN{db.URN}}
+	cache := &resource.State{URN: blastRadiusURN("cache")}
+	prev := &deploy.Snapshot{Resources: []*resource.State{db, app, dns, log, cache}}
+
+	radius := computeBlastRadius(prev, map[resource.URN]plannedOp{
+		db.URN:    {op: deploy.OpReplace, old: db},
+		app.URN:   {op: deploy.OpUpdate, old: app},
+		dns.URN:   {op: deploy.OpReplace, old: dns},
+		log.URN:   {op: deploy.OpSame, old: log},
+		cache.URN: {op: deploy.OpDelete, old: cache},
+	})
+	require.NotNil(t, radius)
+	assert.Equal(t, &display.BlastRadius{
+		Replaces: 2,
+		Deletes:  1,
+		Resources: []display.BlastRadiusResource{
+			{
+				URN: db.URN,
+				Op:  deploy.OpReplace,
+				Dependents: []display.BlastRadiusDependent{
+					{URN: app.URN, Op: deploy.OpUpdate, Protect: true},
+					{URN: dns.URN, Op: deploy.OpReplace, RetainOnDelete: true},
+				},
+			},
+			{URN: dns.URN, Op: deploy.OpReplace, RetainOnDelete: true},
+			{URN: cache.URN, Op: deploy.OpDelete},
+		},
+	}, radius)
+}
+
+func TestComputeBlastRadiusHighImpact(t *testing.T) {
+	t.Parallel()
+
+	root := &resource.State{URN: blastRadiusURN("root")}
+	resources := []*resource.State{root}
+	ops := map[resource.URN]plannedOp{root.URN: {op: deploy.OpReplace, old: root}}
+	for i := 0; i < HighImpactDependents; i++ {
+		child := &resource.State{URN: blastRadiusURN(fmt.Sprintf("child%d", i)), Parent: root.URN}
+		resources = append(resources, child)
+		ops[child.URN] = plannedOp{op: deploy.OpReplace, old: child}
+	}
+
+	radius := computeBlastRadius(&deploy.Snapshot{Resources: resources}, ops)
+	require.NotNil(t, radius)
+	assert.Equal(t, HighImpactDependents+1, radius.Replaces)
+	require.Len(t, radius.Resources, HighImpactDependents+1)
+	assert.Len(t, radius.Resources[0].Dependents, HighImpactDependents)
+	assert.True(t, radius.Resources[0].HighImpact)
+	assert.False(t, radius.Resources[1].HighImpact)
+}
+
+func TestComputeBlastRadiusNoReplacesOrDeletes(t *testing.T) {
+	t.Parallel()
+
+	res := &resource.State{URN: blastRadiusURN("res")}
+	prev := &deploy.Snapshot{Resources: []*resource.State{res}}
+	assert.Nil(t, computeBlastRadius(prev, map[resource.URN]plannedOp{res.URN: {op: deploy.OpUpdate, old: res}}))
+}
+
+func TestComputeBlastRadiusPendingDelete(t *testing.T) {
+	t.Parallel()
+
+	// The resource pending deletion shares its URN with the live resource, but only the live one is replaced.
+	pending := &resource.State{URN: blastRadiusURN("res"), Delete: true}
+	res := &resource.State{URN: blastRadiusURN("res")}
+	prev := &deploy.Snapshot{Resources: []*resource.State{res, pending}}
+
+	radius := computeBlastRadius(prev, map[resource.URN]plannedOp{res.URN: {op: deploy.OpReplace, old: res}})
+	require.NotNil(t, radius)
+	assert.Equal(t, []display.BlastRadiusResource{{URN: res.URN, Op: deploy.OpReplace}}, radius.Resources)
+}
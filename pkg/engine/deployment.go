@@ -16,8 +16,11 @@ package engine
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
@@ -145,6 +148,9 @@ type deploymentOptions struct {
 	// true if we're executing a refresh.
 	isRefresh bool
 
+	// true if the summary of a preview should describe the blast radius of its replacements and deletions.
+	reportBlastRadius bool
+
 	// true if we should trust the dependency graph reported by the language host. Not all Pulumi-supported languages
 	// correctly report their dependencies, in which case this will be false.
 	trustDependencies bool
@@ -245,11 +251,150 @@ type deployment struct {
 	Options    deploymentOptions  // the options used while deploying.
 }
 
+// resourceLimitsKey is the stack configuration key whose value overrides the limits set by the project's limits option.
+var resourceLimitsKey = config.MustMakeKey("pulumi", "resource-limits")
+
+// resourceLimits returns the caps on the concurrency and rate of resource operations, keyed by package name or
+// resource type token. They're set by the project's limits option, and any key may be overridden by the stack's
+// pulumi:resource-limits configuration value, which has the same shape.
+func resourceLimits(proj *workspace.Project, target *deploy.Target) (map[string]workspace.ResourceLimits, error) {
+	limits := make(map[string]workspace.ResourceLimits)
+	if proj != nil && proj.Options != nil {
+		for key, l := range proj.Options.Limits {
+			limits[key] = l
+		}
+	}
+
+	if target != nil {
+		if v, ok := target.Config[resourceLimitsKey]; ok {
+			value, err := v.Value(target.Decrypter)
+			if err != nil {
+				return nil, fmt.Errorf("reading %v: %w", resourceLimitsKey, err)
+			}
+			var stackLimits map[string]workspace.ResourceLimits
+			if err := json.Unmarshal([]byte(value), &stackLimits); err != nil {
+				return nil, fmt.Errorf("parsing %v: %w", resourceLimitsKey, err)
+			}
+			for key, l := range stackLimits {
+				if l.Parallel < 0 || l.Rate < 0 {
+					return nil, fmt.Errorf("%v: the limits of %q must not be negative", resourceLimitsKey, key)
+				}
+				limits[key] = l
+			}
+		}
+	}
+	return limits, nil
+}
+
+// deadlineCancelWait is the time that providers are given to respond to the cancellation of the steps that are still
+// running once a deployment's deadline and grace period have passed.
+const deadlineCancelWait = 5 * time.Second
+
+// inFlightSteps tracks the steps of a deployment that are running, so they can be reported if the deployment ends
+// before they finish. Once the deployment is abandoned, its events are no longer forwarded to the run's actions, so
+// that steps that finish late cannot write to a snapshot manager that has already been closed.
+type inFlightSteps struct {
+	runActions
+
+	m     sync.Mutex
+	steps map[deploy.Step]struct{}
+
+	// forwarding is held for reading while an event is forwarded to the run's actions, and for writing while the
+	// deployment is abandoned, so that abandoning waits for the events that are already being handled.
+	forwarding sync.RWMutex
+	abandoned  bool
+}
+
+// errDeploymentAbandoned is returned for steps that start after their deployment was abandoned.
+var errDeploymentAbandoned = errors.New("the deployment was abandoned after its deadline")
+
+func (s *inFlightSteps) OnResourceStepPre(step deploy.Step) (interface{}, error) {
+	s.forwarding.RLock()
+	defer s.forwarding.RUnlock()
+	if s.abandoned {
+		return nil, errDeploymentAbandoned
+	}
+
+	payload, err := s.runActions.OnResourceStepPre(step)
+	if err == nil {
+		s.m.Lock()
+		s.steps[step] = struct{}{}
+		s.m.Unlock()
+	}
+	return payload, err
+}
+
+func (s *inFlightSteps) OnResourceStepPost(
+	ctx interface{}, step deploy.Step, status resource.Status, err error,
+) error {
+	s.forwarding.RLock()
+	defer s.forwarding.RUnlock()
+	if s.abandoned {
+		return errDeploymentAbandoned
+	}
+
+	s.m.Lock()
+	delete(s.steps, step)
+	s.m.Unlock()
+	return s.runActions.OnResourceStepPost(ctx, step, status, err)
+}
+
+func (s *inFlightSteps) OnResourceStepRetry(
+	step deploy.Step, attempt, maxAttempts int, delay time.Duration, err error,
+) {
+	s.forwarding.RLock()
+	defer s.forwarding.RUnlock()
+	if !s.abandoned {
+		s.runActions.OnResourceStepRetry(step, attempt, maxAttempts, delay, err)
+	}
+}
+
+func (s *inFlightSteps) OnResourceOutputs(step deploy.Step) error {
+	s.forwarding.RLock()
+	defer s.forwarding.RUnlock()
+	if s.abandoned {
+		return errDeploymentAbandoned
+	}
+	return s.runActions.OnResourceOutputs(step)
+}
+
+func (s *inFlightSteps) OnPolicyViolation(urn resource.URN, d plugin.AnalyzeDiagnostic) {
+	s.forwarding.RLock()
+	defer s.forwarding.RUnlock()
+	if !s.abandoned {
+		s.runActions.OnPolicyViolation(urn, d)
+	}
+}
+
+// abandon stops forwarding events to the run's actions, once the events that are already being handled are done,
+// and returns the steps that are still running.
+func (s *inFlightSteps) abandon() []deploy.Step {
+	s.forwarding.Lock()
+	defer s.forwarding.Unlock()
+
+	s.abandoned = true
+	return s.list()
+}
+
+// list returns the steps that are running, sorted by URN.
+func (s *inFlightSteps) list() []deploy.Step {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	steps := make([]deploy.Step, 0, len(s.steps))
+	for step := range s.steps {
+		steps = append(steps, step)
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].URN() < steps[j].URN() })
+	return steps
+}
+
 type runActions interface {
 	deploy.Events
 
 	Changes() display.ResourceChanges
 	MaybeCorrupt() bool
+	BlastRadius(prev *deploy.Snapshot) *display.BlastRadius
 }
 
 // run executes the deployment. It is primarily responsible for handling cancellation.
@@ -263,8 +408,26 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 	}
 	defer chdir()
 
-	// Create a new context for cancellation and tracing.
-	ctx, cancelFunc := context.WithCancel(context.Background())
+	var retry *resource.RetryPolicy
+	proj := deployment.Ctx.Update.GetProject()
+	if proj != nil && proj.Options != nil {
+		retry = proj.Options.Retry
+	}
+	limits, err := resourceLimits(proj, deployment.Ctx.Update.GetTarget())
+	if err != nil {
+		return nil, nil, result.FromError(err)
+	}
+
+	// Create a new context for cancellation and tracing. If the deployment has a deadline, the context expires then,
+	// which stops the deployment from launching new steps.
+	var ctx context.Context
+	var cancelFunc context.CancelFunc
+	deadline := deployment.Options.Deadline
+	if deadline.IsZero() {
+		ctx, cancelFunc = context.WithCancel(context.Background())
+	} else {
+		ctx, cancelFunc = context.WithDeadline(context.Background(), deadline)
+	}
 
 	// Inject our opentracing span into the context.
 	if deployment.Ctx.TracingSpan != nil {
@@ -280,9 +443,10 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 	done := make(chan bool)
 	var newPlan *deploy.Plan
 	var walkResult result.Result
+	inFlight := &inFlightSteps{runActions: actions, steps: make(map[deploy.Step]struct{})}
 	go func() {
 		opts := deploy.Options{
-			Events:                    actions,
+			Events:                    inFlight,
 			Parallel:                  deployment.Options.Parallel,
 			Refresh:                   deployment.Options.Refresh,
 			RefreshOnly:               deployment.Options.isRefresh,
@@ -291,11 +455,18 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 			DestroyTargets:            deployment.Options.DestroyTargets,
 			UpdateTargets:             deployment.Options.UpdateTargets,
 			TargetDependents:          deployment.Options.TargetDependents,
+			ExcludeTargets:            deployment.Options.ExcludeTargets,
+			ExcludeDependents:         deployment.Options.ExcludeDependents,
 			TrustDependencies:         deployment.Options.trustDependencies,
 			UseLegacyDiff:             deployment.Options.UseLegacyDiff,
 			DisableResourceReferences: deployment.Options.DisableResourceReferences,
 			DisableOutputValues:       deployment.Options.DisableOutputValues,
 			GeneratePlan:              deployment.Options.UpdateOptions.GeneratePlan,
+			ContinueOnError:           deployment.Options.ContinueOnError,
+			ValidatePlan:              deployment.Options.ValidatePlan,
+			Retry:                     retry,
+			Limits:                    limits,
+			DeadlineGracePeriod:       deployment.Options.DeadlineGracePeriod,
 		}
 		newPlan, walkResult = deployment.Deployment.Execute(ctx, opts, preview)
 		close(done)
@@ -312,6 +483,15 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 		}
 	}()
 
+	// If the deployment has a deadline, stop waiting for it once the steps that were running at the deadline have had
+	// their grace period and providers have had a chance to respond to the cancellation that follows.
+	var abandon <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline.Add(deployment.Options.DeadlineGracePeriod + deadlineCancelWait)))
+		defer timer.Stop()
+		abandon = timer.C
+	}
+
 	// Wait for the deployment to finish executing or for the user to terminate the run.
 	var res result.Result
 	select {
@@ -320,13 +500,22 @@ func (deployment *deployment) run(cancelCtx *Context, actions runActions, policy
 
 	case <-done:
 		res = walkResult
+		if res != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			res = result.FromError(DeadlineExceededError{Deadline: deadline})
+		}
+
+	case <-abandon:
+		// Any steps that are still running are left as pending operations in the stack's state. The deployment may
+		// still be executing, so stop it from recording anything else before the caller closes the snapshot manager.
+		res = result.FromError(DeadlineExceededError{Deadline: deadline, InFlight: inFlight.abandon()})
 	}
 
 	duration := time.Since(start)
 	changes := actions.Changes()
 
 	// Emit a summary event.
-	deployment.Options.Events.summaryEvent(preview, actions.MaybeCorrupt(), duration, changes, policyPacks)
+	deployment.Options.Events.summaryEvent(preview, actions.MaybeCorrupt(), duration, changes, policyPacks,
+		actions.BlastRadius(deployment.Deployment.Prev()))
 
 	return newPlan, changes, res
 }
@@ -0,0 +1,82 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package engine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// recordingActions records the steps whose events reach it.
+type recordingActions struct {
+	runActions
+
+	pre, post, outputs []deploy.Step
+}
+
+func (a *recordingActions) OnResourceStepPre(step deploy.Step) (interface{}, error) {
+	a.pre = append(a.pre, step)
+	return nil, nil
+}
+
+func (a *recordingActions) OnResourceStepPost(
+	ctx interface{}, step deploy.Step, status resource.Status, err error,
+) error {
+	a.post = append(a.post, step)
+	return nil
+}
+
+func (a *recordingActions) OnResourceOutputs(step deploy.Step) error {
+	a.outputs = append(a.outputs, step)
+	return nil
+}
+
+func TestInFlightStepsAbandon(t *testing.T) {
+	t.Parallel()
+
+	newStep := func(name string) deploy.Step {
+		return deploy.NewDeleteStep(nil, map[resource.URN]bool{},
+			&resource.State{URN: resource.URN("urn:pulumi:stack::project::pkgA:m:typA::" + name)})
+	}
+	a, b, c := newStep("a"), newStep("b"), newStep("c")
+
+	actions := &recordingActions{}
+	inFlight := &inFlightSteps{runActions: actions, steps: make(map[deploy.Step]struct{})}
+
+	for _, step := range []deploy.Step{b, a} {
+		_, err := inFlight.OnResourceStepPre(step)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []deploy.Step{a, b}, inFlight.list())
+
+	// a finishes before the deployment is abandoned, and b is still running.
+	require.NoError(t, inFlight.OnResourceStepPost(nil, a, resource.StatusOK, nil))
+	assert.Equal(t, []deploy.Step{b}, inFlight.abandon())
+
+	// Nothing reaches the actions once the deployment has been abandoned.
+	assert.ErrorIs(t, inFlight.OnResourceStepPost(nil, b, resource.StatusOK, nil), errDeploymentAbandoned)
+	assert.ErrorIs(t, inFlight.OnResourceOutputs(b), errDeploymentAbandoned)
+	_, err := inFlight.OnResourceStepPre(c)
+	assert.ErrorIs(t, err, errDeploymentAbandoned)
+
+	assert.Equal(t, []deploy.Step{b, a}, actions.pre)
+	assert.Equal(t, []deploy.Step{a}, actions.post)
+	assert.Empty(t, actions.outputs)
+}
@@ -16,7 +16,9 @@ package engine
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
 )
 
@@ -38,3 +40,19 @@ type DecryptError struct {
 func (d DecryptError) Error() string {
 	return fmt.Sprintf("failed to decrypt configuration key '%s': %s", d.Key, d.Err.Error())
 }
+
+// DeadlineExceededError is the type of errors that arise when a deployment doesn't finish before its deadline. The
+// deployment stopped launching new steps at the deadline, and ended once the steps that were running finished or the
+// grace period that followed the deadline expired.
+type DeadlineExceededError struct {
+	Deadline time.Time     // The deadline of the deployment.
+	InFlight []deploy.Step // The steps that were still running when the deployment ended.
+}
+
+func (d DeadlineExceededError) Error() string {
+	if len(d.InFlight) == 0 {
+		return fmt.Sprintf("the deployment did not finish before its deadline (%v)", d.Deadline.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("the deployment did not finish before its deadline (%v); %d operations were still running",
+		d.Deadline.Format(time.RFC3339), len(d.InFlight))
+}
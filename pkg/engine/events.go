@@ -58,6 +58,8 @@ func NewEvent(typ EventType, payload interface{}) Event {
 		_, ok = payload.(ResourceOutputsEventPayload)
 	case ResourceOperationFailed:
 		_, ok = payload.(ResourceOperationFailedPayload)
+	case ResourceOperationRetry:
+		_, ok = payload.(ResourceOperationRetryPayload)
 	case PolicyViolationEvent:
 		_, ok = payload.(PolicyViolationEventPayload)
 	default:
@@ -82,6 +84,7 @@ const (
 	ResourcePreEvent        EventType = "resource-pre"
 	ResourceOutputsEvent    EventType = "resource-outputs"
 	ResourceOperationFailed EventType = "resource-operationfailed"
+	ResourceOperationRetry  EventType = "resource-operationretry"
 	PolicyViolationEvent    EventType = "policy-violation"
 )
 
@@ -132,6 +135,7 @@ type SummaryEventPayload struct {
 	Duration        time.Duration           // the duration of the entire update operation (zero values for previews)
 	ResourceChanges display.ResourceChanges // count of changed resources, useful for reporting
 	PolicyPacks     map[string]string       // {policy-pack: version} for each policy pack applied
+	BlastRadius     *display.BlastRadius    // the resources affected by replacements and deletions (previews only)
 }
 
 type ResourceOperationFailedPayload struct {
@@ -140,6 +144,16 @@ type ResourceOperationFailedPayload struct {
 	Steps    int
 }
 
+// ResourceOperationRetryPayload is the payload for an event with type `resource-operationretry`, which is sent when a
+// step failed with a transient error and is about to be retried.
+type ResourceOperationRetryPayload struct {
+	Metadata    StepEventMetadata
+	Attempt     int           // the attempt that is about to be made, counting the first one.
+	MaxAttempts int           // the maximum number of attempts.
+	Delay       time.Duration // how long the engine waits before the attempt.
+	Message     string        // the message of the transient error.
+}
+
 type ResourceOutputsEventPayload struct {
 	Metadata StepEventMetadata
 	Planning bool
@@ -365,6 +379,20 @@ func (e *eventEmitter) resourceOperationFailedEvent(
 	}))
 }
 
+func (e *eventEmitter) resourceOperationRetryEvent(
+	step deploy.Step, attempt, maxAttempts int, delay time.Duration, err error, debug bool,
+) {
+	contract.Requiref(e != nil, "e", "!= nil")
+
+	e.sendEvent(NewEvent(ResourceOperationRetry, ResourceOperationRetryPayload{
+		Metadata:    makeStepEventMetadata(step.Op(), step, debug),
+		Attempt:     attempt,
+		MaxAttempts: maxAttempts,
+		Delay:       delay,
+		Message:     logging.FilterString(err.Error()),
+	}))
+}
+
 func (e *eventEmitter) resourceOutputsEvent(op display.StepOp, step deploy.Step, planning bool, debug bool) {
 	contract.Requiref(e != nil, "e", "!= nil")
 
@@ -405,7 +433,7 @@ func (e *eventEmitter) preludeEvent(isPreview bool, cfg config.Map) {
 }
 
 func (e *eventEmitter) summaryEvent(preview, maybeCorrupt bool, duration time.Duration,
-	resourceChanges display.ResourceChanges, policyPacks map[string]string,
+	resourceChanges display.ResourceChanges, policyPacks map[string]string, blastRadius *display.BlastRadius,
 ) {
 	contract.Requiref(e != nil, "e", "!= nil")
 
@@ -415,6 +443,7 @@ func (e *eventEmitter) summaryEvent(preview, maybeCorrupt bool, duration time.Du
 		Duration:        duration,
 		ResourceChanges: resourceChanges,
 		PolicyPacks:     policyPacks,
+		BlastRadius:     blastRadius,
 	}))
 }
 
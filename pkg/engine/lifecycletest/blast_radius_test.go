@@ -0,0 +1,122 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestPreviewBlastRadius(t *testing.T) {
+	t.Parallel()
+
+	// db is replaced, which updates the protected app that depends on it. cache is deleted, and log, which also
+	// depends on db, is unchanged.
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DiffF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if olds.DeepEquals(news) {
+						return plugin.DiffResult{Changes: plugin.DiffNone}, nil
+					}
+					if urn.Name() == "db" {
+						return plugin.DiffResult{Changes: plugin.DiffSome, ReplaceKeys: []resource.PropertyKey{"size"}}, nil
+					}
+					return plugin.DiffResult{Changes: plugin.DiffSome}, nil
+				},
+			}, nil
+		}),
+	}
+
+	size, withCache := 1.0, true
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		db, _, _, err := monitor.RegisterResource("pkgA:m:typA", "db", true, deploytest.ResourceOptions{
+			Inputs: resource.PropertyMap{"size": resource.NewNumberProperty(size)},
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "app", true, deploytest.ResourceOptions{
+			Inputs:       resource.PropertyMap{"dbSize": resource.NewNumberProperty(size)},
+			Dependencies: []resource.URN{db},
+			Protect:      true,
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "log", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{db},
+		})
+		if err != nil {
+			return err
+		}
+		if withCache {
+			_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "cache", true)
+		}
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	size, withCache = 2, false
+	var radius *display.BlastRadius
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, true, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			for _, e := range events {
+				if e.Type == SummaryEvent {
+					radius = e.Payload().(SummaryEventPayload).BlastRadius
+				}
+			}
+			return res
+		})
+	require.Nil(t, res)
+
+	urn := func(name string) resource.URN { return p.NewURN("pkgA:m:typA", name, "") }
+	require.NotNil(t, radius)
+	assert.Equal(t, &display.BlastRadius{
+		Replaces: 1,
+		Deletes:  1,
+		Resources: []display.BlastRadiusResource{
+			{
+				URN: urn("db"),
+				Op:  deploy.OpReplace,
+				Dependents: []display.BlastRadiusDependent{
+					{URN: urn("app"), Op: deploy.OpUpdate, Protect: true},
+				},
+			},
+			{URN: urn("cache"), Op: deploy.OpDelete},
+		},
+	}, radius)
+}
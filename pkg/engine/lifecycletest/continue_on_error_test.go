@@ -0,0 +1,239 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// snapshotNames returns the sorted names of the custom resources in the given snapshot, leaving out providers.
+func snapshotNames(snap *deploy.Snapshot) []string {
+	var names []string
+	for _, res := range snap.Resources {
+		if res.Custom && !strings.HasPrefix(string(res.Type), "pulumi:providers:") {
+			names = append(names, res.URN.Name().String())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// failureSummary returns the message of the error diagnostic that summarizes the failures of a deployment that
+// continued on error.
+func failureSummary(events []Event) string {
+	for _, e := range events {
+		if e.Type == DiagEvent {
+			p := e.Payload().(DiagEventPayload)
+			if p.Severity == diag.Error && strings.Contains(p.Message, " skipped:") {
+				return p.Message
+			}
+		}
+	}
+	return ""
+}
+
+func TestContinueOnErrorUpdate(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					if urn.Name() == "resA" {
+						return "", nil, resource.StatusOK, errors.New("create failed")
+					}
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.Error(t, err)
+		urnA = resource.NewURN("test", "test", "", "pkgA:m:typA", "resA")
+
+		// resB depends on resA, which failed, so it is skipped.
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{urnA},
+		})
+		assert.Error(t, err)
+
+		// resC and resD don't, so they are created.
+		urnC, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resD", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{urnC},
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, ContinueOnError: true},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			summary := failureSummary(events)
+			assert.Contains(t, summary, "1 resource failed and 1 was skipped")
+			assert.Contains(t, summary, "resA: create failed")
+			assert.Contains(t, summary, "resB: skipped because urn:pulumi:test::test::pkgA:m:typA::resA failed")
+			return res
+		})
+	assertIsErrorOrBailResult(t, res)
+	require.NotNil(t, snap)
+	assert.Equal(t, []string{"resC", "resD"}, snapshotNames(snap))
+
+	// Without continuing on error, the first failure stops the update.
+	p.Options.ContinueOnError = false
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assertIsErrorOrBailResult(t, res)
+	assert.Empty(t, snapshotNames(snap))
+}
+
+func TestContinueOnErrorKeepsSkippedResources(t *testing.T) {
+	t.Parallel()
+
+	failUpdate := false
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DiffF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if !olds.DeepEquals(news) {
+						return plugin.DiffResult{Changes: plugin.DiffSome}, nil
+					}
+					return plugin.DiffResult{Changes: plugin.DiffNone}, nil
+				},
+				UpdateF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap, timeout float64,
+					ignoreChanges []string, preview bool,
+				) (resource.PropertyMap, resource.Status, error) {
+					if failUpdate {
+						return nil, resource.StatusOK, errors.New("update failed")
+					}
+					return news, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	value := "1"
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: resource.PropertyMap{"value": resource.NewStringProperty(value)},
+		})
+		if err != nil {
+			urnA = resource.NewURN("test", "test", "", "pkgA:m:typA", "resA")
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Parent: urnA,
+		})
+		assert.Equal(t, failUpdate, err != nil)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, ContinueOnError: true},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []string{"resA", "resB"}, snapshotNames(snap))
+
+	// The update of resA fails, so its child resB is skipped. resB must not be deleted just because it wasn't
+	// registered.
+	failUpdate, value = true, "2"
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assertIsErrorOrBailResult(t, res)
+	assert.Equal(t, []string{"resA", "resB"}, snapshotNames(snap))
+}
+
+func TestContinueOnErrorDestroy(t *testing.T) {
+	t.Parallel()
+
+	var deleted []string
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					if urn.Name() == "resB" {
+						return resource.StatusOK, errors.New("delete failed")
+					}
+					deleted = append(deleted, urn.Name().String())
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Dependencies: []resource.URN{urnA},
+		})
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		assert.NoError(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		// Delete one resource at a time, so that the order of the deletes is predictable.
+		Options: UpdateOptions{Host: host, Parallel: 1, ContinueOnError: true},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// resB fails to delete, so resA, which it depends on, is kept too. resC is deleted regardless.
+	snap, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			summary := failureSummary(events)
+			assert.Contains(t, summary, "1 resource failed and ")
+			assert.Contains(t, summary, "resB: delete failed")
+			assert.Contains(t, summary,
+				"resA: skipped because urn:pulumi:test::test::pkgA:m:typA::resB, which depends on it, failed to delete")
+			return res
+		})
+	assertIsErrorOrBailResult(t, res)
+	assert.Equal(t, []string{"resC"}, deleted)
+	assert.Equal(t, []string{"resA", "resB"}, snapshotNames(snap))
+}
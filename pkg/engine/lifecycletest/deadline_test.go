@@ -0,0 +1,111 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+)
+
+// runPastDeadline runs an update that registers resA and then resB, where the create of resA takes createDuration. The
+// update's deadline passes while resA is being created.
+func runPastDeadline(t *testing.T, createDuration, gracePeriod time.Duration) (*deploy.Snapshot, int32, error) {
+	var creates, cancels int32
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					atomic.AddInt32(&creates, 1)
+					time.Sleep(createDuration)
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				CancelF: func() error {
+					atomic.AddInt32(&cancels, 1)
+					return nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		if _, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true); err != nil {
+			return err
+		}
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resB", true)
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{
+			Host:                host,
+			Deadline:            time.Now().Add(100 * time.Millisecond),
+			DeadlineGracePeriod: gracePeriod,
+		},
+	}
+	snap, res := TestOp(Update).Run(p.GetProject(), p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.NotNil(t, res)
+
+	// resB is never created, since the deployment stopped launching new steps at the deadline.
+	assert.Equal(t, int32(1), atomic.LoadInt32(&creates))
+	return snap, atomic.LoadInt32(&cancels), res.Error()
+}
+
+func TestDeadlineStepFinishesInGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	snap, cancels, err := runPastDeadline(t, 300*time.Millisecond, 10*time.Second)
+
+	var deadlineErr DeadlineExceededError
+	require.True(t, errors.As(err, &deadlineErr), "unexpected error: %v", err)
+	assert.Empty(t, deadlineErr.InFlight)
+	assert.Equal(t, int32(0), cancels)
+
+	// resA was created in the grace period, and nothing is left pending.
+	assert.Equal(t, []string{"resA"}, snapshotNames(snap))
+	assert.Empty(t, snap.PendingOperations)
+}
+
+func TestDeadlineStepOutlivesGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	snap, cancels, err := runPastDeadline(t, time.Minute, 10*time.Millisecond)
+
+	var deadlineErr DeadlineExceededError
+	require.True(t, errors.As(err, &deadlineErr), "unexpected error: %v", err)
+	require.Len(t, deadlineErr.InFlight, 1)
+	assert.Equal(t, "resA", string(deadlineErr.InFlight[0].URN().Name()))
+	assert.Equal(t, int32(1), cancels)
+
+	// The create of resA is recorded as a pending operation.
+	assert.Empty(t, snapshotNames(snap))
+	require.Len(t, snap.PendingOperations, 1)
+	assert.Equal(t, resource.OperationTypeCreating, snap.PendingOperations[0].Type)
+	assert.Equal(t, "resA", string(snap.PendingOperations[0].Resource.URN.Name()))
+}
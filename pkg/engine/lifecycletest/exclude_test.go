@@ -0,0 +1,221 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// newExcludeTestPlan returns a test plan for a program that registers resA, resB, which depends on resA, and resC.
+// Every resource's inputs are set to the current value of *value, and the URNs of the resources that the provider
+// reads are recorded in *reads.
+func newExcludeTestPlan(t *testing.T, value *string, reads *[]string) *TestPlan {
+	var readsLock sync.Mutex
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DiffF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if !olds.DeepEquals(news) {
+						return plugin.DiffResult{Changes: plugin.DiffSome}, nil
+					}
+					return plugin.DiffResult{Changes: plugin.DiffNone}, nil
+				},
+				ReadF: func(urn resource.URN, id resource.ID,
+					inputs, state resource.PropertyMap,
+				) (plugin.ReadResult, resource.Status, error) {
+					readsLock.Lock()
+					defer readsLock.Unlock()
+					*reads = append(*reads, urn.Name().String())
+					return plugin.ReadResult{Inputs: inputs, Outputs: state}, resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		inputs := resource.PropertyMap{"value": resource.NewStringProperty(*value)}
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Inputs:       inputs,
+			Dependencies: []resource.URN{urnA},
+		})
+		assert.NoError(t, err)
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true, deploytest.ResourceOptions{
+			Inputs: inputs,
+		})
+		assert.NoError(t, err)
+		return nil
+	})
+
+	return &TestPlan{
+		Options: UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, program, loaders...)},
+	}
+}
+
+// stepOps returns the operation of the last step recorded for each custom resource in the given journal entries.
+func stepOps(entries JournalEntries) map[string]display.StepOp {
+	ops := make(map[string]display.StepOp)
+	for _, entry := range entries {
+		if urn := entry.Step.URN(); urn.Type() == "pkgA:m:typA" {
+			ops[urn.Name().String()] = entry.Step.Op()
+		}
+	}
+	return ops
+}
+
+func TestExcludeUpdate(t *testing.T) {
+	t.Parallel()
+
+	value := "1"
+	var reads []string
+	p := newExcludeTestPlan(t, &value, &reads)
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// Change the inputs of every resource, so that each is updated unless it is excluded.
+	value = "2"
+	resA := string(p.NewURN("pkgA:m:typA", "resA", ""))
+	cases := []struct {
+		name              string
+		excludes          []string
+		excludeDependents bool
+		expected          map[string]display.StepOp
+	}{
+		{
+			name:     "literal",
+			excludes: []string{resA},
+			expected: map[string]display.StepOp{
+				"resA": deploy.OpSame, "resB": deploy.OpUpdate, "resC": deploy.OpUpdate,
+			},
+		},
+		{
+			name:              "dependents",
+			excludes:          []string{resA},
+			excludeDependents: true,
+			expected: map[string]display.StepOp{
+				"resA": deploy.OpSame, "resB": deploy.OpSame, "resC": deploy.OpUpdate,
+			},
+		},
+		{
+			name:     "glob",
+			excludes: []string{"**::resC"},
+			expected: map[string]display.StepOp{
+				"resA": deploy.OpUpdate, "resB": deploy.OpUpdate, "resC": deploy.OpSame,
+			},
+		},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			opts := p.Options
+			opts.ExcludeTargets = deploy.NewUrnTargets(c.excludes)
+			opts.ExcludeDependents = c.excludeDependents
+			_, res := TestOp(Update).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient,
+				func(_ workspace.Project, _ deploy.Target, entries JournalEntries,
+					_ []Event, res result.Result,
+				) result.Result {
+					assert.Equal(t, c.expected, stepOps(entries))
+					return res
+				})
+			assert.Nil(t, res)
+		})
+	}
+}
+
+func TestExcludeDestroy(t *testing.T) {
+	t.Parallel()
+
+	value := "1"
+	var reads []string
+	p := newExcludeTestPlan(t, &value, &reads)
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	resA, resB := string(p.NewURN("pkgA:m:typA", "resA", "")), string(p.NewURN("pkgA:m:typA", "resB", ""))
+	cases := []struct {
+		name              string
+		excludes          []string
+		excludeDependents bool
+		expected          []string
+	}{
+		// resB depends on resA, so excluding resB keeps resA too.
+		{name: "dependencies", excludes: []string{resB}, expected: []string{"resA", "resB"}},
+		{name: "literal", excludes: []string{resA}, expected: []string{"resA"}},
+		{name: "dependents", excludes: []string{resA}, excludeDependents: true, expected: []string{"resA", "resB"}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			opts := p.Options
+			opts.ExcludeTargets = deploy.NewUrnTargets(c.excludes)
+			opts.ExcludeDependents = c.excludeDependents
+			snap, res := TestOp(Destroy).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+			assert.Nil(t, res)
+			assert.Equal(t, c.expected, snapshotNames(snap))
+		})
+	}
+}
+
+func TestExcludeRefresh(t *testing.T) {
+	t.Parallel()
+
+	value := "1"
+	var reads []string
+	p := newExcludeTestPlan(t, &value, &reads)
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	opts := p.Options
+	opts.ExcludeTargets = deploy.NewUrnTargets([]string{string(p.NewURN("pkgA:m:typA", "resA", ""))})
+	opts.ExcludeDependents = true
+	_, res = TestOp(Refresh).Run(project, p.GetTarget(t, snap), opts, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []string{"resC"}, reads)
+}
+
+func TestExcludedCreateReferencedByUpdate(t *testing.T) {
+	t.Parallel()
+
+	value := "1"
+	var reads []string
+	p := newExcludeTestPlan(t, &value, &reads)
+
+	// resA and resB are new, and resB can't be created without resA.
+	p.Options.ExcludeTargets = deploy.NewUrnTargets([]string{"**::resA"})
+	p.Steps = []TestStep{{Op: Update, ExpectFailure: true}}
+	p.Run(t, nil)
+}
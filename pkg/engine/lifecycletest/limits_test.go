@@ -0,0 +1,153 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// concurrencyTracker records the maximum number of provider operations that are in flight at once.
+type concurrencyTracker struct {
+	m        sync.Mutex
+	current  int
+	maxSeen  int
+	duration time.Duration
+}
+
+func (c *concurrencyTracker) track() {
+	c.m.Lock()
+	c.current++
+	if c.current > c.maxSeen {
+		c.maxSeen = c.current
+	}
+	c.m.Unlock()
+
+	time.Sleep(c.duration)
+
+	c.m.Lock()
+	c.current--
+	c.m.Unlock()
+}
+
+func (c *concurrencyTracker) max() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.maxSeen
+}
+
+// runWithLimits creates 8 resources of package pkgA and 8 of package pkgB in parallel, and returns the maximum number
+// of creates that were in flight at once for each package.
+func runWithLimits(t *testing.T, project workspace.Project, cfg config.Map) (int, int) {
+	trackers := map[string]*concurrencyTracker{
+		"pkgA": {duration: 20 * time.Millisecond},
+		"pkgB": {duration: 20 * time.Millisecond},
+	}
+	var loaders []*deploytest.ProviderLoader
+	for pkg, tracker := range trackers {
+		tracker := tracker
+		loaders = append(loaders, deploytest.NewProviderLoader(tokens.Package(pkg), semver.MustParse("1.0.0"),
+			func() (plugin.Provider, error) {
+				return &deploytest.Provider{
+					CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+						preview bool,
+					) (resource.ID, resource.PropertyMap, resource.Status, error) {
+						tracker.track()
+						return resource.ID(urn.Name()), news, resource.StatusOK, nil
+					},
+				}, nil
+			}))
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		var wg sync.WaitGroup
+		for _, pkg := range []string{"pkgA", "pkgB"} {
+			for i := 0; i < 8; i++ {
+				typ, name := pkg+":m:typA", fmt.Sprintf("%s-res%d", pkg, i)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, _, _, err := monitor.RegisterResource(tokens.Type(typ), name, true)
+					assert.NoError(t, err)
+				}()
+			}
+		}
+		wg.Wait()
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16},
+		Config:  cfg,
+	}
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	return trackers["pkgA"].max(), trackers["pkgB"].max()
+}
+
+func TestResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	project := (&TestPlan{}).GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Limits: map[string]workspace.ResourceLimits{"pkgA": {Parallel: 2}},
+	}
+	maxA, maxB := runWithLimits(t, project, nil)
+	assert.LessOrEqual(t, maxA, 2)
+	assert.Greater(t, maxB, 2)
+}
+
+func TestResourceLimitsStackConfig(t *testing.T) {
+	t.Parallel()
+
+	// The stack's configuration overrides the project's limits of pkgA, and adds a limit to pkgB's type.
+	project := (&TestPlan{}).GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Limits: map[string]workspace.ResourceLimits{"pkgA": {Parallel: 4}},
+	}
+	cfg := config.Map{
+		config.MustMakeKey("pulumi", "resource-limits"): config.NewObjectValue(
+			`{"pkgA": {"parallel": 1}, "pkgB:m:typA": {"parallel": 3}}`),
+	}
+	maxA, maxB := runWithLimits(t, project, cfg)
+	assert.Equal(t, 1, maxA)
+	assert.LessOrEqual(t, maxB, 3)
+
+	// Invalid limits fail the update.
+	cfg = config.Map{
+		config.MustMakeKey("pulumi", "resource-limits"): config.NewObjectValue(`{"pkgA": {"parallel": -1}}`),
+	}
+	p := &TestPlan{
+		Options: UpdateOptions{Host: deploytest.NewPluginHost(nil, nil, deploytest.NewLanguageRuntime(
+			func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error { return nil }))},
+		Config: cfg,
+	}
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assertIsErrorOrBailResult(t, res)
+}
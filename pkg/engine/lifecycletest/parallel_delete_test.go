@@ -0,0 +1,574 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// deleteWaitTimeout bounds how long a delete in these tests waits for another delete to happen. Deletes that are
+// scheduled correctly never come close to it.
+const deleteWaitTimeout = 10 * time.Second
+
+// chainsProgram returns a program that registers the given chains of resources. Each resource of a chain depends on
+// the one before it.
+func chainsProgram(chains [][]string) plugin.LanguageRuntime {
+	return deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		for _, chain := range chains {
+			var deps []resource.URN
+			for _, name := range chain {
+				urn, _, _, err := monitor.RegisterResource("pkgA:m:typA", name, true, deploytest.ResourceOptions{
+					Dependencies: deps,
+				})
+				if err != nil {
+					return err
+				}
+				deps = []resource.URN{urn}
+			}
+		}
+		return nil
+	})
+}
+
+func TestDestroyStreamsDeletes(t *testing.T) {
+	t.Parallel()
+
+	// The deletes of b3 and a2 can start straight away, and those of b2 and a1 once they have completed. The delete
+	// of a2 doesn't complete until b2 has been deleted, which only happens if b2's delete doesn't wait for a2's.
+	b2Deleted := make(chan struct{})
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					switch urn.Name() {
+					case "a2":
+						select {
+						case <-b2Deleted:
+						case <-time.After(deleteWaitTimeout):
+							return resource.StatusOK, errors.New("b2 was not deleted while a2 was being deleted")
+						}
+					case "b2":
+						close(b2Deleted)
+					}
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := chainsProgram([][]string{{"a1", "a2"}, {"b1", "b2", "b3"}})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	assert.Equal(t, []string{"a1", "a2", "b1", "b2", "b3"}, snapshotNames(snap))
+
+	snap, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Empty(t, snapshotNames(snap))
+}
+
+func TestDestroyStreamsDeletesSkipsDependencies(t *testing.T) {
+	t.Parallel()
+
+	// The delete of a2 fails, so a1 can't be deleted, but the other chain is deleted regardless.
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					if urn.Name() == "a2" {
+						return resource.StatusOK, errors.New("delete failed")
+					}
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	program := chainsProgram([][]string{{"a1", "a2"}, {"b1", "b2", "b3"}})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	p.Options.ContinueOnError = true
+	snap, res = TestOp(Destroy).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assertIsErrorOrBailResult(t, res)
+	assert.Equal(t, []string{"a1", "a2"}, snapshotNames(snap))
+}
+
+func TestDeleteBeforeReplaceDeletesDependentsInParallel(t *testing.T) {
+	t.Parallel()
+
+	// Replacing A deletes it first, which requires B and C, which depend on it, to be deleted and replaced too. B and
+	// C don't depend on one another, so their deletes wait until both have started.
+	var m sync.Mutex
+	deleting := map[string]bool{}
+	bothDeleting := make(chan struct{})
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DiffF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if !olds["A"].DeepEquals(news["A"]) {
+						return plugin.DiffResult{
+							Changes:             plugin.DiffSome,
+							ReplaceKeys:         []resource.PropertyKey{"A"},
+							DeleteBeforeReplace: true,
+						}, nil
+					}
+					return plugin.DiffResult{}, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					name := urn.Name().String()
+					if name != "B" && name != "C" {
+						return resource.StatusOK, nil
+					}
+
+					m.Lock()
+					deleting[name] = true
+					if deleting["B"] && deleting["C"] {
+						close(bothDeleting)
+					}
+					m.Unlock()
+
+					select {
+					case <-bothDeleting:
+						return resource.StatusOK, nil
+					case <-time.After(deleteWaitTimeout):
+						return resource.StatusOK, fmt.Errorf("%v was deleted alone", name)
+					}
+				},
+			}, nil
+		}),
+	}
+
+	inputA := "foo"
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "A", true, deploytest.ResourceOptions{
+			Inputs: resource.PropertyMap{"A": resource.NewStringProperty(inputA)},
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{"B", "C"} {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", name, true, deploytest.ResourceOptions{
+				Inputs:       resource.PropertyMap{"A": resource.NewStringProperty("foo")},
+				Dependencies: []resource.URN{urnA},
+				PropertyDeps: map[resource.PropertyKey][]resource.URN{"A": {urnA}},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	inputA = "bar"
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, entries JournalEntries, _ []Event, res result.Result) result.Result {
+			deleted := map[string]bool{}
+			for _, entry := range entries {
+				if entry.Kind == JournalEntrySuccess && entry.Step.Op() == deploy.OpDeleteReplaced {
+					deleted[entry.Step.URN().Name().String()] = true
+				}
+			}
+			assert.Equal(t, map[string]bool{"A": true, "B": true, "C": true}, deleted)
+			return res
+		})
+	assert.Nil(t, res)
+	assert.Equal(t, []string{"A", "B", "C"}, snapshotNames(snap))
+}
+
+func TestDeleteBeforeReplaceDependentsRespectParallel(t *testing.T) {
+	t.Parallel()
+
+	// Replacing X and Y deletes them first, along with the three resources that depend on each of them. The deletes
+	// of both replacements can run at the same time, but never more of them than the degree of parallelism allows.
+	const parallel = 2
+	var m sync.Mutex
+	deleting, maxDeleting := 0, 0
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DiffF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if !olds["A"].DeepEquals(news["A"]) {
+						return plugin.DiffResult{
+							Changes:             plugin.DiffSome,
+							ReplaceKeys:         []resource.PropertyKey{"A"},
+							DeleteBeforeReplace: true,
+						}, nil
+					}
+					return plugin.DiffResult{}, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					m.Lock()
+					deleting++
+					if deleting > maxDeleting {
+						maxDeleting = deleting
+					}
+					m.Unlock()
+
+					time.Sleep(20 * time.Millisecond)
+
+					m.Lock()
+					deleting--
+					m.Unlock()
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	// X and Y are registered at the same time, so that their replacements run at the same time.
+	input := "foo"
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		register := func(root string) error {
+			urn, _, _, err := monitor.RegisterResource("pkgA:m:typA", root, true, deploytest.ResourceOptions{
+				Inputs: resource.PropertyMap{"A": resource.NewStringProperty(input)},
+			})
+			if err != nil {
+				return err
+			}
+			for i := 0; i < 3; i++ {
+				_, _, _, err := monitor.RegisterResource("pkgA:m:typA", fmt.Sprintf("%s%d", root, i), true,
+					deploytest.ResourceOptions{
+						Inputs:       resource.PropertyMap{"A": resource.NewStringProperty("foo")},
+						Dependencies: []resource.URN{urn},
+						PropertyDeps: map[resource.PropertyKey][]resource.URN{"A": {urn}},
+					})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		errs := make(chan error, 2)
+		for _, root := range []string{"X", "Y"} {
+			root := root
+			go func() { errs <- register(root) }()
+		}
+		if err := <-errs; err != nil {
+			return err
+		}
+		return <-errs
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: parallel},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	input = "bar"
+	snap, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, false, p.BackendClient, nil)
+	assert.Nil(t, res)
+	assert.Len(t, snapshotNames(snap), 8)
+	assert.LessOrEqual(t, maxDeleting, parallel)
+}
+
+func TestDestroyPendingDeleteSharingURNWithFailedDelete(t *testing.T) {
+	t.Parallel()
+
+	// resA has a copy that is pending deletion, which is the only resource that depends on resB. The delete of the
+	// live copy of resA fails, but the pending copy is deleted, so resB can be deleted after it.
+	liveFailed := make(chan struct{})
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					switch id {
+					case "1":
+						close(liveFailed)
+						return resource.StatusOK, errors.New("delete failed")
+					case "0":
+						// Finish after the failure of the live copy has been recorded.
+						select {
+						case <-liveFailed:
+							time.Sleep(100 * time.Millisecond)
+						case <-time.After(deleteWaitTimeout):
+						}
+					}
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+	host := deploytest.NewPluginHost(nil, nil, nil, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16, ContinueOnError: true},
+	}
+
+	urnA := p.NewURN("pkgA:m:typA", "resA", "")
+	urnB := p.NewURN("pkgA:m:typA", "resB", "")
+	old := &deploy.Snapshot{
+		Resources: []*resource.State{
+			{
+				Type:    urnB.Type(),
+				URN:     urnB,
+				Custom:  true,
+				ID:      "2",
+				Inputs:  resource.PropertyMap{},
+				Outputs: resource.PropertyMap{},
+			},
+			{
+				Type:    urnA.Type(),
+				URN:     urnA,
+				Custom:  true,
+				ID:      "1",
+				Inputs:  resource.PropertyMap{},
+				Outputs: resource.PropertyMap{},
+			},
+			{
+				Type:         urnA.Type(),
+				URN:          urnA,
+				Custom:       true,
+				ID:           "0",
+				Inputs:       resource.PropertyMap{},
+				Outputs:      resource.PropertyMap{},
+				Delete:       true,
+				Dependencies: []resource.URN{urnB},
+			},
+		},
+	}
+
+	snap, res := TestOp(Destroy).Run(p.GetProject(), p.GetTarget(t, old), p.Options, false, p.BackendClient, nil)
+	assertIsErrorOrBailResult(t, res)
+	assert.Equal(t, []string{"resA"}, snapshotNames(snap))
+	for _, res := range snap.Resources {
+		if res.URN == urnA {
+			assert.Equal(t, resource.ID("1"), res.ID)
+		}
+	}
+}
+
+// benchmarkDeleteDuration returns how long the delete of the resource at the given position of the given chain takes
+// in the benchmarks. The durations vary so that the slowest delete of each dependency level is in a different chain.
+func benchmarkDeleteDuration(chain, level int) time.Duration {
+	return time.Duration(10+((chain+level)%4)*30) * time.Millisecond
+}
+
+// BenchmarkDestroyParallelDeletes destroys a stack of independent chains of resources whose deletes take varying
+// amounts of time. Deleting the stack level by level, waiting for the slowest delete of each level before starting
+// the next, takes the time reported as levels-ms/op. Streaming the deletes takes about as long as the slowest chain.
+func BenchmarkDestroyParallelDeletes(b *testing.B) {
+	const chains, depth = 16, 8
+
+	names := make([][]string, chains)
+	durations := map[string]time.Duration{}
+	for c := range names {
+		for l := 0; l < depth; l++ {
+			name := fmt.Sprintf("c%d-r%d", c, l)
+			names[c] = append(names[c], name)
+			durations[name] = benchmarkDeleteDuration(c, l)
+		}
+	}
+
+	// Deleting level by level takes the sum of the slowest delete of each level.
+	var levels time.Duration
+	for l := 0; l < depth; l++ {
+		var slowest time.Duration
+		for c := 0; c < chains; c++ {
+			if d := benchmarkDeleteDuration(c, l); d > slowest {
+				slowest = d
+			}
+		}
+		levels += slowest
+	}
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					time.Sleep(durations[urn.Name().String()])
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+	host := deploytest.NewPluginHost(nil, nil, chainsProgram(names), loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16},
+	}
+	project := p.GetProject()
+	snap, res := TestOp(Update).Run(project, p.GetTarget(b, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(b, res)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, res := TestOp(Destroy).Run(project, p.GetTarget(b, snap), p.Options, false, p.BackendClient, nil)
+		require.Nil(b, res)
+	}
+	b.ReportMetric(float64(levels.Milliseconds()), "levels-ms/op")
+}
+
+// BenchmarkDeleteBeforeReplaceDependents replaces a resource that must be deleted before it is replaced, and that
+// many resources depend on. Those resources are deleted in parallel before the resource is, rather than one after the
+// other, which would take the time reported as serial-ms/op.
+func BenchmarkDeleteBeforeReplaceDependents(b *testing.B) {
+	const dependents = 32
+	const deleteDuration = 25 * time.Millisecond
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{
+				CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+					preview bool,
+				) (resource.ID, resource.PropertyMap, resource.Status, error) {
+					return resource.ID(urn.Name()), news, resource.StatusOK, nil
+				},
+				DiffF: func(urn resource.URN, id resource.ID, olds, news resource.PropertyMap,
+					ignoreChanges []string,
+				) (plugin.DiffResult, error) {
+					if !olds["A"].DeepEquals(news["A"]) {
+						return plugin.DiffResult{
+							Changes:             plugin.DiffSome,
+							ReplaceKeys:         []resource.PropertyKey{"A"},
+							DeleteBeforeReplace: true,
+						}, nil
+					}
+					return plugin.DiffResult{}, nil
+				},
+				DeleteF: func(urn resource.URN, id resource.ID, olds resource.PropertyMap,
+					timeout float64,
+				) (resource.Status, error) {
+					time.Sleep(deleteDuration)
+					return resource.StatusOK, nil
+				},
+			}, nil
+		}),
+	}
+
+	var inputA string
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		urnA, _, _, err := monitor.RegisterResource("pkgA:m:typA", "A", true, deploytest.ResourceOptions{
+			Inputs: resource.PropertyMap{"A": resource.NewStringProperty(inputA)},
+		})
+		if err != nil {
+			return err
+		}
+		for i := 0; i < dependents; i++ {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", fmt.Sprintf("dep%d", i), true,
+				deploytest.ResourceOptions{
+					Inputs:       resource.PropertyMap{"A": resource.NewStringProperty("foo")},
+					Dependencies: []resource.URN{urnA},
+					PropertyDeps: map[resource.PropertyKey][]resource.URN{"A": {urnA}},
+				})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, Parallel: 16},
+	}
+	project := p.GetProject()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		inputA = "foo"
+		snap, res := TestOp(Update).Run(project, p.GetTarget(b, nil), p.Options, false, p.BackendClient, nil)
+		require.Nil(b, res)
+		inputA = "bar"
+		b.StartTimer()
+
+		_, res = TestOp(Update).Run(project, p.GetTarget(b, snap), p.Options, false, p.BackendClient, nil)
+		require.Nil(b, res)
+	}
+	b.ReportMetric(float64(((dependents + 1) * deleteDuration).Milliseconds()), "serial-ms/op")
+}
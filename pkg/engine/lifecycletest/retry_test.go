@@ -0,0 +1,192 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lifecycletest
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/pulumi/pulumi/pkg/v3/engine"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// retryEvents returns the payloads of the events that announce the retry of a failed resource operation.
+func retryEvents(events []Event) []ResourceOperationRetryPayload {
+	var retries []ResourceOperationRetryPayload
+	for _, e := range events {
+		if e.Type == ResourceOperationRetry {
+			retries = append(retries, e.Payload().(ResourceOperationRetryPayload))
+		}
+	}
+	return retries
+}
+
+// flakyCreateLoader returns a provider loader whose provider fails the first `failures` calls to Create with the
+// given error before succeeding, counting the calls in `attempts`.
+func flakyCreateLoader(failures int32, failure error, attempts *int32) *deploytest.ProviderLoader {
+	return deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+		return &deploytest.Provider{
+			CreateF: func(urn resource.URN, news resource.PropertyMap, timeout float64,
+				preview bool,
+			) (resource.ID, resource.PropertyMap, resource.Status, error) {
+				if atomic.AddInt32(attempts, 1) <= failures {
+					return "", nil, resource.StatusOK, failure
+				}
+				return "created-id", news, resource.StatusOK, nil
+			},
+		}, nil
+	})
+}
+
+func TestRetryProjectPolicy(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	loaders := []*deploytest.ProviderLoader{
+		flakyCreateLoader(2, rpcerror.New(codes.Unavailable, "throttled"), &attempts),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.NoError(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	project := p.GetProject()
+	project.Options = &workspace.ProjectOptions{
+		Retry: &resource.RetryPolicy{MaxAttempts: 3, InitialDelay: 0.001},
+	}
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			retries := retryEvents(events)
+			assert.Len(t, retries, 2)
+			for i, r := range retries {
+				assert.Equal(t, deploy.OpCreate, r.Metadata.Op)
+				assert.Equal(t, resource.URN("urn:pulumi:test::test::pkgA:m:typA::resA"), r.Metadata.URN)
+				assert.Equal(t, i+2, r.Attempt)
+				assert.Equal(t, 3, r.MaxAttempts)
+				assert.Contains(t, r.Message, "throttled")
+			}
+			return res
+		})
+	assert.Nil(t, res)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(t, []string{"resA"}, snapshotNames(snap))
+}
+
+func TestRetryResourcePolicy(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	loaders := []*deploytest.ProviderLoader{
+		flakyCreateLoader(10, rpcerror.New(codes.ResourceExhausted, "rate limit exceeded"), &attempts),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Retry: &resource.RetryPolicy{MaxAttempts: 4},
+		})
+		assert.Error(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	project := p.GetProject()
+
+	// The resource's policy overrides the maximum number of attempts, and inherits the project's delay.
+	project.Options = &workspace.ProjectOptions{
+		Retry: &resource.RetryPolicy{MaxAttempts: 2, InitialDelay: 0.001},
+	}
+	_, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			assert.Len(t, retryEvents(events), 3)
+			return res
+		})
+	assertIsErrorOrBailResult(t, res)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+}
+
+func TestRetryNonRetryableError(t *testing.T) {
+	t.Parallel()
+
+	for _, failure := range []error{
+		errors.New("create failed"),
+		rpcerror.New(codes.InvalidArgument, "invalid argument"),
+	} {
+		var attempts int32
+		loaders := []*deploytest.ProviderLoader{flakyCreateLoader(1, failure, &attempts)}
+
+		program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+			_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+				Retry: &resource.RetryPolicy{MaxAttempts: 3, InitialDelay: 0.001},
+			})
+			assert.Error(t, err)
+			return nil
+		})
+		host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+		p := &TestPlan{
+			Options: UpdateOptions{Host: host},
+		}
+		_, res := TestOp(Update).Run(p.GetProject(), p.GetTarget(t, nil), p.Options, false, p.BackendClient,
+			func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+				assert.Empty(t, retryEvents(events))
+				return res
+			})
+		assertIsErrorOrBailResult(t, res)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	}
+}
+
+func TestRetryDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+	loaders := []*deploytest.ProviderLoader{
+		flakyCreateLoader(1, rpcerror.New(codes.Unavailable, "throttled"), &attempts),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true)
+		assert.Error(t, err)
+		return nil
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host},
+	}
+	_, res := TestOp(Update).Run(p.GetProject(), p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	assertIsErrorOrBailResult(t, res)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
@@ -15,6 +15,7 @@
 package lifecycletest
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"testing"
@@ -22,13 +23,17 @@ import (
 	"github.com/blang/semver"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	. "github.com/pulumi/pulumi/pkg/v3/engine"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/deploytest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
 )
 
@@ -1640,3 +1645,133 @@ func TestPlannedUpdateWithDependentDelete(t *testing.T) {
 	assert.NotNil(t, snap)
 	assert.Nil(t, res)
 }
+
+func TestValidatePlanReportsEveryViolation(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	ins := resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "bar"})
+	diverge := false
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		insA := ins
+		if diverge {
+			insA = resource.NewPropertyMapFromMap(map[string]interface{}{"foo": "baz"})
+		}
+		_, _, _, err := monitor.RegisterResource("pkgA:m:typA", "resA", true, deploytest.ResourceOptions{
+			Inputs: insA,
+		})
+		if err != nil {
+			return err
+		}
+		_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resB", true, deploytest.ResourceOptions{
+			Inputs:  ins,
+			Protect: diverge,
+		})
+		if err != nil {
+			return err
+		}
+		if diverge {
+			_, _, _, err = monitor.RegisterResource("pkgA:m:typA", "resC", true)
+		}
+		return err
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, GeneratePlan: true, Experimental: true},
+	}
+	project := p.GetProject()
+
+	plan, res := TestOp(Update).Plan(project, p.GetTarget(t, nil), p.Options, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// Every divergence is reported, rather than just the first.
+	diverge = true
+	p.Options.Plan = plan.Clone()
+	p.Options.ValidatePlan = true
+	var errors []string
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, true, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			for _, e := range events {
+				if e.Type == DiagEvent {
+					if payload := e.Payload().(DiagEventPayload); payload.Severity == diag.Error {
+						errors = append(errors, colors.Never.Colorize(payload.Message))
+					}
+				}
+			}
+			return res
+		})
+	assert.NotNil(t, res)
+
+	urnA, urnB, urnC := p.NewURN("pkgA:m:typA", "resA", ""), p.NewURN("pkgA:m:typA", "resB", ""),
+		p.NewURN("pkgA:m:typA", "resC", "")
+	require.Len(t, errors, 4)
+	assert.Equal(t, fmt.Sprintf("resource %s violates plan: properties changed: ++foo[{bar}!={baz}]\n", urnA), errors[0])
+	assert.Equal(t, fmt.Sprintf("resource %s violates plan: protect changed (expected false)\n", urnB), errors[1])
+	assert.Equal(t, "create is not allowed by the plan: no steps were expected for this resource\n", errors[2])
+	assert.Equal(t, fmt.Sprintf("the program diverges from the plan in 3 ways:\n"+
+		"    - resource %s violates plan: properties changed: ++foo[{bar}!={baz}]\n"+
+		"    - resource %s violates plan: protect changed (expected false)\n"+
+		"    - %s: create is not allowed by the plan: no steps were expected for this resource\n",
+		urnA, urnB, urnC), errors[3])
+}
+
+func TestValidatePlanReportsComponentMissingFromPlan(t *testing.T) {
+	t.Parallel()
+
+	loaders := []*deploytest.ProviderLoader{
+		deploytest.NewProviderLoader("pkgA", semver.MustParse("1.0.0"), func() (plugin.Provider, error) {
+			return &deploytest.Provider{}, nil
+		}),
+	}
+
+	program := deploytest.NewLanguageRuntime(func(_ plugin.RunInfo, monitor *deploytest.ResourceMonitor) error {
+		urn, _, _, err := monitor.RegisterResource("my:module:Component", "comp", false)
+		if err != nil {
+			return err
+		}
+		return monitor.RegisterResourceOutputs(urn, resource.PropertyMap{
+			"foo": resource.NewStringProperty("bar"),
+		})
+	})
+	host := deploytest.NewPluginHost(nil, nil, program, loaders...)
+
+	p := &TestPlan{
+		Options: UpdateOptions{Host: host, GeneratePlan: true, Experimental: true},
+	}
+	project := p.GetProject()
+
+	snap, res := TestOp(Update).Run(project, p.GetTarget(t, nil), p.Options, false, p.BackendClient, nil)
+	require.Nil(t, res)
+	plan, res := TestOp(Update).Plan(project, p.GetTarget(t, snap), p.Options, p.BackendClient, nil)
+	require.Nil(t, res)
+
+	// The component's inputs are unchanged, so only the registration of its outputs diverges from the plan.
+	urn := p.NewURN("my:module:Component", "comp", "")
+	p.Options.Plan = plan.Clone()
+	delete(p.Options.Plan.ResourcePlans, urn)
+	p.Options.ValidatePlan = true
+	var errors []string
+	_, res = TestOp(Update).Run(project, p.GetTarget(t, snap), p.Options, true, p.BackendClient,
+		func(_ workspace.Project, _ deploy.Target, _ JournalEntries, events []Event, res result.Result) result.Result {
+			for _, e := range events {
+				if e.Type == DiagEvent {
+					if payload := e.Payload().(DiagEventPayload); payload.Severity == diag.Error {
+						errors = append(errors, colors.Never.Colorize(payload.Message))
+					}
+				}
+			}
+			return res
+		})
+	assert.NotNil(t, res)
+
+	require.Len(t, errors, 2)
+	assert.Equal(t, fmt.Sprintf("no plan for resource %s\n", urn), errors[0])
+	assert.Equal(t, fmt.Sprintf("the program diverges from the plan in 1 way:\n"+
+		"    - no plan for resource %s\n", urn), errors[1])
+}
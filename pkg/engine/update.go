@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	resourceanalyzer "github.com/pulumi/pulumi/pkg/v3/resource/analyzer"
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
@@ -130,6 +131,12 @@ type UpdateOptions struct {
 	// XXXTargets lists.
 	TargetDependents bool
 
+	// Specific resources to leave untouched during an update, refresh or destroy operation.
+	ExcludeTargets deploy.UrnTargets
+
+	// true if the dependents of the resources in ExcludeTargets should be left untouched too.
+	ExcludeDependents bool
+
 	// true if the engine should use legacy diffing behavior during an update.
 	UseLegacyDiff bool
 
@@ -151,11 +158,26 @@ type UpdateOptions struct {
 	// The plan to use for the update, if any.
 	Plan *deploy.Plan
 
+	// ValidatePlan when true causes a preview that uses a plan to check the whole program against the plan, reporting
+	// every divergence from it rather than stopping at the first.
+	ValidatePlan bool
+
 	// GeneratePlan when true cause plans to be generated, we skip this if we know their not needed (e.g. during up)
 	GeneratePlan bool
 
 	// Experimental is true if the engine is in experimental mode (i.e. PULUMI_EXPERIMENTAL was set)
 	Experimental bool
+
+	// ContinueOnError is true if the deployment should carry on executing the steps that don't depend on a failed
+	// resource after a step fails, reporting all of the failures at the end.
+	ContinueOnError bool
+	// Deadline, if set, is the time at which the deployment stops launching new steps. The steps that are still running
+	// are given DeadlineGracePeriod to finish, after which providers are asked to cancel them. The deployment then
+	// ends with a DeadlineExceededError, and any steps that are still running are left as pending operations.
+	Deadline time.Time
+
+	// DeadlineGracePeriod is the time that running steps are given to finish once the deadline has passed.
+	DeadlineGracePeriod time.Duration
 }
 
 // HasChanges returns true if there are any non-same changes in the resulting summary.
@@ -196,11 +218,12 @@ func Update(u UpdateInfo, ctx *Context, opts UpdateOptions, dryRun bool) (
 	defer logging.V(7).Infof("*** Update(preview=%v) complete ***", dryRun)
 
 	return update(ctx, info, deploymentOptions{
-		UpdateOptions: opts,
-		SourceFunc:    newUpdateSource,
-		Events:        emitter,
-		Diag:          newEventSink(emitter, false),
-		StatusDiag:    newEventSink(emitter, true),
+		UpdateOptions:     opts,
+		SourceFunc:        newUpdateSource,
+		Events:            emitter,
+		Diag:              newEventSink(emitter, false),
+		StatusDiag:        newEventSink(emitter, true),
+		reportBlastRadius: true,
 	}, dryRun)
 }
 
@@ -617,6 +640,12 @@ func (acts *updateActions) OnResourceStepPost(
 	return ctx.(SnapshotMutation).End(step, err == nil || status == resource.StatusPartialFailure)
 }
 
+func (acts *updateActions) OnResourceStepRetry(
+	step deploy.Step, attempt, maxAttempts int, delay time.Duration, err error,
+) {
+	acts.Opts.Events.resourceOperationRetryEvent(step, attempt, maxAttempts, delay, err, acts.Opts.Debug)
+}
+
 func (acts *updateActions) OnResourceOutputs(step deploy.Step) error {
 	acts.MapLock.Lock()
 	assertSeen(acts.Seen, step)
@@ -644,10 +673,15 @@ func (acts *updateActions) Changes() display.ResourceChanges {
 	return display.ResourceChanges(acts.Ops)
 }
 
+func (acts *updateActions) BlastRadius(prev *deploy.Snapshot) *display.BlastRadius {
+	return nil
+}
+
 type previewActions struct {
 	Ops     map[display.StepOp]int
 	Opts    deploymentOptions
 	Seen    map[resource.URN]deploy.Step
+	Logical map[resource.URN]plannedOp // the logical operation planned for each resource.
 	MapLock sync.Mutex
 }
 
@@ -671,9 +705,10 @@ func ShouldRecordReadStep(step deploy.Step) bool {
 
 func newPreviewActions(opts deploymentOptions) *previewActions {
 	return &previewActions{
-		Ops:  make(map[display.StepOp]int),
-		Opts: opts,
-		Seen: make(map[resource.URN]deploy.Step),
+		Ops:     make(map[display.StepOp]int),
+		Opts:    opts,
+		Seen:    make(map[resource.URN]deploy.Step),
+		Logical: make(map[resource.URN]plannedOp),
 	}
 }
 
@@ -727,6 +762,11 @@ func (acts *previewActions) OnResourceStepPost(ctx interface{},
 			acts.Ops[op]++
 			acts.MapLock.Unlock()
 		}
+		if step.Logical() {
+			acts.MapLock.Lock()
+			acts.Logical[step.URN()] = plannedOp{op: op, old: step.Old()}
+			acts.MapLock.Unlock()
+		}
 
 		acts.Opts.Events.resourceOutputsEvent(op, step, true /*planning*/, acts.Opts.Debug)
 	}
@@ -734,6 +774,12 @@ func (acts *previewActions) OnResourceStepPost(ctx interface{},
 	return nil
 }
 
+func (acts *previewActions) OnResourceStepRetry(
+	step deploy.Step, attempt, maxAttempts int, delay time.Duration, err error,
+) {
+	// Previews never retry steps, as they don't call the providers' Create, Update or Delete methods.
+}
+
 func (acts *previewActions) OnResourceOutputs(step deploy.Step) error {
 	acts.MapLock.Lock()
 	assertSeen(acts.Seen, step)
@@ -761,3 +807,13 @@ func (acts *previewActions) MaybeCorrupt() bool {
 func (acts *previewActions) Changes() display.ResourceChanges {
 	return display.ResourceChanges(acts.Ops)
 }
+
+func (acts *previewActions) BlastRadius(prev *deploy.Snapshot) *display.BlastRadius {
+	if !acts.Opts.reportBlastRadius {
+		return nil
+	}
+
+	acts.MapLock.Lock()
+	defer acts.MapLock.Unlock()
+	return computeBlastRadius(prev, acts.Logical)
+}
@@ -0,0 +1,114 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"sort"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
+)
+
+// deleteSchedule orders a set of steps that delete resources. A resource can only be deleted once every resource in
+// the set that depends on it has been deleted, so each step waits for the steps that delete those dependents. Rather
+// than grouping the steps into levels that must complete one after the other, the schedule releases each step as soon
+// as the steps it waits for have completed, so that a slow delete only holds up the deletes that actually depend on
+// it.
+type deleteSchedule struct {
+	steps    []Step          // the steps, in the order in which they were given.
+	ready    []Step          // the steps that wait for no other step.
+	waiting  map[Step]int    // the number of steps that each step is still waiting for.
+	releases map[Step][]Step // the steps that wait for each step, in the order in which they were given.
+}
+
+// newDeleteSchedule schedules the given delete steps using the given dependency graph. If trustDependencies is false,
+// the dependency graph can't be relied upon, and the steps are executed one after the other in the given order.
+func newDeleteSchedule(dg *graph.DependencyGraph, deleteSteps []Step, trustDependencies bool) *deleteSchedule {
+	s := &deleteSchedule{
+		steps:    deleteSteps,
+		waiting:  make(map[Step]int),
+		releases: make(map[Step][]Step),
+	}
+
+	if !trustDependencies {
+		logging.V(7).Infof("Planner does not trust dependency graph, scheduling deletions serially")
+		for i, step := range deleteSteps {
+			if i == 0 {
+				s.ready = append(s.ready, step)
+				continue
+			}
+			s.waiting[step] = 1
+			s.releases[deleteSteps[i-1]] = []Step{step}
+		}
+		return s
+	}
+
+	logging.V(7).Infof("Planner trusts dependency graph, scheduling deletions in parallel")
+
+	// Record the step that deletes each condemned resource, so that the edges of the dependency graph between
+	// condemned resources can be turned into edges between their steps.
+	condemned := make(graph.ResourceSet)
+	stepMap := make(map[*resource.State]Step)
+	index := make(map[Step]int)
+	for i, step := range deleteSteps {
+		condemned[step.Res()] = true
+		stepMap[step.Res()] = step
+		index[step] = i
+	}
+
+	// The deletes of the condemned resources that a resource depends on wait for the delete of that resource.
+	for _, step := range deleteSteps {
+		var releases []Step
+		for dep := range dg.DependenciesOf(step.Res()).Intersect(condemned) {
+			depStep := stepMap[dep]
+			s.waiting[depStep]++
+			releases = append(releases, depStep)
+		}
+		sort.Slice(releases, func(i, j int) bool { return index[releases[i]] < index[releases[j]] })
+		s.releases[step] = releases
+	}
+	for _, step := range deleteSteps {
+		if s.waiting[step] == 0 {
+			logging.V(7).Infof("Planner scheduling deletion of '%v'", step.URN())
+			s.ready = append(s.ready, step)
+		}
+	}
+	return s
+}
+
+// Len returns the number of steps in the schedule.
+func (s *deleteSchedule) Len() int {
+	return len(s.steps)
+}
+
+// Ready returns the steps that can be executed straight away.
+func (s *deleteSchedule) Ready() []Step {
+	return s.ready
+}
+
+// Complete records that the given step has completed, and returns the steps that can be executed as a result. A step
+// that fails or is skipped must also be completed, so that the steps waiting for it can be skipped in turn.
+func (s *deleteSchedule) Complete(step Step) []Step {
+	var ready []Step
+	for _, next := range s.releases[step] {
+		s.waiting[next]--
+		if s.waiting[next] == 0 {
+			logging.V(7).Infof("Planner scheduling deletion of '%v'", next.URN())
+			ready = append(ready, next)
+		}
+	}
+	return ready
+}
@@ -0,0 +1,95 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// newTestDeleteSteps returns a delete step for each of the given resources, which are component resources so that the
+// steps need no provider.
+func newTestDeleteSteps(resources ...*resource.State) []Step {
+	steps := make([]Step, len(resources))
+	for i, res := range resources {
+		steps[i] = NewDeleteStep(nil, map[resource.URN]bool{}, res)
+	}
+	return steps
+}
+
+func stepNames(steps []Step) []string {
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = string(step.URN().Name())
+	}
+	return names
+}
+
+func TestDeleteSchedule(t *testing.T) {
+	t.Parallel()
+
+	// a <- b <- c and a <- d, so c and d can be deleted straight away, b once c has been deleted, and a once b and d
+	// have been deleted.
+	a := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::a"}
+	b := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::b", Dependencies: []resource.URN{a.URN}}
+	c := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::c", Dependencies: []resource.URN{b.URN}}
+	d := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::d", Dependencies: []resource.URN{a.URN}}
+	dg := graph.NewDependencyGraph([]*resource.State{a, b, c, d})
+
+	// The steps are given in reverse topological order, as the step generator gives them.
+	steps := newTestDeleteSteps(d, c, b, a)
+	s := newDeleteSchedule(dg, steps, true)
+	assert.Equal(t, 4, s.Len())
+	assert.Equal(t, []string{"d", "c"}, stepNames(s.Ready()))
+
+	// Deleting d doesn't release a, which still waits for b.
+	assert.Empty(t, s.Complete(steps[0]))
+	assert.Equal(t, []string{"b"}, stepNames(s.Complete(steps[1])))
+	assert.Equal(t, []string{"a"}, stepNames(s.Complete(steps[2])))
+	assert.Empty(t, s.Complete(steps[3]))
+}
+
+func TestDeleteScheduleIgnoresResourcesNotDeleted(t *testing.T) {
+	t.Parallel()
+
+	// b depends on a but isn't being deleted, so it doesn't hold up the delete of a.
+	a := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::a"}
+	b := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::b", Dependencies: []resource.URN{a.URN}}
+	dg := graph.NewDependencyGraph([]*resource.State{a, b})
+
+	s := newDeleteSchedule(dg, newTestDeleteSteps(a), true)
+	assert.Equal(t, []string{"a"}, stepNames(s.Ready()))
+}
+
+func TestDeleteScheduleUntrustedDependencies(t *testing.T) {
+	t.Parallel()
+
+	a := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::a"}
+	b := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::b"}
+	c := &resource.State{URN: "urn:pulumi:stack::project::pkgA:m:typA::c"}
+	dg := graph.NewDependencyGraph([]*resource.State{a, b, c})
+
+	// Without dependencies to go on, the steps are executed one after the other in the given order.
+	steps := newTestDeleteSteps(c, b, a)
+	s := newDeleteSchedule(dg, steps, false)
+	assert.Equal(t, []string{"c"}, stepNames(s.Ready()))
+	assert.Equal(t, []string{"b"}, stepNames(s.Complete(steps[0])))
+	assert.Equal(t, []string{"a"}, stepNames(s.Complete(steps[1])))
+	assert.Empty(t, s.Complete(steps[2]))
+}
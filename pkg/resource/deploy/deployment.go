@@ -21,6 +21,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	uuid "github.com/gofrs/uuid"
 
@@ -61,11 +62,22 @@ type Options struct {
 	DestroyTargets            UrnTargets // Specific resources to destroy.
 	UpdateTargets             UrnTargets // Specific resources to update.
 	TargetDependents          bool       // true if we're allowing things to proceed, even with unspecified targets
+	ExcludeTargets            UrnTargets // Specific resources to leave untouched.
+	ExcludeDependents         bool       // true if the dependents of excluded resources are left untouched too.
 	TrustDependencies         bool       // whether or not to trust the resource dependency graph.
 	UseLegacyDiff             bool       // whether or not to use legacy diffing behavior.
 	DisableResourceReferences bool       // true to disable resource reference support.
 	DisableOutputValues       bool       // true to disable output value support.
 	GeneratePlan              bool       // true to enable plan generation.
+	ContinueOnError           bool       // true to carry on with the steps that don't depend on a failed resource.
+	ValidatePlan              bool       // true to report every divergence of a preview from its plan.
+	// the policy for retrying provider operations that fail with transient errors, unless overridden by a resource.
+	Retry *resource.RetryPolicy
+	// the caps on the concurrency and rate of resource operations, keyed by package name or resource type token.
+	Limits map[string]workspace.ResourceLimits
+	// the time given to running steps to finish when the deployment passes its deadline, before providers are asked to
+	// cancel them.
+	DeadlineGracePeriod time.Duration
 }
 
 // DegreeOfParallelism returns the degree of parallelism that should be used during the
@@ -182,6 +194,7 @@ func (t *UrnTargets) addLiteral(urn resource.URN) {
 type StepExecutorEvents interface {
 	OnResourceStepPre(step Step) (interface{}, error)
 	OnResourceStepPost(ctx interface{}, step Step, status resource.Status, err error) error
+	OnResourceStepRetry(step Step, attempt, maxAttempts int, delay time.Duration, err error)
 	OnResourceOutputs(step Step) error
 }
 
@@ -288,6 +301,8 @@ type Deployment struct {
 	goals                *goalMap                         // the set of resource goals generated by the deployment.
 	news                 *resourceMap                     // the set of new resources generated by the deployment
 	newPlans             *resourcePlans                   // the set of new resource plans.
+	validatePlan         bool                             // true if every divergence from the plan is reported.
+	planViolations       planViolations                   // the divergences from the plan, if validatePlan is set.
 }
 
 // addDefaultProviders adds any necessary default provider definitions and references to the given snapshot. Version
@@ -19,6 +19,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize/english"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
 	"github.com/pulumi/pulumi/pkg/v3/resource/graph"
@@ -93,7 +96,9 @@ func (ex *deploymentExecutor) printPendingOperationsWarning() {
 		"using `pulumi refresh` which will refresh the state from the provider you are using and " +
 		"clear the pending operations if there are any.\n" +
 		"\n" +
-		"Note that `pulumi refresh` will need to be run interactively to clear pending CREATE operations."
+		"Note that `pulumi refresh` will need to be run interactively to clear pending CREATE operations. " +
+		"Alternatively, `pulumi state pending` resolves each pending operation by reading the resource from its " +
+		"provider, dropping the operation, or marking the resource for deletion."
 
 	warning := "Attempting to deploy or update resources " +
 		fmt.Sprintf("with %d pending operations from previous deployment.\n", len(ex.deployment.prev.PendingOperations)) +
@@ -129,6 +134,19 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	go func() {
 		select {
 		case <-callerCtx.Done():
+			// If the deployment passed its deadline, give the steps that are running a chance to finish before asking
+			// providers to cancel them. No new steps are launched in the meantime.
+			if errors.Is(callerCtx.Err(), context.DeadlineExceeded) && opts.DeadlineGracePeriod > 0 {
+				logging.V(4).Infof("deploymentExecutor.Execute(...): deadline exceeded, waiting %v for running steps...",
+					opts.DeadlineGracePeriod)
+				select {
+				case <-time.After(opts.DeadlineGracePeriod):
+				case <-done:
+					logging.V(4).Infof("deploymentExecutor.Execute(...): exiting provider canceller")
+					return
+				}
+			}
+
 			logging.V(4).Infof("deploymentExecutor.Execute(...): signalling cancellation to providers...")
 			cancelErr := ex.deployment.ctx.Host.SignalCancellation()
 			if cancelErr != nil {
@@ -139,6 +157,10 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 		}
 	}()
 
+	// A preview can validate the whole program against the plan, since none of the steps that diverge from it are
+	// actually applied. An update always stops at the first divergence.
+	ex.deployment.validatePlan = opts.ValidatePlan && preview && ex.deployment.plan != nil
+
 	// If this deployment is an import, run the imports and exit.
 	if ex.deployment.isImport {
 		return ex.importResources(callerCtx, opts, preview)
@@ -191,7 +213,7 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	ctx, cancel := context.WithCancel(callerCtx)
 
 	// Set up a step generator and executor for this deployment.
-	ex.stepExec = newStepExecutor(ctx, cancel, ex.deployment, opts, preview, false)
+	ex.stepExec = newStepExecutor(ctx, cancel, ex.deployment, opts, preview, opts.ContinueOnError)
 
 	// We iterate the source in its own goroutine because iteration is blocking and we want the main loop to be able to
 	// respond to cancellation requests promptly.
@@ -235,6 +257,12 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 					if !event.Result.IsBail() {
 						ex.reportError("", event.Result.Error())
 					}
+					if opts.ContinueOnError {
+						// Let the steps that are already executing run to completion, but don't perform any deletes:
+						// the program didn't finish, so we can't tell which resources it no longer wants.
+						ex.stepExec.SignalCompletion()
+						return false, result.Bail()
+					}
 					cancel()
 
 					// We reported any errors above.  So we can just bail now.
@@ -274,6 +302,11 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	ex.stepExec.WaitForCompletion()
 	logging.V(4).Infof("deploymentExecutor.Execute(...): step executor has completed")
 
+	// Now that nothing else can fail, report every resource that failed or was skipped together.
+	if opts.ContinueOnError {
+		ex.reportFailures()
+	}
+
 	// Now that we've performed all steps in the deployment, ensure that the list of targets to update was
 	// valid.  We have to do this *after* performing the steps as the target list may have referred
 	// to a resource that was created in one of the steps.
@@ -285,8 +318,9 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 	// so by the time we get here everything in the map should have an empty ops list (except for unneeded
 	// deletes). We skip this check if we already have an error, chances are if the deployment failed lots of
 	// operations wouldn't have got a chance to run so we'll spam errors about all of those failed operations
-	// making it less clear to the user what the root cause error was.
-	if res == nil && ex.deployment.plan != nil {
+	// making it less clear to the user what the root cause error was. The same goes for resources that were skipped
+	// because we continued on error.
+	if res == nil && ex.deployment.plan != nil && !(opts.ContinueOnError && ex.stepExec.Errored()) {
 		for urn, resourcePlan := range ex.deployment.plan.ResourcePlans {
 			if len(resourcePlan.Ops) != 0 {
 				if len(resourcePlan.Ops) == 1 && resourcePlan.Ops[0] == OpDelete {
@@ -307,13 +341,20 @@ func (ex *deploymentExecutor) Execute(callerCtx context.Context, opts Options, p
 				}
 
 				err := fmt.Errorf("expected resource operations for %v but none were seen", urn)
-				logging.V(4).Infof("deploymentExecutor.Execute(...): error handling event: %v", err)
-				ex.reportError(urn, err)
-				res = result.Bail()
+				if err := ex.deployment.planViolation(urn, err); err != nil {
+					logging.V(4).Infof("deploymentExecutor.Execute(...): error handling event: %v", err)
+					ex.reportError(urn, err)
+					res = result.Bail()
+				}
 			}
 		}
 	}
 
+	// If the whole program was validated against the plan, report every divergence from the plan together.
+	if res == nil && ex.deployment.validatePlan {
+		res = ex.reportPlanViolations()
+	}
+
 	if res != nil && res.IsBail() {
 		return nil, res
 	}
@@ -376,22 +417,14 @@ func (ex *deploymentExecutor) performDeletes(
 		return res
 	}
 
-	deletes := ex.stepGen.ScheduleDeletes(deleteSteps)
-
-	// ScheduleDeletes gives us a list of lists of steps. Each list of steps can safely be executed
-	// in parallel, but each list must execute completes before the next list can safely begin
-	// executing.
-	//
-	// This is not "true" delete parallelism, since there may be resources that could safely begin
-	// deleting but we won't until the previous set of deletes fully completes. This approximation
-	// is conservative, but correct.
-	for _, antichain := range deletes {
-		logging.V(4).Infof("deploymentExecutor.Execute(...): beginning delete antichain")
-		tok := ex.stepExec.ExecuteParallel(antichain)
-		tok.Wait(ctx)
-		logging.V(4).Infof("deploymentExecutor.Execute(...): antichain complete")
+	// If we're continuing on error, resources that were skipped because something they depend on failed were never
+	// registered, but that doesn't mean that the program no longer wants them, so they must not be deleted.
+	if ex.stepExec.continueOnError {
+		deleteSteps = ex.filterFailedDeletes(deleteSteps)
 	}
 
+	ex.executeDeletes(ctx, ex.stepGen.ScheduleDeletes(deleteSteps))
+
 	// After executing targeted deletes, we may now have resources that depend on the resource that
 	// were deleted.  Go through and clean things up accordingly for them.
 	if targetsOpt.IsConstrained() {
@@ -406,6 +439,163 @@ func (ex *deploymentExecutor) performDeletes(
 	return nil
 }
 
+// filterFailedDeletes removes the deletes of resources that failed or were skipped from the given steps.
+func (ex *deploymentExecutor) filterFailedDeletes(steps []Step) []Step {
+	filtered := steps[:0]
+	for _, step := range steps {
+		if ex.stepExec.HasFailed(step.URN()) {
+			logging.V(7).Infof("performDeletes(...): not deleting %v, which failed or was skipped", step.URN())
+			continue
+		}
+		filtered = append(filtered, step)
+	}
+	return filtered
+}
+
+// executeDeletes executes the delete steps of the given schedule. Each step is submitted to the step executor as soon as
+// the steps that it waits for have completed, so that deletes don't wait on unrelated deletes. If a step fails, the
+// resources that its resource depends on can't be deleted, so their steps are skipped.
+func (ex *deploymentExecutor) executeDeletes(ctx context.Context, schedule *deleteSchedule) {
+	var dg *graph.DependencyGraph
+	blocked := make(map[*resource.State]resource.URN)
+
+	// The channel is large enough for every step to complete without blocking, even if we stop waiting for them.
+	completed := make(chan Step, schedule.Len())
+	inFlight := 0
+
+	ready := schedule.Ready()
+	for len(ready) > 0 || inFlight > 0 {
+		var next []Step
+		for _, step := range ready {
+			if dependent, has := blocked[step.Old()]; has {
+				err := fmt.Errorf("skipped because %v, which depends on it, failed to delete", dependent)
+				ex.reportError(step.URN(), err)
+				ex.stepExec.Skip(step.URN(), err)
+				next = append(next, schedule.Complete(step)...)
+				continue
+			}
+
+			logging.V(4).Infof("deploymentExecutor.Execute(...): beginning delete of %v", step.URN())
+			tok := ex.stepExec.ExecuteSerial(chain{step})
+			inFlight++
+			go func(step Step) {
+				tok.Wait(ctx)
+				completed <- step
+			}(step)
+		}
+		ready = next
+		if len(ready) > 0 {
+			continue
+		}
+		if inFlight == 0 {
+			break
+		}
+
+		select {
+		case step := <-completed:
+			inFlight--
+			logging.V(4).Infof("deploymentExecutor.Execute(...): delete of %v complete", step.URN())
+
+			// A resource that failed to delete is still there, so the resources that it depends on can't be deleted.
+			// Failures and dependencies are followed state by state rather than by URN, since the copies of a
+			// resource that are pending deletion share its URN but not its dependencies.
+			if step.Old() != nil && ex.stepExec.HasFailedOld(step.Old()) {
+				if dg == nil {
+					dg = graph.NewDependencyGraph(ex.deployment.prev.Resources)
+				}
+				pending := []*resource.State{step.Old()}
+				for len(pending) > 0 {
+					res := pending[len(pending)-1]
+					pending = pending[:len(pending)-1]
+					for dep := range dg.DependenciesOf(res) {
+						if _, has := blocked[dep]; !has {
+							blocked[dep] = step.URN()
+							pending = append(pending, dep)
+						}
+					}
+				}
+			}
+			ready = schedule.Complete(step)
+		case <-ctx.Done():
+			logging.V(4).Infof("deploymentExecutor.Execute(...): deletes canceled")
+			return
+		}
+	}
+}
+
+// failedDependency returns the URN of a resource that failed or was skipped that the resource with the given parent,
+// dependencies and provider depends on, if there is one.
+func (ex *deploymentExecutor) failedDependency(
+	parent resource.URN, dependencies []resource.URN, provider string,
+) (resource.URN, bool) {
+	deps := append([]resource.URN{parent}, dependencies...)
+	if provider != "" {
+		if ref, err := providers.ParseReference(provider); err == nil {
+			deps = append(deps, ref.URN())
+		}
+	}
+	for _, dep := range deps {
+		if dep != "" && ex.stepExec.HasFailed(dep) {
+			return dep, true
+		}
+	}
+	return "", false
+}
+
+// skipRegistration skips the registration of a resource that depends on a resource that failed or was skipped,
+// telling the program that the registration failed.
+func (ex *deploymentExecutor) skipRegistration(urn, dependency resource.URN, done func(err error)) {
+	err := fmt.Errorf("skipped because %v failed", dependency)
+	logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): %v %v", urn, err)
+	ex.reportError(urn, err)
+	ex.stepExec.Skip(urn, err)
+	done(err)
+}
+
+// reportPlanViolations reports every divergence of the deployment from its plan, and fails the deployment if there
+// are any.
+func (ex *deploymentExecutor) reportPlanViolations() result.Result {
+	violations := ex.deployment.PlanViolations()
+	if len(violations) == 0 {
+		return nil
+	}
+
+	var message strings.Builder
+	for _, violation := range violations {
+		// Prefix the violations that don't mention their resource with its URN.
+		if violation.URN != "" && !strings.Contains(violation.Err.Error(), string(violation.URN)) {
+			fmt.Fprintf(&message, "\n    - %v: %v", violation.URN, violation.Err)
+		} else {
+			fmt.Fprintf(&message, "\n    - %v", violation.Err)
+		}
+	}
+	ex.reportError("", fmt.Errorf("the program diverges from the plan in %d %s:%s",
+		len(violations), english.PluralWord(len(violations), "way", ""), message.String()))
+	return result.Bail()
+}
+
+// reportFailures reports the resources that failed or were skipped during a deployment that continued on error.
+func (ex *deploymentExecutor) reportFailures() {
+	failures := ex.stepExec.Failures()
+	if len(failures) == 0 {
+		return
+	}
+
+	var failed, skipped int
+	var message strings.Builder
+	for _, failure := range failures {
+		if failure.Skipped {
+			skipped++
+		} else {
+			failed++
+		}
+		fmt.Fprintf(&message, "\n    - %v: %v", failure.URN, failure.Err)
+	}
+	ex.reportError("", fmt.Errorf("%d %s failed and %d %s skipped:%s",
+		failed, english.PluralWord(failed, "resource", ""), skipped, english.PluralWord(skipped, "was", "were"),
+		message.String()))
+}
+
 // handleSingleEvent handles a single source event. For all incoming events, it produces a chain that needs
 // to be executed and schedules the chain for execution.
 func (ex *deploymentExecutor) handleSingleEvent(event SourceEvent) result.Result {
@@ -416,9 +606,28 @@ func (ex *deploymentExecutor) handleSingleEvent(event SourceEvent) result.Result
 	switch e := event.(type) {
 	case RegisterResourceEvent:
 		logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): received RegisterResourceEvent")
+		if ex.stepExec.continueOnError {
+			goal := e.Goal()
+			deps := append([]resource.URN(nil), goal.Dependencies...)
+			for _, propDeps := range goal.PropertyDependencies {
+				deps = append(deps, propDeps...)
+			}
+			if dep, failed := ex.failedDependency(goal.Parent, deps, goal.Provider); failed {
+				urn := ex.deployment.generateURN(goal.Parent, goal.Type, goal.Name)
+				ex.skipRegistration(urn, dep, func(err error) { e.Done(&RegisterResult{Err: err}) })
+				return nil
+			}
+		}
 		steps, res = ex.stepGen.GenerateSteps(e)
 	case ReadResourceEvent:
 		logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): received ReadResourceEvent")
+		if ex.stepExec.continueOnError {
+			if dep, failed := ex.failedDependency(e.Parent(), e.Dependencies(), e.Provider()); failed {
+				urn := ex.deployment.generateURN(e.Parent(), e.Type(), e.Name())
+				ex.skipRegistration(urn, dep, func(err error) { e.Done(&ReadResult{Err: err}) })
+				return nil
+			}
+		}
 		steps, res = ex.stepGen.GenerateReadSteps(e)
 	case RegisterResourceOutputsEvent:
 		logging.V(4).Infof("deploymentExecutor.handleSingleEvent(...): received register resource outputs")
@@ -489,10 +698,12 @@ func (ex *deploymentExecutor) refresh(callerCtx context.Context, opts Options, p
 	// If the user did not provide any --target's, create a refresh step for each resource in the
 	// old snapshot.  If they did provider --target's then only create refresh steps for those
 	// specific targets.
+	// Excluded resources are never refreshed.
 	steps := []Step{}
 	resourceToStep := map[*resource.State]Step{}
+	excluded := excludedResources(prev, opts.ExcludeTargets, opts.ExcludeDependents)
 	for _, res := range prev.Resources {
-		if opts.RefreshTargets.Contains(res.URN) {
+		if opts.RefreshTargets.Contains(res.URN) && !excluded[res] {
 			step := NewRefreshStep(ex.deployment, res, nil)
 			steps = append(steps, step)
 			resourceToStep[res] = step
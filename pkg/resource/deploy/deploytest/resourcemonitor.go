@@ -102,6 +102,7 @@ type ResourceOptions struct {
 	Aliases                 []resource.Alias
 	ImportID                resource.ID
 	CustomTimeouts          *resource.CustomTimeouts
+	Retry                   *resource.RetryPolicy
 	RetainOnDelete          bool
 	DeletedWith             resource.URN
 	SupportsPartialValues   *bool
@@ -188,6 +189,15 @@ func (rm *ResourceMonitor) RegisterResource(t tokens.Type, name string, custom b
 		}
 	}
 
+	var retry *pulumirpc.RegisterResourceRequest_RetryPolicy
+	if opts.Retry != nil {
+		retry = &pulumirpc.RegisterResourceRequest_RetryPolicy{
+			MaxAttempts:  int32(opts.Retry.MaxAttempts),
+			InitialDelay: prepareTestTimeout(opts.Retry.InitialDelay),
+			MaxDelay:     prepareTestTimeout(opts.Retry.MaxDelay),
+		}
+	}
+
 	deleteBeforeReplace := false
 	if opts.DeleteBeforeReplace != nil {
 		deleteBeforeReplace = *opts.DeleteBeforeReplace
@@ -219,6 +229,7 @@ func (rm *ResourceMonitor) RegisterResource(t tokens.Type, name string, custom b
 		AliasURNs:                  aliasStrings,
 		ImportId:                   string(opts.ImportID),
 		CustomTimeouts:             timeouts,
+		Retry:                      retry,
 		SupportsPartialValues:      supportsPartialValues,
 		Remote:                     opts.Remote,
 		ReplaceOnChanges:           opts.ReplaceOnChanges,
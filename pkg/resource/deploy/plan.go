@@ -4,12 +4,14 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mitchellh/copystructure"
 
 	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
 	"github.com/pulumi/pulumi/pkg/v3/version"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
@@ -631,3 +633,42 @@ func (rp *ResourcePlan) checkGoal(
 
 	return nil
 }
+
+// PlanViolation is a way in which a deployment diverges from its plan.
+type PlanViolation struct {
+	URN resource.URN // the resource that diverges from the plan, if any.
+	Err error        // how the resource diverges from the plan.
+}
+
+// planViolations records the ways in which a deployment diverges from its plan.
+type planViolations struct {
+	m          sync.Mutex
+	violations []PlanViolation
+}
+
+// planViolation handles a divergence of the deployment from its plan. If the deployment validates the whole program
+// against its plan, the divergence is reported and recorded, and nil is returned so that the deployment carries on.
+// Otherwise, the error is returned so that the deployment stops.
+func (d *Deployment) planViolation(urn resource.URN, err error) error {
+	if !d.validatePlan {
+		return err
+	}
+
+	d.Diag().Errorf(diag.RawMessage(urn, err.Error()))
+
+	d.planViolations.m.Lock()
+	defer d.planViolations.m.Unlock()
+	d.planViolations.violations = append(d.planViolations.violations, PlanViolation{URN: urn, Err: err})
+	return nil
+}
+
+// PlanViolations returns the recorded divergences of the deployment from its plan, in the order in which they
+// happened.
+func (d *Deployment) PlanViolations() []PlanViolation {
+	d.planViolations.m.Lock()
+	defer d.planViolations.m.Unlock()
+
+	violations := make([]PlanViolation, len(d.planViolations.violations))
+	copy(violations, d.planViolations.violations)
+	return violations
+}
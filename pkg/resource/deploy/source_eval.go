@@ -389,6 +389,10 @@ func (d *defaultProviders) handleRequest(req providers.ProviderRequest) (provide
 		return providers.Reference{}, context.Canceled
 	}
 
+	if result.Err != nil {
+		return providers.Reference{}, fmt.Errorf("registering default provider for package %s: %w", req, result.Err)
+	}
+
 	logging.V(5).Infof("registered default provider for package %s: %s", req, result.State.URN)
 
 	id := result.State.ID
@@ -977,6 +981,9 @@ func (rm *resmon) ReadResource(ctx context.Context,
 	}
 
 	contract.Assertf(result != nil, "ReadResource operation returned a nil result")
+	if result.Err != nil {
+		return nil, rpcerror.New(codes.Aborted, result.Err.Error())
+	}
 	marshaled, err := plugin.MarshalProperties(result.State.Outputs, plugin.MarshalOptions{
 		Label:         label,
 		KeepUnknowns:  true,
@@ -1010,6 +1017,7 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	customTimeouts := req.GetCustomTimeouts()
 	retainOnDelete := req.GetRetainOnDelete()
 	deletedWith := resource.URN(req.GetDeletedWith())
+	retryPolicy := req.GetRetry()
 
 	// Custom resources must have a three-part type so that we can 1) identify if they are providers and 2) retrieve the
 	// provider responsible for managing a particular resource (based on the type's Package).
@@ -1167,9 +1175,9 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	logging.V(5).Infof(
 		"ResourceMonitor.RegisterResource received: t=%v, name=%v, custom=%v, #props=%v, parent=%v, protect=%v, "+
 			"provider=%v, deps=%v, deleteBeforeReplace=%v, ignoreChanges=%v, aliases=%v, customTimeouts=%v, "+
-			"providers=%v, replaceOnChanges=%v, retainOnDelete=%v, deletedWith=%v",
+			"providers=%v, replaceOnChanges=%v, retainOnDelete=%v, deletedWith=%v, retry=%v",
 		t, name, custom, len(props), parent, protect, providerRef, dependencies, deleteBeforeReplace, ignoreChanges,
-		aliases, customTimeouts, providerRefs, replaceOnChanges, retainOnDelete, deletedWith)
+		aliases, customTimeouts, providerRefs, replaceOnChanges, retainOnDelete, deletedWith, retryPolicy)
 
 	// If this is a remote component, fetch its provider and issue the construct call. Otherwise, register the resource.
 	var result *RegisterResult
@@ -1257,11 +1265,33 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 			}
 		}
 
+		var retry *resource.RetryPolicy
+		if retryPolicy != nil {
+			retry = &resource.RetryPolicy{MaxAttempts: int(retryPolicy.MaxAttempts)}
+			if retryPolicy.InitialDelay != "" {
+				seconds, err := generateRetryDelayInSeconds(retryPolicy.InitialDelay)
+				if err != nil {
+					return nil, err
+				}
+				retry.InitialDelay = seconds
+			}
+			if retryPolicy.MaxDelay != "" {
+				seconds, err := generateRetryDelayInSeconds(retryPolicy.MaxDelay)
+				if err != nil {
+					return nil, err
+				}
+				retry.MaxDelay = seconds
+			}
+		}
+
+		goal := resource.NewGoal(t, name, custom, props, parent, protect, dependencies,
+			providerRef.String(), nil, propertyDependencies, deleteBeforeReplace, ignoreChanges,
+			additionalSecretKeys, aliases, id, &timeouts, replaceOnChanges, retainOnDelete, deletedWith)
+		goal.Retry = retry
+
 		// Send the goal state to the engine.
 		step := &registerResourceEvent{
-			goal: resource.NewGoal(t, name, custom, props, parent, protect, dependencies,
-				providerRef.String(), nil, propertyDependencies, deleteBeforeReplace, ignoreChanges,
-				additionalSecretKeys, aliases, id, &timeouts, replaceOnChanges, retainOnDelete, deletedWith),
+			goal: goal,
 			done: make(chan *RegisterResult),
 		}
 
@@ -1279,6 +1309,9 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 			logging.V(5).Infof("ResourceMonitor.RegisterResource operation canceled, name=%s", name)
 			return nil, rpcerror.New(codes.Unavailable, "resource monitor shut down while waiting on step's done channel")
 		}
+		if result.Err != nil {
+			return nil, rpcerror.New(codes.Aborted, result.Err.Error())
+		}
 	}
 
 	if !custom && result != nil && result.State != nil && result.State.URN != "" {
@@ -1324,6 +1357,7 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 	// • replaceOnChanges
 	// • retainOnDelete
 	// • deletedWith
+	// • retry
 	// Revisit these semantics in Pulumi v4.0
 	// See this issue for more: https://github.com/pulumi/pulumi/issues/9704
 	if !custom {
@@ -1351,6 +1385,9 @@ func (rm *resmon) RegisterResource(ctx context.Context,
 		rm.checkComponentOption(result.State.URN, "deletedWith", func() bool {
 			return deletedWith != ""
 		})
+		rm.checkComponentOption(result.State.URN, "retry", func() bool {
+			return retryPolicy != nil
+		})
 	}
 
 	logging.V(5).Infof(
@@ -1519,6 +1556,15 @@ func generateTimeoutInSeconds(timeout string) (float64, error) {
 	return duration.Seconds(), nil
 }
 
+func generateRetryDelayInSeconds(delay string) (float64, error) {
+	duration, err := time.ParseDuration(delay)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse retry delay %s", delay)
+	}
+
+	return duration.Seconds(), nil
+}
+
 func decorateResourceSpans(span opentracing.Span, method string, req, resp interface{}, grpcError error) {
 	if req == nil {
 		return
@@ -20,12 +20,15 @@ import (
 	"fmt"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/diag"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/plugin"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/logging"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/result"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/retry"
 )
 
 const (
@@ -35,12 +38,36 @@ const (
 
 	// Utility constant for easy debugging.
 	stepExecutorLogLevel = 4
+
+	// Defaults for retrying steps that fail with transient errors, and the factor by which the delay grows.
+	defaultRetryInitialDelay = time.Second
+	defaultRetryMaxDelay     = 30 * time.Second
+	retryBackoff             = 2.0
 )
 
-// errStepApplyFailed is a sentinel error for errors that arise when step application fails.
-// We (the step executor) are not responsible for reporting those errors so this sentinel ensures
-// that we don't do so.
-var errStepApplyFailed = errors.New("step application failed")
+// stepApplyError wraps errors that arise when step application fails. We (the step executor) are not responsible for
+// reporting those errors so this type ensures that we don't do so.
+type stepApplyError struct {
+	err       error
+	completed bool // true if the step was completed despite failing, which happens on partial failures.
+}
+
+func (e *stepApplyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *stepApplyError) Unwrap() error {
+	return e.err
+}
+
+// stepFailure records a resource whose step failed, or that was skipped because a resource that it depends on failed,
+// in a deployment that continues on error.
+type stepFailure struct {
+	URN     resource.URN    // the URN of the resource.
+	Old     *resource.State // the old state of the resource that the failed step operated on, if any.
+	Err     error           // the error that failed the step, or that explains why the resource was skipped.
+	Skipped bool            // true if the resource was skipped rather than failing itself.
+}
 
 // The step executor operates in terms of "chains" and "antichains". A chain is set of steps that are totally ordered
 // when ordered by dependency; each step in a chain depends directly on the step that comes before it. An antichain
@@ -74,6 +101,16 @@ func (c completionToken) Wait(ctx context.Context) {
 type incomingChain struct {
 	Chain          chain     // The chain we intend to execute
 	CompletionChan chan bool // A completion channel to be closed when the chain has completed execution
+
+	// If set, the chain is a single step that is executed on behalf of another worker, which is sent the result of
+	// the step and handles its failure.
+	StepResults chan<- stepResult
+}
+
+// stepResult is the result of a step that was executed on behalf of another worker.
+type stepResult struct {
+	step Step
+	err  error
 }
 
 // stepExecutor is the component of the engine responsible for taking steps and executing
@@ -92,10 +129,19 @@ type stepExecutor struct {
 
 	workers        sync.WaitGroup     // WaitGroup tracking the worker goroutines that are owned by this step executor.
 	incomingChains chan incomingChain // Incoming chains that we are to execute
+	incomingLock   sync.RWMutex       // Lock guarding the closing of incomingChains against hand-offs between workers.
+	incomingClosed bool               // True once incomingChains has been closed.
 
 	ctx      context.Context    // cancellation context for the current deployment.
 	cancel   context.CancelFunc // CancelFunc that cancels the above context.
 	sawError atomic.Value       // atomic boolean indicating whether or not the step excecutor saw that there was an error.
+
+	limiter *stepLimiter // The limiter that caps the concurrency and rate of steps per package and resource type.
+
+	failuresLock sync.Mutex               // Lock guarding the failures below.
+	failures     []stepFailure            // The resources that failed or were skipped, if continuing on error.
+	failed       map[resource.URN]bool    // The set of URNs in failures.
+	failedOlds   map[*resource.State]bool // The set of old states in failures.
 }
 
 //
@@ -171,11 +217,13 @@ func (se *stepExecutor) ExecuteRegisterResourceOutputs(e RegisterResourceOutputs
 	if se.deployment.plan != nil {
 		resourcePlan, ok := se.deployment.plan.ResourcePlans[urn]
 		if !ok {
-			return result.FromError(fmt.Errorf("no plan for resource %v", urn))
-		}
-
-		if err := resourcePlan.checkOutputs(oldOuts, outs); err != nil {
-			return result.FromError(fmt.Errorf("resource violates plan: %w", err))
+			if err := se.deployment.planViolation(urn, fmt.Errorf("no plan for resource %v", urn)); err != nil {
+				return result.FromError(err)
+			}
+		} else if err := resourcePlan.checkOutputs(oldOuts, outs); err != nil {
+			if err := se.deployment.planViolation(urn, fmt.Errorf("resource violates plan: %w", err)); err != nil {
+				return result.FromError(err)
+			}
 		}
 	}
 
@@ -216,12 +264,75 @@ func (se *stepExecutor) Errored() bool {
 	return se.sawError.Load().(bool)
 }
 
+// Failures returns the resources that failed or were skipped so far, in the order in which they did so.
+func (se *stepExecutor) Failures() []stepFailure {
+	se.failuresLock.Lock()
+	defer se.failuresLock.Unlock()
+
+	return append([]stepFailure(nil), se.failures...)
+}
+
+// HasFailed returns true if the resource with the given URN failed or was skipped.
+func (se *stepExecutor) HasFailed(urn resource.URN) bool {
+	se.failuresLock.Lock()
+	defer se.failuresLock.Unlock()
+
+	return se.failed[urn]
+}
+
+// HasFailedOld returns true if a step that operated on the given old state of a resource failed. Unlike HasFailed, this
+// tells apart the states of a resource that share its URN, such as those pending deletion after a replacement.
+func (se *stepExecutor) HasFailedOld(old *resource.State) bool {
+	se.failuresLock.Lock()
+	defer se.failuresLock.Unlock()
+
+	return se.failedOlds[old]
+}
+
+// Skip records that the resource with the given URN was skipped because a resource that it depends on failed.
+func (se *stepExecutor) Skip(urn resource.URN, err error) {
+	se.recordFailure(stepFailure{URN: urn, Err: err, Skipped: true})
+	se.sawError.Store(true)
+}
+
+func (se *stepExecutor) recordFailure(failure stepFailure) {
+	se.failuresLock.Lock()
+	defer se.failuresLock.Unlock()
+
+	se.failures = append(se.failures, failure)
+	se.failed[failure.URN] = true
+	if failure.Old != nil {
+		se.failedOlds[failure.Old] = true
+	}
+}
+
 // SignalCompletion signals to the stepExecutor that there are no more chains left to execute. All worker
 // threads will terminate as soon as they retire all of the work they are currently executing.
 func (se *stepExecutor) SignalCompletion() {
+	se.incomingLock.Lock()
+	defer se.incomingLock.Unlock()
+
+	se.incomingClosed = true
 	close(se.incomingChains)
 }
 
+// handOff submits a step to an idle worker on behalf of another worker, which is sent the result of the step. It
+// returns false if no worker is idle, or if no more chains are accepted, in which case the step is not executed.
+func (se *stepExecutor) handOff(step Step, results chan<- stepResult) bool {
+	se.incomingLock.RLock()
+	defer se.incomingLock.RUnlock()
+
+	if se.incomingClosed {
+		return false
+	}
+	select {
+	case se.incomingChains <- incomingChain{Chain: chain{step}, StepResults: results}:
+		return true
+	default:
+		return false
+	}
+}
+
 // WaitForCompletion blocks the calling goroutine until the step executor completes execution of all in-flight
 // chains.
 func (se *stepExecutor) WaitForCompletion() {
@@ -239,7 +350,20 @@ func (se *stepExecutor) WaitForCompletion() {
 // executeChain executes a chain, one step at a time. If any step in the chain fails to execute, or if the
 // context is canceled, the chain stops execution.
 func (se *stepExecutor) executeChain(workerID int, chain chain) {
-	for _, step := range chain {
+	// The chain of a delete-before-replace replacement begins with the deletes of the resources that must be replaced
+	// along with the resource, in reverse dependency order. These deletes only need to wait for the deletes of their
+	// dependents, so they are executed in parallel rather than one after the other.
+	start := 0
+	if deletes := leadingDeletes(chain); deletes > 1 {
+		if step, err := se.executeDeletes(workerID, chain[:deletes]); err != nil {
+			se.failChain(workerID, step, chain[deletes:], err)
+			return
+		}
+		start = deletes
+	}
+
+	for i := start; i < len(chain); i++ {
+		step := chain[i]
 		select {
 		case <-se.ctx.Done():
 			se.log(workerID, "step %v on %v canceled", step.Op(), step.URN())
@@ -248,22 +372,95 @@ func (se *stepExecutor) executeChain(workerID int, chain chain) {
 		}
 
 		if err := se.executeStep(workerID, step); err != nil {
-			se.log(workerID, "step %v on %v failed, signalling cancellation", step.Op(), step.URN())
-			se.cancelDueToError()
-			if err != errStepApplyFailed {
-				// Step application errors are recorded by the OnResourceStepPost callback. This is confusing,
-				// but it means that at this level we shouldn't be logging any errors that came from there.
-				//
-				// The errStepApplyFailed sentinel signals that the error that failed this chain was a step apply
-				// error and that we shouldn't log it. Everything else should be logged to the diag system as usual.
-				diagMsg := diag.RawMessage(step.URN(), err.Error())
-				se.deployment.Diag().Errorf(diagMsg)
-			}
+			se.failChain(workerID, step, chain[i:], err)
 			return
 		}
 	}
 }
 
+// failChain handles the failure of the given step of a chain. rest is the part of the chain that was abandoned, which
+// may include the failed step.
+func (se *stepExecutor) failChain(workerID int, step Step, rest chain, err error) {
+	var applyErr *stepApplyError
+	if errors.As(err, &applyErr) {
+		err = applyErr.err
+	} else {
+		// Step application errors are recorded by the OnResourceStepPost callback. This is confusing,
+		// but it means that at this level we shouldn't be logging any errors that came from there.
+		//
+		// The stepApplyError type signals that the error that failed this chain was a step apply
+		// error and that we shouldn't log it. Everything else should be logged to the diag system as usual.
+		diagMsg := diag.RawMessage(step.URN(), err.Error())
+		se.deployment.Diag().Errorf(diagMsg)
+	}
+
+	if !se.continueOnError {
+		se.log(workerID, "step %v on %v failed, signalling cancellation", step.Op(), step.URN())
+		se.cancelDueToError()
+		return
+	}
+
+	// The rest of the chain depends on the step that failed, so it is abandoned. If the resource's registration
+	// was not completed, tell the program that it failed so that it can carry on with everything else.
+	se.log(workerID, "step %v on %v failed, continuing", step.Op(), step.URN())
+	se.recordFailure(stepFailure{URN: step.URN(), Old: step.Old(), Err: err})
+	se.cancelDueToError()
+	if applyErr == nil || !applyErr.completed {
+		se.failRegistration(rest, err)
+	}
+}
+
+// leadingDeletes returns the number of steps at the start of the given chain that delete resources.
+func leadingDeletes(chain chain) int {
+	for i, step := range chain {
+		if op := step.Op(); op != OpDelete && op != OpDeleteReplaced {
+			return i
+		}
+	}
+	return len(chain)
+}
+
+// executeDeletes executes the given delete steps on behalf of a worker, running each one as soon as the deletes of
+// its dependents among the steps have completed. Steps that are ready are handed off to idle workers, and the worker
+// executes them itself when there are none, so the deletes never run more steps at once than the deployment's degree
+// of parallelism allows. If a step fails, no further steps are started, and the failed step and its error are returned
+// once the steps that are running have completed.
+func (se *stepExecutor) executeDeletes(workerID int, steps []Step) (Step, error) {
+	schedule := newDeleteSchedule(se.deployment.depGraph, steps, se.opts.TrustDependencies)
+
+	// The channel is large enough for every step to complete without blocking the workers that execute them.
+	completed := make(chan stepResult, len(steps))
+	inFlight := 0
+	parallel := se.opts.DegreeOfParallelism()
+
+	var failed Step
+	var failure error
+	ready := schedule.Ready()
+	for {
+		for failure == nil && se.ctx.Err() == nil && len(ready) > 0 && inFlight < parallel {
+			step := ready[0]
+			ready = ready[1:]
+			inFlight++
+			if !se.handOff(step, completed) {
+				completed <- stepResult{step: step, err: se.executeStep(workerID, step)}
+			}
+		}
+		if inFlight == 0 {
+			return failed, failure
+		}
+
+		c := <-completed
+		inFlight--
+		if c.err != nil {
+			if failure == nil {
+				failed, failure = c.step, c.err
+			}
+			continue
+		}
+		ready = append(ready, schedule.Complete(c.step)...)
+	}
+}
+
 func (se *stepExecutor) cancelDueToError() {
 	se.sawError.Store(true)
 	if !se.continueOnError {
@@ -271,6 +468,29 @@ func (se *stepExecutor) cancelDueToError() {
 	}
 }
 
+// failRegistration tells the program that the registration or read of a resource in the given steps, which are the
+// remainder of a chain that stopped executing, failed. This lets the program carry on with the rest of the deployment
+// rather than waiting forever on the resource.
+func (se *stepExecutor) failRegistration(steps chain, err error) {
+	for _, step := range steps {
+		switch step := step.(type) {
+		case *SameStep:
+			step.reg.Done(&RegisterResult{Err: err})
+		case *CreateStep:
+			step.reg.Done(&RegisterResult{Err: err})
+		case *UpdateStep:
+			step.reg.Done(&RegisterResult{Err: err})
+		case *ImportStep:
+			step.reg.Done(&RegisterResult{Err: err})
+		case *ReadStep:
+			step.event.Done(&ReadResult{Err: err})
+		default:
+			continue
+		}
+		return
+	}
+}
+
 //
 // The next few functions are responsible for executing individual steps. The basic flow of step
 // execution is
@@ -282,6 +502,80 @@ func (se *stepExecutor) cancelDueToError() {
 // verbatim to the post-step event.
 //
 
+// applyStep applies a step, retrying it with exponential backoff if the provider fails with a transient error and the
+// retry policy of the step's resource allows it. Only the provider operations that create, update and delete resources
+// are retried, and never during previews.
+func (se *stepExecutor) applyStep(workerID int, step Step) (resource.Status, StepCompleteFunc, error) {
+	policy := se.retryPolicy(step)
+	if se.preview || policy.MaxAttempts <= 1 {
+		return se.applyStepOnce(workerID, step)
+	}
+	switch step.Op() {
+	case OpCreate, OpUpdate, OpDelete, OpCreateReplacement, OpDeleteReplaced:
+	default:
+		return se.applyStepOnce(workerID, step)
+	}
+
+	delay, maxDelay := defaultRetryInitialDelay, defaultRetryMaxDelay
+	if policy.InitialDelay > 0 {
+		delay = time.Duration(policy.InitialDelay * float64(time.Second))
+	}
+	if policy.MaxDelay > 0 {
+		maxDelay = time.Duration(policy.MaxDelay * float64(time.Second))
+	}
+	backoff := retryBackoff
+
+	var status resource.Status
+	var stepComplete StepCompleteFunc
+	var err error
+	_, _, _ = retry.Until(se.ctx, retry.Acceptor{
+		Delay:    &delay,
+		Backoff:  &backoff,
+		MaxDelay: &maxDelay,
+		Accept: func(try int, nextRetryTime time.Duration) (bool, interface{}, error) {
+			status, stepComplete, err = se.applyStepOnce(workerID, step)
+			attempt := try + 1
+			if err == nil || status == resource.StatusPartialFailure || !plugin.IsRetryableError(err) ||
+				attempt >= policy.MaxAttempts {
+				return true, nil, nil
+			}
+
+			se.log(workerID, "step %v on %v failed with a transient error, retrying in %v: %v",
+				step.Op(), step.URN(), nextRetryTime, err)
+			if events := se.opts.Events; events != nil {
+				events.OnResourceStepRetry(step, attempt+1, policy.MaxAttempts, nextRetryTime, err)
+			}
+			return false, nil, nil
+		},
+	})
+
+	// If the deployment was cancelled while waiting to retry, the error from the last attempt is returned.
+	return status, stepComplete, err
+}
+
+// applyStepOnce applies a step once the limits configured for its resource's package and type allow it. Only the steps
+// on custom resources are limited, as those are the ones that call into providers.
+func (se *stepExecutor) applyStepOnce(workerID int, step Step) (resource.Status, StepCompleteFunc, error) {
+	if res := step.Res(); res != nil && res.Custom {
+		release, err := se.limiter.acquire(se.ctx, step.Type())
+		if err != nil {
+			return resource.StatusOK, nil, err
+		}
+		defer release()
+	}
+	return step.Apply(se.preview)
+}
+
+// retryPolicy returns the policy used to retry the provider operation of the given step. A resource's own policy takes
+// precedence over the deployment's. The policy of a resource that is being deleted is not known, as it isn't saved in
+// the state, so the deployment's policy is used for it.
+func (se *stepExecutor) retryPolicy(step Step) *resource.RetryPolicy {
+	if goal, ok := se.deployment.goals.get(step.URN()); ok {
+		return goal.Retry.Merge(se.opts.Retry)
+	}
+	return se.opts.Retry.Merge(nil)
+}
+
 // executeStep executes a single step, returning true if the step execution was successful and
 // false if it was not.
 func (se *stepExecutor) executeStep(workerID int, step Step) error {
@@ -297,7 +591,7 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 	}
 
 	se.log(workerID, "applying step %v on %v (preview %v)", step.Op(), step.URN(), se.preview)
-	status, stepComplete, err := step.Apply(se.preview)
+	status, stepComplete, err := se.applyStep(workerID, step)
 
 	if err == nil {
 		// If we have a state object, and this is a create or update, remember it, as we may need to update it later.
@@ -374,7 +668,7 @@ func (se *stepExecutor) executeStep(workerID int, step Step) error {
 
 	if err != nil {
 		se.log(workerID, "step %v on %v failed with an error: %v", step.Op(), step.URN(), err)
-		return errStepApplyFailed
+		return &stepApplyError{err: err, completed: stepComplete != nil}
 	}
 
 	return nil
@@ -422,8 +716,7 @@ func (se *stepExecutor) worker(workerID int, launchAsync bool) {
 
 			se.log(workerID, "worker received chain for execution")
 			if !launchAsync {
-				se.executeChain(workerID, request.Chain)
-				close(request.CompletionChan)
+				se.executeRequest(workerID, request)
 				continue
 			}
 
@@ -434,8 +727,7 @@ func (se *stepExecutor) worker(workerID int, launchAsync bool) {
 			go func() {
 				defer se.workers.Done()
 				se.log(newWorkerID, "launching oneshot worker")
-				se.executeChain(newWorkerID, request.Chain)
-				close(request.CompletionChan)
+				se.executeRequest(newWorkerID, request)
 			}()
 
 			oneshotWorkerID++
@@ -446,6 +738,18 @@ func (se *stepExecutor) worker(workerID int, launchAsync bool) {
 	}
 }
 
+// executeRequest executes a chain received by a worker, and signals its completion.
+func (se *stepExecutor) executeRequest(workerID int, request incomingChain) {
+	if request.StepResults != nil {
+		step := request.Chain[0]
+		request.StepResults <- stepResult{step: step, err: se.executeStep(workerID, step)}
+		return
+	}
+
+	se.executeChain(workerID, request.Chain)
+	close(request.CompletionChan)
+}
+
 func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment *Deployment, opts Options,
 	preview, continueOnError bool,
 ) *stepExecutor {
@@ -457,6 +761,9 @@ func newStepExecutor(ctx context.Context, cancel context.CancelFunc, deployment
 		incomingChains:  make(chan incomingChain),
 		ctx:             ctx,
 		cancel:          cancel,
+		failed:          make(map[resource.URN]bool),
+		failedOlds:      make(map[*resource.State]bool),
+		limiter:         newStepLimiter(opts.Limits),
 	}
 
 	exec.sawError.Store(false)
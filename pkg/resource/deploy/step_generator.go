@@ -41,6 +41,7 @@ type stepGenerator struct {
 
 	updateTargetsOpt  UrnTargets // the set of resources to update; resources not in this set will be same'd
 	replaceTargetsOpt UrnTargets // the set of resoures to replace
+	excludeTargetsOpt UrnTargets // the set of resources to leave untouched; resources in this set will be same'd
 
 	// signals that one or more errors have been reported to the user, and the deployment should terminate
 	// in error. This primarily allows `preview` to aggregate many policy violation events and
@@ -73,14 +74,43 @@ type stepGenerator struct {
 }
 
 func (sg *stepGenerator) isTargetedUpdate() bool {
-	return sg.updateTargetsOpt.IsConstrained() || sg.replaceTargetsOpt.IsConstrained()
+	return sg.updateTargetsOpt.IsConstrained() || sg.replaceTargetsOpt.IsConstrained() ||
+		sg.excludeTargetsOpt.IsConstrained()
+}
+
+// isExcluded returns if `res` is excluded from the update. The function accommodates `--exclude-dependents`, and
+// records excluded dependents so that their own dependents are excluded in turn.
+func (sg *stepGenerator) isExcluded(res *resource.State) bool {
+	if !sg.excludeTargetsOpt.IsConstrained() {
+		return false
+	} else if sg.excludeTargetsOpt.Contains(res.URN) {
+		return true
+	} else if !sg.opts.ExcludeDependents {
+		return false
+	}
+
+	deps := append([]resource.URN{res.Parent}, res.Dependencies...)
+	if ref := res.Provider; ref != "" {
+		res, err := providers.ParseReference(ref)
+		contract.AssertNoErrorf(err, "failed to parse provider reference: %v", ref)
+		deps = append(deps, res.URN())
+	}
+	for _, dep := range deps {
+		if dep != "" && sg.excludeTargetsOpt.Contains(dep) {
+			sg.excludeTargetsOpt.addLiteral(res.URN)
+			return true
+		}
+	}
+	return false
 }
 
 // isTargetedForUpdate returns if `res` is targeted for update. The function accommodates
 // `--target-dependents`. `targetDependentsForUpdate` should probably be called if this function
-// returns true.
+// returns true. Excluded resources are never targeted.
 func (sg *stepGenerator) isTargetedForUpdate(res *resource.State) bool {
-	if sg.updateTargetsOpt.Contains(res.URN) {
+	if sg.isExcluded(res) {
+		return false
+	} else if sg.updateTargetsOpt.Contains(res.URN) {
 		return true
 	} else if !sg.opts.TargetDependents {
 		return false
@@ -255,6 +285,33 @@ func (sg *stepGenerator) GenerateReadSteps(event ReadResourceEvent) ([]Step, res
 	}, nil
 }
 
+// checkPlannedOp checks the given step against the operations planned for its resource, consuming the next planned
+// operation. It returns an error if the step isn't allowed by the plan, unless the deployment is validating the whole
+// program against its plan, in which case the divergence is recorded instead.
+func (sg *stepGenerator) checkPlannedOp(s Step) error {
+	var err error
+	if resourcePlan, ok := sg.deployment.plan.ResourcePlans[s.URN()]; ok {
+		if len(resourcePlan.Ops) == 0 {
+			err = fmt.Errorf("%v is not allowed by the plan: no more steps were expected for this resource", s.Op())
+		} else {
+			constraint := resourcePlan.Ops[0]
+			// We remove the Op from the list before doing the constraint check.
+			// This is because we look at Ops at the end to see if any expected operations didn't attempt to happen.
+			// This op has been attempted, it just might fail its constraint.
+			resourcePlan.Ops = resourcePlan.Ops[1:]
+			if !ConstrainedTo(s.Op(), constraint) {
+				err = fmt.Errorf("%v is not allowed by the plan: this resource is constrained to %v", s.Op(), constraint)
+			}
+		}
+	} else if !ConstrainedTo(s.Op(), OpSame) {
+		err = fmt.Errorf("%v is not allowed by the plan: no steps were expected for this resource", s.Op())
+	}
+	if err != nil {
+		return sg.deployment.planViolation(s.URN(), err)
+	}
+	return nil
+}
+
 // GenerateSteps produces one or more steps required to achieve the goal state specified by the
 // incoming RegisterResourceEvent.
 //
@@ -272,22 +329,8 @@ func (sg *stepGenerator) GenerateSteps(event RegisterResourceEvent) ([]Step, res
 		logging.V(5).Infof("Checking step %s for %s", s.Op(), s.URN())
 
 		if sg.deployment.plan != nil {
-			if resourcePlan, ok := sg.deployment.plan.ResourcePlans[s.URN()]; ok {
-				if len(resourcePlan.Ops) == 0 {
-					return nil, result.Errorf("%v is not allowed by the plan: no more steps were expected for this resource", s.Op())
-				}
-				constraint := resourcePlan.Ops[0]
-				// We remove the Op from the list before doing the constraint check.
-				// This is because we look at Ops at the end to see if any expected operations didn't attempt to happen.
-				// This op has been attempted, it just might fail its constraint.
-				resourcePlan.Ops = resourcePlan.Ops[1:]
-				if !ConstrainedTo(s.Op(), constraint) {
-					return nil, result.Errorf("%v is not allowed by the plan: this resource is constrained to %v", s.Op(), constraint)
-				}
-			} else {
-				if !ConstrainedTo(s.Op(), OpSame) {
-					return nil, result.Errorf("%v is not allowed by the plan: no steps were expected for this resource", s.Op())
-				}
+			if err := sg.checkPlannedOp(s); err != nil {
+				return nil, result.FromError(err)
 			}
 		}
 
@@ -326,6 +369,9 @@ func (sg *stepGenerator) GenerateSteps(event RegisterResourceEvent) ([]Step, res
 				// in an error state so that we eventually will error out of the entire
 				// application run.
 				d := diag.GetResourceWillBeCreatedButWasNotSpecifiedInTargetList(step.URN())
+				if sg.excludeTargetsOpt.IsConstrained() && sg.excludeTargetsOpt.Contains(urn) {
+					d = diag.GetResourceWillBeCreatedButWasExcluded(step.URN())
+				}
 
 				sg.deployment.Diag().Errorf(d, step.URN(), urn)
 				sg.sawError = true
@@ -665,15 +711,19 @@ func (sg *stepGenerator) generateSteps(event RegisterResourceEvent) ([]Step, res
 	// We don't check plans if the resource is invalid, it's going to fail anyway.
 	if !invalid && sg.deployment.plan != nil {
 		resourcePlan, ok := sg.deployment.plan.ResourcePlans[urn]
+		var err error
 		if !ok {
 			if old == nil {
 				// We could error here, but we'll trigger an error later on anyway that Create isn't valid here
-			} else if err := checkMissingPlan(old, inputs, goal); err != nil {
-				return nil, result.FromError(fmt.Errorf("resource %s violates plan: %w", urn, err))
+			} else {
+				err = checkMissingPlan(old, inputs, goal)
 			}
 		} else {
-			if err := resourcePlan.checkGoal(oldInputs, inputs, goal); err != nil {
-				return nil, result.FromError(fmt.Errorf("resource %s violates plan: %w", urn, err))
+			err = resourcePlan.checkGoal(oldInputs, inputs, goal)
+		}
+		if err != nil {
+			if err := sg.deployment.planViolation(urn, fmt.Errorf("resource %s violates plan: %w", urn, err)); err != nil {
+				return nil, result.FromError(err)
 			}
 		}
 	}
@@ -1113,24 +1163,8 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt UrnTargets) ([]Step, result.
 	// Check each proposed delete against the relevant resource plan
 	for _, s := range dels {
 		if sg.deployment.plan != nil {
-			if resourcePlan, ok := sg.deployment.plan.ResourcePlans[s.URN()]; ok {
-				if len(resourcePlan.Ops) == 0 {
-					return nil, result.Errorf("%v is not allowed by the plan: no more steps were expected for this resource", s.Op())
-				}
-
-				constraint := resourcePlan.Ops[0]
-				// We remove the Op from the list before doing the constraint check.
-				// This is because we look at Ops at the end to see if any expected operations didn't attempt to happen.
-				// This op has been attempted, it just might fail its constraint.
-				resourcePlan.Ops = resourcePlan.Ops[1:]
-
-				if !ConstrainedTo(s.Op(), constraint) {
-					return nil, result.Errorf("%v is not allowed by the plan: this resource is constrained to %v", s.Op(), constraint)
-				}
-			} else {
-				if !ConstrainedTo(s.Op(), OpSame) {
-					return nil, result.Errorf("%v is not allowed by the plan: no steps were expected for this resource", s.Op())
-				}
+			if err := sg.checkPlannedOp(s); err != nil {
+				return nil, result.FromError(err)
 			}
 		}
 
@@ -1165,6 +1199,29 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt UrnTargets) ([]Step, result.
 		dels = filtered
 	}
 
+	// Excluded resources are never deleted, and neither is anything that they depend on.
+	if sg.excludeTargetsOpt.IsConstrained() {
+		excluded := excludedResources(sg.deployment.prev, sg.excludeTargetsOpt, sg.opts.ExcludeDependents)
+		dg := graph.NewDependencyGraph(sg.deployment.prev.Resources)
+		kept := make(map[resource.URN]bool)
+		for res := range excluded {
+			kept[res.URN] = true
+			for dep := range dg.TransitiveDependenciesOf(res) {
+				kept[dep.URN] = true
+			}
+		}
+
+		filtered := []Step{}
+		for _, step := range dels {
+			if kept[step.URN()] {
+				logging.V(7).Infof("Planner decided not to delete '%v' due to exclusion", step.URN())
+				continue
+			}
+			filtered = append(filtered, step)
+		}
+		dels = filtered
+	}
+
 	deletingUnspecifiedTarget := false
 	for _, step := range dels {
 		urn := step.URN()
@@ -1196,6 +1253,31 @@ func (sg *stepGenerator) GenerateDeletes(targetsOpt UrnTargets) ([]Step, result.
 	return dels, nil
 }
 
+// excludedResources returns the resources in the given snapshot that are excluded by excludeTargetsOpt, along with
+// their (transitive) dependents and children if excludeDependents is true.
+func excludedResources(
+	snap *Snapshot, excludeTargetsOpt UrnTargets, excludeDependents bool,
+) graph.ResourceSet {
+	excluded := make(graph.ResourceSet)
+	if snap == nil || !excludeTargetsOpt.IsConstrained() {
+		return excluded
+	}
+
+	dg := graph.NewDependencyGraph(snap.Resources)
+	for _, res := range snap.Resources {
+		if !excludeTargetsOpt.Contains(res.URN) || excluded[res] {
+			continue
+		}
+		excluded[res] = true
+		if excludeDependents {
+			for _, dep := range dg.DependingOn(res, nil, true) {
+				excluded[dep] = true
+			}
+		}
+	}
+	return excluded
+}
+
 // getTargetDependents returns the (transitive) set of dependents on the target resources.
 // This includes both implicit and explicit dependents in the DAG itself, as well as children.
 func (sg *stepGenerator) getTargetDependents(targetsOpt UrnTargets) map[resource.URN]bool {
@@ -1287,91 +1369,12 @@ func (sg *stepGenerator) determineAllowedResourcesToDeleteFromTargets(
 	return resourcesToDelete, nil
 }
 
-// ScheduleDeletes takes a list of steps that will delete resources and "schedules" them by producing a list of list of
-// steps, where each list can be executed in parallel but a previous list must be executed to completion before
-// advancing to the next list.
-//
-// In lieu of tracking per-step dependencies and orienting the step executor around these dependencies, this function
-// provides a conservative approximation of what deletions can safely occur in parallel. The insight here is that the
-// resource dependency graph is a partially-ordered set and all partially-ordered sets can be easily decomposed into
-// antichains - subsets of the set that are all not comparable to one another. (In this definition, "not comparable"
-// means "do not depend on one another").
-//
-// The algorithm for decomposing a poset into antichains is:
-//  1. While there exist elements in the poset,
-//     1a. There must exist at least one "maximal" element of the poset. Let E_max be those elements.
-//     2a. Remove all elements E_max from the poset. E_max is an antichain.
-//     3a. Goto 1.
-//
-// Translated to our dependency graph:
-//  1. While the set of condemned resources is not empty:
-//     1a. Remove all resources with no outgoing edges from the graph and add them to the current antichain.
-//     2a. Goto 1.
-//
-// The resulting list of antichains is a list of list of steps that can be safely executed in parallel. Since we must
-// process deletes in reverse (so we don't delete resources upon which other resources depend), we reverse the list and
-// hand it back to the deployment executor for safe execution.
-func (sg *stepGenerator) ScheduleDeletes(deleteSteps []Step) []antichain {
-	var antichains []antichain                // the list of parallelizable steps we intend to return.
-	dg := sg.deployment.depGraph              // the current deployment's dependency graph.
-	condemned := make(graph.ResourceSet)      // the set of condemned resources.
-	stepMap := make(map[*resource.State]Step) // a map from resource states to the steps that delete them.
-
-	// If we don't trust the dependency graph we've been given, we must be conservative and delete everything serially.
-	if !sg.opts.TrustDependencies {
-		logging.V(7).Infof("Planner does not trust dependency graph, scheduling deletions serially")
-		for _, step := range deleteSteps {
-			antichains = append(antichains, antichain{step})
-		}
-
-		return antichains
-	}
-
-	logging.V(7).Infof("Planner trusts dependency graph, scheduling deletions in parallel")
-
-	// For every step we've been given, record it as condemned and save the step that will be used to delete it. We'll
-	// iteratively place these steps into antichains as we remove elements from the condemned set.
-	for _, step := range deleteSteps {
-		condemned[step.Res()] = true
-		stepMap[step.Res()] = step
-	}
-
-	for len(condemned) > 0 {
-		var steps antichain
-		logging.V(7).Infof("Planner beginning schedule of new deletion antichain")
-		for res := range condemned {
-			// Does res have any outgoing edges to resources that haven't already been removed from the graph?
-			condemnedDependencies := dg.DependenciesOf(res).Intersect(condemned)
-			if len(condemnedDependencies) == 0 {
-				// If not, it's safe to delete res at this stage.
-				logging.V(7).Infof("Planner scheduling deletion of '%v'", res.URN)
-				steps = append(steps, stepMap[res])
-			}
-
-			// If one of this resource's dependencies or this resource's parent hasn't been removed from the graph yet,
-			// it can't be deleted this round.
-		}
-
-		// For all reosurces that are to be deleted in this round, remove them from the graph.
-		for _, step := range steps {
-			delete(condemned, step.Res())
-		}
-
-		antichains = append(antichains, steps)
-	}
-
-	// Up until this point, all logic has been "backwards" - we're scheduling resources for deletion when all of their
-	// dependencies finish deletion, but that's exactly the opposite of what we need to do. We can only delete a
-	// resource when all *resources that depend on it* complete deletion. Our solution is still correct, though, it's
-	// just backwards.
-	//
-	// All we have to do here is reverse the list and then our solution is correct.
-	for i := len(antichains)/2 - 1; i >= 0; i-- {
-		opp := len(antichains) - 1 - i
-		antichains[i], antichains[opp] = antichains[opp], antichains[i]
-	}
-
-	return antichains
+// ScheduleDeletes takes a list of steps that will delete resources and "schedules" them, so that each step is executed
+// only once the steps that delete the resources that depend on its resource have completed. Steps that don't depend on
+// one another are executed in parallel, and each step is released as soon as it can be, rather than waiting for a whole
+// "level" of deletes to complete. If the dependency graph can't be trusted, the steps are executed serially.
+func (sg *stepGenerator) ScheduleDeletes(deleteSteps []Step) *deleteSchedule {
+	return newDeleteSchedule(sg.deployment.depGraph, deleteSteps, sg.opts.TrustDependencies)
 }
 
 // providerChanged diffs the Provider field of old and new resources, returning true if the rest of the step generator
@@ -1928,6 +1931,7 @@ func newStepGenerator(
 		opts:                 opts,
 		updateTargetsOpt:     updateTargetsOpt,
 		replaceTargetsOpt:    replaceTargetsOpt,
+		excludeTargetsOpt:    opts.ExcludeTargets,
 		urns:                 make(map[resource.URN]bool),
 		reads:                make(map[resource.URN]bool),
 		creates:              make(map[resource.URN]bool),
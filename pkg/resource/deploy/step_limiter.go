@@ -0,0 +1,92 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// stepLimit enforces the limits configured for the resources of a provider package or of a resource type.
+type stepLimit struct {
+	slots   chan struct{} // a semaphore with a slot per concurrent step, or nil if concurrency is unlimited.
+	limiter *rate.Limiter // the limiter for the rate at which steps start, or nil if the rate is unlimited.
+}
+
+// stepLimiter caps the concurrency and rate of the steps that the step executor applies, per provider package and per
+// resource type. The limits of a resource type apply in addition to those of its package.
+type stepLimiter struct {
+	limits map[string]*stepLimit // the limits, keyed by package name or resource type token.
+}
+
+func newStepLimiter(limits map[string]workspace.ResourceLimits) *stepLimiter {
+	l := &stepLimiter{limits: make(map[string]*stepLimit)}
+	for key, limits := range limits {
+		var limit stepLimit
+		if limits.Parallel > 0 {
+			limit.slots = make(chan struct{}, limits.Parallel)
+		}
+		if limits.Rate > 0 {
+			limit.limiter = rate.NewLimiter(rate.Limit(limits.Rate), 1)
+		}
+		if limit.slots != nil || limit.limiter != nil {
+			l.limits[key] = &limit
+		}
+	}
+	return l
+}
+
+// acquire blocks until a step on a resource of the given type may be applied, and returns a function that must be
+// called once the step has been applied. It returns an error if the context is canceled while waiting.
+func (l *stepLimiter) acquire(ctx context.Context, typ tokens.Type) (func(), error) {
+	if len(l.limits) == 0 {
+		return func() {}, nil
+	}
+
+	// The limits are always acquired in the same order, type then package, so that steps can't deadlock.
+	var acquired []*stepLimit
+	release := func() {
+		for _, limit := range acquired {
+			<-limit.slots
+		}
+	}
+	for _, key := range []string{string(typ), string(typ.Package())} {
+		limit, ok := l.limits[key]
+		if !ok {
+			continue
+		}
+
+		if limit.slots != nil {
+			select {
+			case limit.slots <- struct{}{}:
+				acquired = append(acquired, limit)
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			}
+		}
+		if limit.limiter != nil {
+			if err := limit.limiter.Wait(ctx); err != nil {
+				release()
+				return nil, err
+			}
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deploy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+func TestStepLimiterParallel(t *testing.T) {
+	t.Parallel()
+
+	l := newStepLimiter(map[string]workspace.ResourceLimits{
+		"pkgA":             {Parallel: 2},
+		"pkgB:index:Thing": {Parallel: 1},
+	})
+	ctx := context.Background()
+
+	// Two steps on pkgA may run at once, but not a third.
+	releaseA1, err := l.acquire(ctx, "pkgA:index:Thing")
+	require.NoError(t, err)
+	releaseA2, err := l.acquire(ctx, "pkgA:index:Other")
+	require.NoError(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(timeoutCtx, "pkgA:index:Thing")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Other packages are unaffected, and a type's limit only applies to that type.
+	releaseB1, err := l.acquire(ctx, "pkgB:index:Thing")
+	require.NoError(t, err)
+	releaseB2, err := l.acquire(ctx, "pkgB:index:Other")
+	require.NoError(t, err)
+	releaseC, err := l.acquire(ctx, "pkgC:index:Thing")
+	require.NoError(t, err)
+
+	// Releasing a step lets the next one through.
+	releaseA1()
+	releaseA3, err := l.acquire(ctx, "pkgA:index:Thing")
+	require.NoError(t, err)
+
+	for _, release := range []func(){releaseA2, releaseA3, releaseB1, releaseB2, releaseC} {
+		release()
+	}
+}
+
+func TestStepLimiterRate(t *testing.T) {
+	t.Parallel()
+
+	l := newStepLimiter(map[string]workspace.ResourceLimits{
+		"pkgA": {Rate: 20},
+	})
+
+	// At 20 steps per second, four steps take at least 150ms to start.
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := l.acquire(context.Background(), "pkgA:index:Thing")
+		require.NoError(t, err)
+		release()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 140*time.Millisecond)
+}
@@ -0,0 +1,261 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+)
+
+// ResourceDiffKind describes how a resource differs between two snapshots.
+type ResourceDiffKind string
+
+const (
+	// ResourceAdded indicates that a resource only exists in the new snapshot.
+	ResourceAdded ResourceDiffKind = "added"
+	// ResourceRemoved indicates that a resource only exists in the old snapshot.
+	ResourceRemoved ResourceDiffKind = "removed"
+	// ResourceChanged indicates that a resource exists in both snapshots, but its recorded state differs.
+	ResourceChanged ResourceDiffKind = "changed"
+)
+
+// ResourceDiff describes the difference between the recorded state of a single resource in two snapshots.
+type ResourceDiff struct {
+	// Kind is the kind of difference.
+	Kind ResourceDiffKind
+	// URN is the URN of the resource.
+	URN resource.URN
+	// Old is the state of the resource in the old snapshot, or nil if it was added.
+	Old *resource.State
+	// New is the state of the resource in the new snapshot, or nil if it was removed.
+	New *resource.State
+	// Inputs is the difference between the resource's inputs, or nil if they are the same.
+	Inputs *resource.ObjectDiff
+	// Outputs is the difference between the resource's outputs, or nil if they are the same.
+	Outputs *resource.ObjectDiff
+	// Fields lists the names of the other fields of the resource state that differ, e.g. "ID" or "Protect".
+	Fields []string
+}
+
+// diffKey identifies the resources within a snapshot that can be matched with one another. A URN alone is not enough,
+// as a resource that is pending deletion may share its URN with its replacement. Several resources that are pending
+// deletion may also share a URN, e.g. after repeated failed replacements; those are matched in snapshot order.
+type diffKey struct {
+	urn    resource.URN
+	delete bool
+}
+
+// DiffOptions controls how DiffSnapshotsWithOptions matches the resources of two snapshots.
+type DiffOptions struct {
+	// IgnoreStack matches resources regardless of the stack and project recorded in their URNs, so that the
+	// snapshots of two different stacks can be compared. References to other resources in the old snapshot are
+	// rewritten to the stack and project of the new snapshot before the resources are compared.
+	IgnoreStack bool
+	// FollowAliases matches a resource in the new snapshot that has no counterpart with the same URN to a resource in
+	// the old snapshot whose URN is one of its aliases.
+	FollowAliases bool
+}
+
+// DiffSnapshots compares the resources in two snapshots and returns the differences between them. Resources are matched
+// by URN. Added and changed resources are returned in the order of the new snapshot, followed by removed resources in
+// the order of the old snapshot. Either snapshot may be nil, in which case it is treated as empty.
+func DiffSnapshots(old, new *deploy.Snapshot) []ResourceDiff {
+	return DiffSnapshotsWithOptions(old, new, DiffOptions{})
+}
+
+// DiffSnapshotsWithOptions compares the resources in two snapshots as DiffSnapshots does, matching resources as
+// described by the given options.
+func DiffSnapshotsWithOptions(old, new *deploy.Snapshot, opts DiffOptions) []ResourceDiff {
+	var oldResources, newResources []*resource.State
+	if old != nil {
+		oldResources = old.Resources
+	}
+	if new != nil {
+		newResources = new.Resources
+	}
+
+	if opts.IgnoreStack && len(newResources) != 0 {
+		stack, project := newResources[0].URN.Stack(), newResources[0].URN.Project()
+		relocated := make([]*resource.State, len(oldResources))
+		for i, res := range oldResources {
+			relocated[i] = relocateResource(res, stack, project)
+		}
+		oldResources = relocated
+	}
+
+	olds := make(map[diffKey][]*resource.State, len(oldResources))
+	for _, res := range oldResources {
+		key := diffKey{res.URN, res.Delete}
+		olds[key] = append(olds[key], res)
+	}
+
+	// match matches a resource in the new snapshot to the first resource in the old snapshot with the given key that
+	// hasn't been matched yet.
+	matches := make(map[*resource.State]*resource.State, len(newResources))
+	matched := make(map[*resource.State]bool, len(newResources))
+	match := func(res *resource.State, key diffKey) bool {
+		for _, oldRes := range olds[key] {
+			if !matched[oldRes] {
+				matches[res] = oldRes
+				matched[oldRes] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	// Match resources by URN first, so that an alias never claims a resource that still exists under its own URN.
+	for _, res := range newResources {
+		match(res, diffKey{res.URN, res.Delete})
+	}
+	if opts.FollowAliases {
+		for _, res := range newResources {
+			if _, ok := matches[res]; ok {
+				continue
+			}
+			for _, alias := range res.Aliases {
+				if match(res, diffKey{alias, res.Delete}) {
+					break
+				}
+			}
+		}
+	}
+
+	var diffs []ResourceDiff
+	for _, res := range newResources {
+		oldRes, ok := matches[res]
+		if !ok {
+			diffs = append(diffs, ResourceDiff{Kind: ResourceAdded, URN: res.URN, New: res})
+			continue
+		}
+		if diff, changed := DiffResource(oldRes, res); changed {
+			diffs = append(diffs, diff)
+		}
+	}
+	for _, res := range oldResources {
+		if !matched[res] {
+			diffs = append(diffs, ResourceDiff{Kind: ResourceRemoved, URN: res.URN, Old: res})
+		}
+	}
+	return diffs
+}
+
+// relocateResource returns a copy of the given resource with its URN and its references to other resources rewritten
+// to the given stack and project.
+func relocateResource(res *resource.State, stack tokens.QName, project tokens.PackageName) *resource.State {
+	relocate := func(urn resource.URN) resource.URN {
+		if urn == "" || !urn.IsValid() {
+			return urn
+		}
+		qualifiedType := urn.QualifiedType()
+		parentType, baseType := tokens.Type(""), qualifiedType
+		if i := strings.LastIndex(string(qualifiedType), "$"); i != -1 {
+			parentType, baseType = qualifiedType[:i], qualifiedType[i+1:]
+		}
+		return resource.NewURN(stack, project, parentType, baseType, urn.Name())
+	}
+	relocateAll := func(urns []resource.URN) []resource.URN {
+		if urns == nil {
+			return nil
+		}
+		result := make([]resource.URN, len(urns))
+		for i, urn := range urns {
+			result[i] = relocate(urn)
+		}
+		return result
+	}
+
+	copied := *res
+	copied.URN = relocate(res.URN)
+	copied.Parent = relocate(res.Parent)
+	copied.DeletedWith = relocate(res.DeletedWith)
+	copied.Dependencies = relocateAll(res.Dependencies)
+	copied.Aliases = relocateAll(res.Aliases)
+	if res.PropertyDependencies != nil {
+		copied.PropertyDependencies = make(map[resource.PropertyKey][]resource.URN, len(res.PropertyDependencies))
+		for k, deps := range res.PropertyDependencies {
+			copied.PropertyDependencies[k] = relocateAll(deps)
+		}
+	}
+	if res.Provider != "" {
+		if ref, err := providers.ParseReference(res.Provider); err == nil {
+			if relocated, err := providers.NewReference(relocate(ref.URN()), ref.ID()); err == nil {
+				copied.Provider = relocated.String()
+			}
+		}
+	}
+	return &copied
+}
+
+// DiffResource compares two recorded states of the same resource. It returns the difference and true if they differ.
+func DiffResource(old, new *resource.State) (ResourceDiff, bool) {
+	diff := ResourceDiff{
+		Kind:    ResourceChanged,
+		URN:     new.URN,
+		Old:     old,
+		New:     new,
+		Inputs:  old.Inputs.Diff(new.Inputs),
+		Outputs: old.Outputs.Diff(new.Outputs),
+	}
+
+	field := func(name string, o, n interface{}) {
+		if !reflect.DeepEqual(o, n) {
+			diff.Fields = append(diff.Fields, name)
+		}
+	}
+	field("URN", old.URN, new.URN)
+	field("Type", old.Type, new.Type)
+	field("ID", old.ID, new.ID)
+	field("Custom", old.Custom, new.Custom)
+	field("Delete", old.Delete, new.Delete)
+	field("Parent", old.Parent, new.Parent)
+	field("Provider", old.Provider, new.Provider)
+	field("Protect", old.Protect, new.Protect)
+	field("External", old.External, new.External)
+	field("RetainOnDelete", old.RetainOnDelete, new.RetainOnDelete)
+	field("DeletedWith", old.DeletedWith, new.DeletedWith)
+	field("PendingReplacement", old.PendingReplacement, new.PendingReplacement)
+	field("ImportID", old.ImportID, new.ImportID)
+	field("CustomTimeouts", old.CustomTimeouts, new.CustomTimeouts)
+	field("Dependencies", nonEmpty(old.Dependencies), nonEmpty(new.Dependencies))
+	field("PropertyDependencies", nonEmptyMap(old.PropertyDependencies), nonEmptyMap(new.PropertyDependencies))
+	field("InitErrors", nonEmpty(old.InitErrors), nonEmpty(new.InitErrors))
+	field("AdditionalSecretOutputs", nonEmpty(old.AdditionalSecretOutputs), nonEmpty(new.AdditionalSecretOutputs))
+	field("Aliases", nonEmpty(old.Aliases), nonEmpty(new.Aliases))
+
+	changed := diff.Inputs.AnyChanges() || diff.Outputs.AnyChanges() || len(diff.Fields) != 0
+	return diff, changed
+}
+
+// nonEmpty normalizes empty slices to nil so that they compare equal regardless of how they were deserialized.
+func nonEmpty[T any](s []T) []T {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
+
+// nonEmptyMap normalizes empty maps to nil so that they compare equal regardless of how they were deserialized.
+func nonEmptyMap[K comparable, V any](m map[K]V) map[K]V {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
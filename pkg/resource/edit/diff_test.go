@@ -0,0 +1,163 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	old := NewSnapshot([]*resource.State{pA, a, b})
+
+	a2 := NewResource("a", pA)
+	a2.Protect = true
+	a2.Outputs = resource.PropertyMap{"foo": resource.NewStringProperty("bar")}
+	c := NewResource("c", pA)
+	new := NewSnapshot([]*resource.State{pA, a2, c})
+
+	diffs := DiffSnapshots(old, new)
+	require.Len(t, diffs, 3)
+
+	assert.Equal(t, ResourceChanged, diffs[0].Kind)
+	assert.Equal(t, a.URN, diffs[0].URN)
+	assert.Equal(t, []string{"Protect"}, diffs[0].Fields)
+	assert.Nil(t, diffs[0].Inputs)
+	require.NotNil(t, diffs[0].Outputs)
+	assert.True(t, diffs[0].Outputs.Added("foo"))
+
+	assert.Equal(t, ResourceAdded, diffs[1].Kind)
+	assert.Equal(t, c.URN, diffs[1].URN)
+	assert.Same(t, c, diffs[1].New)
+
+	assert.Equal(t, ResourceRemoved, diffs[2].Kind)
+	assert.Equal(t, b.URN, diffs[2].URN)
+	assert.Same(t, b, diffs[2].Old)
+}
+
+func TestDiffSnapshotsNoChanges(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	a.Dependencies = []resource.URN{}
+
+	a2 := NewResource("a", pA)
+	assert.Empty(t, DiffSnapshots(NewSnapshot([]*resource.State{pA, a}), NewSnapshot([]*resource.State{pA, a2})))
+	assert.Len(t, DiffSnapshots(nil, NewSnapshot([]*resource.State{pA, a2})), 2)
+}
+
+func TestDiffSnapshotsFollowAliases(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	old := NewSnapshot([]*resource.State{pA, a, b})
+
+	renamed := NewResource("renamed", pA)
+	renamed.Aliases = []resource.URN{a.URN, b.URN}
+	b2 := NewResource("b", pA)
+	new := NewSnapshot([]*resource.State{pA, renamed, b2})
+
+	// Without following aliases, the rename looks like a replacement.
+	diffs := DiffSnapshots(old, new)
+	require.Len(t, diffs, 2)
+	assert.Equal(t, ResourceAdded, diffs[0].Kind)
+	assert.Equal(t, ResourceRemoved, diffs[1].Kind)
+
+	// b still exists under its own URN, so only a is matched by the alias.
+	diffs = DiffSnapshotsWithOptions(old, new, DiffOptions{FollowAliases: true})
+	require.Len(t, diffs, 1)
+	assert.Equal(t, ResourceChanged, diffs[0].Kind)
+	assert.Equal(t, renamed.URN, diffs[0].URN)
+	assert.Same(t, a, diffs[0].Old)
+	assert.Equal(t, []string{"URN", "Aliases"}, diffs[0].Fields)
+}
+
+func TestDiffSnapshotsPendingDeletes(t *testing.T) {
+	t.Parallel()
+
+	// Two failed replacements of a left two copies of it pending deletion, which share its URN.
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	d1, d2 := NewResource("a", pA), NewResource("a", pA)
+	d1.ID, d1.Delete = "d1", true
+	d2.ID, d2.Delete = "d2", true
+	old := NewSnapshot([]*resource.State{pA, d1, d2, a})
+
+	// Both copies are reported once they have been deleted.
+	diffs := DiffSnapshots(old, NewSnapshot([]*resource.State{pA, a}))
+	require.Len(t, diffs, 2)
+	assert.Equal(t, ResourceRemoved, diffs[0].Kind)
+	assert.Same(t, d1, diffs[0].Old)
+	assert.Equal(t, ResourceRemoved, diffs[1].Kind)
+	assert.Same(t, d2, diffs[1].Old)
+
+	// Deleting only the second copy reports its removal.
+	d1b := NewResource("a", pA)
+	d1b.ID, d1b.Delete = "d1", true
+	diffs = DiffSnapshots(old, NewSnapshot([]*resource.State{pA, d1b, a}))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, ResourceRemoved, diffs[0].Kind)
+	assert.Same(t, d2, diffs[0].Old)
+
+	// The copies are matched in order, so a change to the second one is reported against it.
+	d2b := NewResource("a", pA)
+	d2b.ID, d2b.Delete, d2b.Protect = "d2", true, true
+	diffs = DiffSnapshots(old, NewSnapshot([]*resource.State{pA, d1b, d2b, a}))
+	require.Len(t, diffs, 1)
+	assert.Equal(t, ResourceChanged, diffs[0].Kind)
+	assert.Same(t, d2, diffs[0].Old)
+	assert.Equal(t, []string{"Protect"}, diffs[0].Fields)
+}
+
+func TestDiffSnapshotsIgnoreStack(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA, pA.URN)
+	a.Outputs = resource.PropertyMap{"size": resource.NewNumberProperty(1)}
+	staging := NewSnapshot([]*resource.State{pA, a})
+
+	prodProvider := NewProviderResource("a", "p1", "0")
+	prodProvider.URN = resource.NewURN("prod", "other", "", prodProvider.Type, "p1")
+	prodA := NewResource("a", prodProvider, prodProvider.URN)
+	prodA.URN = resource.NewURN("prod", "other", "", prodA.Type, "a")
+	prodA.Outputs = resource.PropertyMap{"size": resource.NewNumberProperty(2)}
+	prod := NewSnapshot([]*resource.State{prodProvider, prodA})
+
+	assert.Len(t, DiffSnapshots(staging, prod), 4)
+
+	diffs := DiffSnapshotsWithOptions(staging, prod, DiffOptions{IgnoreStack: true})
+	require.Len(t, diffs, 1)
+	assert.Equal(t, ResourceChanged, diffs[0].Kind)
+	assert.Equal(t, prodA.URN, diffs[0].URN)
+	assert.Empty(t, diffs[0].Fields)
+	assert.True(t, diffs[0].Outputs.Updated("size"))
+
+	// The original resources are left untouched.
+	assert.Equal(t, tokens.QName("test"), a.URN.Stack())
+}
@@ -0,0 +1,351 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
+)
+
+// MoveOptions controls which resources MoveResources carries along with the explicitly requested ones.
+type MoveOptions struct {
+	// IncludeChildren moves every resource that is (transitively) parented to a requested resource.
+	IncludeChildren bool
+	// IncludeDependencies moves every resource that a moved resource (transitively) depends on.
+	IncludeDependencies bool
+}
+
+// MoveResult describes the outcome of a successful call to MoveResources.
+type MoveResult struct {
+	// Moved maps the original URN of every resource removed from the source snapshot to its URN in the destination.
+	Moved map[resource.URN]resource.URN
+	// CopiedProviders lists the providers that were copied into the destination because resources that remain in the
+	// source snapshot still refer to them.
+	CopiedProviders []resource.URN
+}
+
+// MoveResources moves the resources with the given URNs from the source snapshot into the destination snapshot. The
+// URNs of moved resources are rewritten to belong to the given destination stack and project, and every provider that
+// a moved resource refers to is carried along: providers that are no longer used in the source are moved, while
+// providers that are still in use are copied.
+//
+// Moving is refused if resources that stay behind in the source would be left referring to moved resources, or if a
+// moved resource depends on a resource that is neither moved nor already present in the destination. Both snapshots
+// are left untouched if an error is returned.
+func MoveResources(source, dest *deploy.Snapshot, urns []resource.URN,
+	destStack tokens.QName, destProject tokens.PackageName, opts MoveOptions,
+) (*MoveResult, error) {
+	contract.Requiref(source != nil, "source", "must not be nil")
+	contract.Requiref(dest != nil, "dest", "must not be nil")
+
+	if len(source.PendingOperations) != 0 {
+		return nil, fmt.Errorf("the source stack has pending operations; resolve them before moving resources")
+	}
+
+	byURN := make(map[resource.URN]*resource.State, len(source.Resources))
+	for _, res := range source.Resources {
+		if res.Delete {
+			continue
+		}
+		byURN[res.URN] = res
+	}
+
+	// Start with the requested resources and grow the set until it is closed under the requested relationships.
+	moving := map[resource.URN]bool{}
+	var worklist []*resource.State
+	add := func(res *resource.State) {
+		if !moving[res.URN] {
+			moving[res.URN] = true
+			worklist = append(worklist, res)
+		}
+	}
+	for _, urn := range urns {
+		res, ok := byURN[urn]
+		if !ok {
+			return nil, fmt.Errorf("no such resource %q exists in the source stack", urn)
+		}
+		if res.Type == resource.RootStackType {
+			return nil, fmt.Errorf("the root stack resource %q cannot be moved", urn)
+		}
+		if providers.IsProviderType(res.Type) {
+			return nil, fmt.Errorf("provider %q cannot be moved directly; it is moved along with its resources", urn)
+		}
+		add(res)
+	}
+	for len(worklist) > 0 {
+		res := worklist[0]
+		worklist = worklist[1:]
+
+		if opts.IncludeChildren {
+			for _, other := range source.Resources {
+				if other.Parent == res.URN && !other.Delete {
+					add(other)
+				}
+			}
+		}
+		if opts.IncludeDependencies {
+			for _, dep := range allDependencies(res) {
+				if depRes, ok := byURN[dep]; ok && depRes.Type != resource.RootStackType &&
+					!providers.IsProviderType(depRes.Type) {
+					add(depRes)
+				}
+			}
+		}
+	}
+
+	for _, res := range source.Resources {
+		if res.Delete && moving[res.URN] {
+			return nil, fmt.Errorf("resource %s has a pending deletion; run an update before moving it", res.URN)
+		}
+	}
+
+	// Work out which providers travel with the moved resources, and whether they can be removed from the source.
+	movedProviders := map[resource.URN]bool{}
+	for _, res := range source.Resources {
+		if !moving[res.URN] || res.Provider == "" {
+			continue
+		}
+		ref, err := providers.ParseReference(res.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provider reference for resource %s: %w", res.URN, err)
+		}
+		movedProviders[ref.URN()] = true
+	}
+	copiedProviders := map[resource.URN]bool{}
+	for _, res := range source.Resources {
+		if moving[res.URN] || movedProviders[res.URN] || res.Provider == "" {
+			continue
+		}
+		ref, err := providers.ParseReference(res.Provider)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse provider reference for resource %s: %w", res.URN, err)
+		}
+		if movedProviders[ref.URN()] {
+			copiedProviders[ref.URN()] = true
+		}
+	}
+
+	// Resources that stay behind must not refer to anything that leaves the source.
+	for _, res := range source.Resources {
+		if moving[res.URN] || movedProviders[res.URN] {
+			continue
+		}
+		if moving[res.Parent] {
+			return nil, fmt.Errorf("resource %s is a child of %s, which is being moved; "+
+				"move its children as well", res.URN, res.Parent)
+		}
+		for _, dep := range allDependencies(res) {
+			if moving[dep] {
+				return nil, fmt.Errorf("resource %s depends on %s, which is being moved", res.URN, dep)
+			}
+		}
+	}
+
+	// Find the root stack resources on either side. Resources parented to the source's root stack are reparented to
+	// the destination's root stack, which is created if the destination does not have one yet.
+	var sourceRoot, destRoot *resource.State
+	for _, res := range source.Resources {
+		if res.Type == resource.RootStackType {
+			sourceRoot = res
+		}
+	}
+	for _, res := range dest.Resources {
+		if res.Type == resource.RootStackType {
+			destRoot = res
+		}
+	}
+	var newDestRoot *resource.State
+	if destRoot == nil && sourceRoot != nil {
+		name := tokens.QName(string(destProject) + "-" + string(destStack))
+		newDestRoot = &resource.State{
+			Type:    resource.RootStackType,
+			URN:     resource.NewURN(destStack, destProject, "", resource.RootStackType, name),
+			Custom:  false,
+			Inputs:  resource.PropertyMap{},
+			Outputs: resource.PropertyMap{},
+		}
+		destRoot = newDestRoot
+	}
+
+	// Compute the new URN for every moved resource and provider. The source snapshot is topologically sorted, so the
+	// parent of a resource is always renamed before the resource itself.
+	renames := map[resource.URN]resource.URN{}
+	for _, res := range source.Resources {
+		if !moving[res.URN] && !movedProviders[res.URN] {
+			continue
+		}
+
+		var parentType tokens.Type
+		if newParent, ok := renames[res.Parent]; ok {
+			if newParent.Type() != resource.RootStackType {
+				parentType = newParent.QualifiedType()
+			}
+		} else if res.Parent != "" && (sourceRoot == nil || res.Parent != sourceRoot.URN) {
+			return nil, fmt.Errorf("resource %s is a child of %s, which is not being moved; "+
+				"move its parent as well", res.URN, res.Parent)
+		}
+		renames[res.URN] = resource.NewURN(destStack, destProject, parentType, res.Type, res.URN.Name())
+	}
+
+	destURNs := map[resource.URN]*resource.State{}
+	for _, res := range dest.Resources {
+		if !res.Delete {
+			destURNs[res.URN] = res
+		}
+	}
+	reusedProviders := map[resource.URN]bool{}
+	for oldURN, newURN := range renames {
+		existing, ok := destURNs[newURN]
+		if !ok {
+			continue
+		}
+		if movedProviders[oldURN] && existing.ID == byURN[oldURN].ID {
+			// The destination already has this exact provider, so the moved resources can simply share it.
+			reusedProviders[oldURN] = true
+			continue
+		}
+		return nil, fmt.Errorf("a resource with URN %s already exists in the destination stack", newURN)
+	}
+
+	// rewriteURN maps a URN in the source to the URN of the same resource in the destination: either a moved
+	// resource, or one that is already present in the destination under the same name.
+	rewriteURN := func(urn resource.URN) (resource.URN, bool) {
+		if newURN, ok := renames[urn]; ok {
+			return newURN, true
+		}
+		newURN := resource.NewURN(destStack, destProject, "", urn.QualifiedType(), urn.Name())
+		_, ok := destURNs[newURN]
+		return newURN, ok
+	}
+	rewriteDeps := func(res *resource.State, deps []resource.URN) ([]resource.URN, error) {
+		if deps == nil {
+			return nil, nil
+		}
+		newDeps := make([]resource.URN, 0, len(deps))
+		for _, dep := range deps {
+			newDep, ok := rewriteURN(dep)
+			if !ok {
+				return nil, fmt.Errorf("resource %s depends on %s, which is not being moved and is not present "+
+					"in the destination stack; move its dependencies as well", res.URN, dep)
+			}
+			newDeps = append(newDeps, newDep)
+		}
+		return newDeps, nil
+	}
+
+	// Build the rewritten copies of all moved resources before touching either snapshot.
+	var newResources []*resource.State
+	for _, res := range source.Resources {
+		if !moving[res.URN] && !movedProviders[res.URN] {
+			continue
+		}
+		if reusedProviders[res.URN] {
+			continue
+		}
+
+		copied := *res
+		moved := &copied
+		moved.URN = renames[res.URN]
+		// Aliases refer to names in the source stack, which are meaningless in the destination.
+		moved.Aliases = nil
+		if newParent, ok := renames[res.Parent]; ok {
+			moved.Parent = newParent
+		} else if res.Parent != "" {
+			moved.Parent = destRoot.URN
+		}
+
+		deps, err := rewriteDeps(res, res.Dependencies)
+		if err != nil {
+			return nil, err
+		}
+		moved.Dependencies = deps
+
+		if res.PropertyDependencies != nil {
+			moved.PropertyDependencies = make(map[resource.PropertyKey][]resource.URN, len(res.PropertyDependencies))
+			for key, propDeps := range res.PropertyDependencies {
+				newPropDeps, err := rewriteDeps(res, propDeps)
+				if err != nil {
+					return nil, err
+				}
+				moved.PropertyDependencies[key] = newPropDeps
+			}
+		}
+
+		if res.DeletedWith != "" {
+			newDeletedWith, ok := rewriteURN(res.DeletedWith)
+			if !ok {
+				return nil, fmt.Errorf("resource %s is deleted with %s, which is not being moved "+
+					"and is not present in the destination stack",
+					res.URN, res.DeletedWith)
+			}
+			moved.DeletedWith = newDeletedWith
+		}
+
+		if res.Provider != "" {
+			ref, err := providers.ParseReference(res.Provider)
+			contract.AssertNoErrorf(err, "provider reference was validated above")
+			newRef, err := providers.NewReference(renames[ref.URN()], ref.ID())
+			if err != nil {
+				return nil, err
+			}
+			moved.Provider = newRef.String()
+		}
+
+		newResources = append(newResources, moved)
+	}
+
+	// Everything checks out, so commit the changes to both snapshots.
+	result := &MoveResult{Moved: map[resource.URN]resource.URN{}}
+	remaining := make([]*resource.State, 0, len(source.Resources))
+	for _, res := range source.Resources {
+		switch {
+		case moving[res.URN]:
+			result.Moved[res.URN] = renames[res.URN]
+		case movedProviders[res.URN] && copiedProviders[res.URN]:
+			result.CopiedProviders = append(result.CopiedProviders, res.URN)
+			remaining = append(remaining, res)
+		case movedProviders[res.URN]:
+			result.Moved[res.URN] = renames[res.URN]
+		default:
+			remaining = append(remaining, res)
+		}
+	}
+	source.Resources = remaining
+
+	if newDestRoot != nil {
+		dest.Resources = append(dest.Resources, newDestRoot)
+	}
+	dest.Resources = append(dest.Resources, newResources...)
+
+	return result, nil
+}
+
+// allDependencies returns every URN that the given resource depends on, whether through its dependency list, its
+// property dependencies, or its deletedWith relationship.
+func allDependencies(res *resource.State) []resource.URN {
+	deps := append([]resource.URN{}, res.Dependencies...)
+	for _, propDeps := range res.PropertyDependencies {
+		deps = append(deps, propDeps...)
+	}
+	if res.DeletedWith != "" {
+		deps = append(deps, res.DeletedWith)
+	}
+	return deps
+}
@@ -0,0 +1,190 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveResourceCopiesSharedProvider(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA)
+	source := NewSnapshot([]*resource.State{pA, a, b})
+	dest := NewSnapshot(nil)
+
+	result, err := MoveResources(source, dest, []resource.URN{a.URN}, "dest", "proj", MoveOptions{})
+	require.NoError(t, err)
+
+	newURN := resource.NewURN("dest", "proj", "", a.Type, "a")
+	newProvURN := resource.NewURN("dest", "proj", "", pA.Type, "p1")
+	assert.Equal(t, map[resource.URN]resource.URN{a.URN: newURN}, result.Moved)
+	assert.Equal(t, []resource.URN{pA.URN}, result.CopiedProviders)
+
+	// The provider is still used by b, so it stays in the source.
+	assert.Equal(t, []*resource.State{pA, b}, source.Resources)
+
+	require.Len(t, dest.Resources, 2)
+	assert.Equal(t, newProvURN, dest.Resources[0].URN)
+	assert.Equal(t, newURN, dest.Resources[1].URN)
+	ref, err := providers.NewReference(newProvURN, "0")
+	require.NoError(t, err)
+	assert.Equal(t, ref.String(), dest.Resources[1].Provider)
+
+	assert.NoError(t, source.VerifyIntegrity())
+	assert.NoError(t, dest.VerifyIntegrity())
+}
+
+func TestMoveResourceWithDependencies(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	b := NewResource("b", pA, a.URN)
+	c := NewResource("c", pA)
+
+	t.Run("without-dependencies", func(t *testing.T) {
+		t.Parallel()
+
+		source := NewSnapshot([]*resource.State{pA, a, b, c})
+		dest := NewSnapshot(nil)
+		_, err := MoveResources(source, dest, []resource.URN{b.URN}, "dest", "test", MoveOptions{})
+		assert.ErrorContains(t, err, "move its dependencies as well")
+		assert.Len(t, source.Resources, 4)
+		assert.Len(t, dest.Resources, 0)
+	})
+
+	t.Run("with-dependencies", func(t *testing.T) {
+		t.Parallel()
+
+		source := NewSnapshot([]*resource.State{pA, a, b, c})
+		dest := NewSnapshot(nil)
+		result, err := MoveResources(source, dest, []resource.URN{b.URN}, "dest", "test", MoveOptions{
+			IncludeDependencies: true,
+		})
+		require.NoError(t, err)
+		assert.Len(t, result.Moved, 2)
+		assert.Equal(t, []*resource.State{pA, c}, source.Resources)
+
+		require.Len(t, dest.Resources, 3)
+		newA := resource.NewURN("dest", "test", "", a.Type, "a")
+		assert.Equal(t, []resource.URN{newA}, dest.Resources[2].Dependencies)
+		assert.NoError(t, dest.VerifyIntegrity())
+	})
+
+	t.Run("dependencies-in-destination", func(t *testing.T) {
+		t.Parallel()
+
+		// The destination already has its own copy of a, so b can refer to it there without moving a.
+		destA := NewResource("a", nil)
+		destA.URN = resource.NewURN("dest", "test", "", a.Type, "a")
+		source := NewSnapshot([]*resource.State{pA, a, b, c})
+		dest := NewSnapshot([]*resource.State{destA})
+		result, err := MoveResources(source, dest, []resource.URN{b.URN}, "dest", "test", MoveOptions{})
+		require.NoError(t, err)
+		assert.Len(t, result.Moved, 1)
+		assert.Equal(t, []resource.URN{pA.URN}, result.CopiedProviders)
+		assert.Equal(t, []*resource.State{pA, a, c}, source.Resources)
+
+		require.Len(t, dest.Resources, 3)
+		assert.Equal(t, []resource.URN{destA.URN}, dest.Resources[2].Dependencies)
+		assert.NoError(t, dest.VerifyIntegrity())
+	})
+
+	t.Run("leaves-dependents-behind", func(t *testing.T) {
+		t.Parallel()
+
+		source := NewSnapshot([]*resource.State{pA, a, b, c})
+		dest := NewSnapshot(nil)
+		_, err := MoveResources(source, dest, []resource.URN{a.URN}, "dest", "test", MoveOptions{})
+		assert.ErrorContains(t, err, "which is being moved")
+	})
+}
+
+func TestMoveResourceWithChildren(t *testing.T) {
+	t.Parallel()
+
+	stackType := resource.RootStackType
+	root := &resource.State{
+		Type: stackType,
+		URN:  resource.NewURN("test", "test", "", stackType, "test-test"),
+	}
+	compType := tokens.Type("my:index:Component")
+	comp := &resource.State{
+		Type:   compType,
+		URN:    resource.NewURN("test", "test", "", compType, "comp"),
+		Parent: root.URN,
+	}
+	pA := NewProviderResource("a", "p1", "0")
+	child := NewResource("child", pA)
+	child.URN = resource.NewURN("test", "test", compType, child.Type, "child")
+	child.Parent = comp.URN
+
+	source := NewSnapshot([]*resource.State{root, pA, comp, child})
+	dest := NewSnapshot(nil)
+
+	_, err := MoveResources(source, dest, []resource.URN{comp.URN}, "dest", "other", MoveOptions{})
+	assert.ErrorContains(t, err, "move its children as well")
+
+	result, err := MoveResources(source, dest, []resource.URN{comp.URN}, "dest", "other", MoveOptions{
+		IncludeChildren: true,
+	})
+	require.NoError(t, err)
+	assert.Len(t, result.Moved, 3)
+	assert.Empty(t, result.CopiedProviders)
+	assert.Equal(t, []*resource.State{root}, source.Resources)
+
+	// A root stack resource is created in the destination, and the component is reparented to it.
+	require.Len(t, dest.Resources, 4)
+	newRoot, newComp, newChild := dest.Resources[0], dest.Resources[2], dest.Resources[3]
+	assert.Equal(t, resource.NewURN("dest", "other", "", stackType, "other-dest"), newRoot.URN)
+	assert.Equal(t, newRoot.URN, newComp.Parent)
+	assert.Equal(t, resource.NewURN("dest", "other", compType, child.Type, "child"), newChild.URN)
+	assert.Equal(t, newComp.URN, newChild.Parent)
+	assert.NoError(t, dest.VerifyIntegrity())
+}
+
+func TestMoveResourceConflict(t *testing.T) {
+	t.Parallel()
+
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	source := NewSnapshot([]*resource.State{pA, a})
+
+	// The destination already contains the same provider, which is shared, and a resource named like a.
+	destProv := NewProviderResource("a", "p1", "0")
+	destProv.URN = resource.NewURN("dest", "test", "", destProv.Type, "p1")
+	dest := NewSnapshot([]*resource.State{destProv})
+
+	result, err := MoveResources(source, dest, []resource.URN{a.URN}, "dest", "test", MoveOptions{})
+	require.NoError(t, err)
+	assert.Len(t, result.Moved, 2)
+	assert.Empty(t, source.Resources)
+	require.Len(t, dest.Resources, 2)
+	assert.NoError(t, dest.VerifyIntegrity())
+
+	source = NewSnapshot([]*resource.State{pA, NewResource("a", pA)})
+	_, err = MoveResources(source, dest, []resource.URN{a.URN}, "dest", "test", MoveOptions{})
+	assert.ErrorContains(t, err, "already exists in the destination stack")
+}
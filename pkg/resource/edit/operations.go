@@ -129,6 +129,12 @@ func DeleteResource(
 	return nil
 }
 
+// ProtectResource protects a resource.
+func ProtectResource(_ *deploy.Snapshot, res *resource.State) error {
+	res.Protect = true
+	return nil
+}
+
 // UnprotectResource unprotects a resource.
 func UnprotectResource(_ *deploy.Snapshot, res *resource.State) error {
 	res.Protect = false
@@ -0,0 +1,128 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"fmt"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// PendingResolution is a way of resolving a pending operation that was left in a snapshot by an interrupted
+// deployment.
+type PendingResolution string
+
+const (
+	// PendingAdopt resolves a pending operation by recording the live state of its resource, as read from the
+	// resource's provider.
+	PendingAdopt PendingResolution = "adopt"
+	// PendingDrop resolves a pending operation by discarding it, leaving the rest of the snapshot as it is.
+	PendingDrop PendingResolution = "drop"
+	// PendingDelete resolves a pending operation by marking its resource for deletion by the next deployment.
+	PendingDelete PendingResolution = "delete"
+)
+
+// PendingResolutions lists the ways of resolving a pending operation.
+var PendingResolutions = []PendingResolution{PendingAdopt, PendingDrop, PendingDelete}
+
+// ParsePendingResolution parses the name of a way to resolve a pending operation.
+func ParsePendingResolution(s string) (PendingResolution, error) {
+	for _, r := range PendingResolutions {
+		if string(r) == s {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("unknown resolution %q; expected one of adopt, drop or delete", s)
+}
+
+// LocatePendingResource returns the resource in the snapshot that the given pending operation was applied to, or nil
+// if the resource isn't in the snapshot, as is the case for pending creates.
+func LocatePendingResource(snap *deploy.Snapshot, op resource.Operation) *resource.State {
+	if op.Resource.ID == "" {
+		return nil
+	}
+	for _, res := range snap.Resources {
+		if res.URN == op.Resource.URN && res.ID == op.Resource.ID {
+			return res
+		}
+	}
+	return nil
+}
+
+// AdoptPendingOperation resolves the given pending operation of the snapshot by recording the live state of its
+// resource. live is the state of the resource as read from its provider, or nil if the resource doesn't exist,
+// in which case the resource is removed from the snapshot.
+func AdoptPendingOperation(snap *deploy.Snapshot, op resource.Operation, live *resource.State) error {
+	old := LocatePendingResource(snap, op)
+
+	switch {
+	case live == nil && old != nil:
+		if err := DeleteResource(snap, old, nil, false); err != nil {
+			return err
+		}
+	case live != nil && old != nil:
+		// Replace the resource in place, so that it keeps its position relative to its dependencies.
+		for i, res := range snap.Resources {
+			if res == old {
+				snap.Resources[i] = live
+			}
+		}
+	case live != nil:
+		// The resource isn't depended on by any other resource in the snapshot, so it can go at the end.
+		snap.Resources = append(snap.Resources, live)
+	}
+
+	return removePendingOperation(snap, op)
+}
+
+// DropPendingOperation resolves the given pending operation of the snapshot by discarding it.
+func DropPendingOperation(snap *deploy.Snapshot, op resource.Operation) error {
+	return removePendingOperation(snap, op)
+}
+
+// DeletePendingOperation resolves the given pending operation of the snapshot by marking its resource for deletion, so
+// that the next deployment deletes it. If the resource isn't in the snapshot, the operation's resource must have an
+// ID.
+func DeletePendingOperation(snap *deploy.Snapshot, op resource.Operation) error {
+	if old := LocatePendingResource(snap, op); old != nil {
+		if old.Protect {
+			return ResourceProtectedError{Condemned: old}
+		}
+		old.Delete = true
+	} else {
+		if op.Resource.ID == "" {
+			return fmt.Errorf("can't delete %q: the ID of the resource is unknown", op.Resource.URN)
+		}
+		op.Resource.Delete = true
+		snap.Resources = append(snap.Resources, op.Resource)
+	}
+
+	return removePendingOperation(snap, op)
+}
+
+// removePendingOperation removes the given pending operation from the snapshot. Operations are identified by their
+// resource.
+func removePendingOperation(snap *deploy.Snapshot, op resource.Operation) error {
+	for i, pending := range snap.PendingOperations {
+		if pending.Resource == op.Resource {
+			ops := make([]resource.Operation, 0, len(snap.PendingOperations)-1)
+			ops = append(ops, snap.PendingOperations[:i]...)
+			snap.PendingOperations = append(ops, snap.PendingOperations[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("the pending %s of %q is not in the snapshot", op.Type, op.Resource.URN)
+}
@@ -0,0 +1,143 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// newPendingSnapshot returns a snapshot with a provider, a resource "a" with a pending update, and a pending create of
+// a resource "b".
+func newPendingSnapshot() ([]*resource.State, []resource.Operation) {
+	pA := NewProviderResource("a", "p1", "0")
+	a := NewResource("a", pA)
+	a.ID = "a-id"
+	b := NewResource("b", pA)
+
+	updated := *a
+	updated.Inputs = resource.PropertyMap{"key": resource.NewStringProperty("new")}
+	return []*resource.State{pA, a}, []resource.Operation{
+		resource.NewOperation(&updated, resource.OperationTypeUpdating),
+		resource.NewOperation(b, resource.OperationTypeCreating),
+	}
+}
+
+func TestParsePendingResolution(t *testing.T) {
+	t.Parallel()
+
+	for _, r := range PendingResolutions {
+		parsed, err := ParsePendingResolution(string(r))
+		assert.NoError(t, err)
+		assert.Equal(t, r, parsed)
+	}
+	_, err := ParsePendingResolution("ignore")
+	assert.Error(t, err)
+}
+
+func TestDropPendingOperation(t *testing.T) {
+	t.Parallel()
+
+	resources, ops := newPendingSnapshot()
+	snap := NewSnapshot(resources)
+	snap.PendingOperations = ops
+
+	require.NoError(t, DropPendingOperation(snap, ops[1]))
+	assert.Equal(t, ops[:1], snap.PendingOperations)
+	assert.Equal(t, resources, snap.Resources)
+
+	require.NoError(t, DropPendingOperation(snap, ops[0]))
+	assert.Empty(t, snap.PendingOperations)
+	assert.Equal(t, resources, snap.Resources)
+	assert.NoError(t, snap.VerifyIntegrity())
+
+	// An operation can't be resolved twice.
+	assert.Error(t, DropPendingOperation(snap, ops[0]))
+}
+
+func TestAdoptPendingOperation(t *testing.T) {
+	t.Parallel()
+
+	resources, ops := newPendingSnapshot()
+	snap := NewSnapshot(resources)
+	snap.PendingOperations = ops
+
+	// Adopting the update replaces the resource in place.
+	liveA := *ops[0].Resource
+	liveA.Outputs = resource.PropertyMap{"key": resource.NewStringProperty("new")}
+	require.NoError(t, AdoptPendingOperation(snap, ops[0], &liveA))
+	assert.Equal(t, []*resource.State{resources[0], &liveA}, snap.Resources)
+
+	// Adopting the create appends the resource.
+	liveB := *ops[1].Resource
+	liveB.ID = "b-id"
+	require.NoError(t, AdoptPendingOperation(snap, ops[1], &liveB))
+	assert.Equal(t, []*resource.State{resources[0], &liveA, &liveB}, snap.Resources)
+	assert.Empty(t, snap.PendingOperations)
+	assert.NoError(t, snap.VerifyIntegrity())
+}
+
+func TestAdoptPendingOperationMissingResource(t *testing.T) {
+	t.Parallel()
+
+	resources, ops := newPendingSnapshot()
+	snap := NewSnapshot(resources)
+	snap.PendingOperations = ops
+
+	// If the resource no longer exists, it's removed from the snapshot.
+	require.NoError(t, AdoptPendingOperation(snap, ops[0], nil))
+	assert.Equal(t, resources[:1], snap.Resources)
+	require.NoError(t, AdoptPendingOperation(snap, ops[1], nil))
+	assert.Equal(t, resources[:1], snap.Resources)
+	assert.Empty(t, snap.PendingOperations)
+}
+
+func TestDeletePendingOperation(t *testing.T) {
+	t.Parallel()
+
+	resources, ops := newPendingSnapshot()
+	snap := NewSnapshot(resources)
+	snap.PendingOperations = ops
+
+	// The resource of the update is marked for deletion.
+	require.NoError(t, DeletePendingOperation(snap, ops[0]))
+	assert.True(t, resources[1].Delete)
+
+	// The resource of the create can't be deleted until its ID is known.
+	assert.Error(t, DeletePendingOperation(snap, ops[1]))
+	ops[1].Resource.ID = "b-id"
+	require.NoError(t, DeletePendingOperation(snap, ops[1]))
+	require.Len(t, snap.Resources, 3)
+	assert.Equal(t, ops[1].Resource, snap.Resources[2])
+	assert.True(t, snap.Resources[2].Delete)
+	assert.Empty(t, snap.PendingOperations)
+	assert.NoError(t, snap.VerifyIntegrity())
+}
+
+func TestDeletePendingOperationProtected(t *testing.T) {
+	t.Parallel()
+
+	resources, ops := newPendingSnapshot()
+	resources[1].Protect = true
+	snap := NewSnapshot(resources)
+	snap.PendingOperations = ops
+
+	assert.ErrorIs(t, DeletePendingOperation(snap, ops[0]), ResourceProtectedError{Condemned: resources[1]})
+	assert.Len(t, snap.PendingOperations, 2)
+}
@@ -0,0 +1,212 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy"
+	"github.com/pulumi/pulumi/pkg/v3/resource/deploy/providers"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// SelectorSyntax documents the syntax accepted by ParseSelector, in a form suitable for inclusion in command help.
+const SelectorSyntax = `A selector is a whitespace-separated list of terms, all of which must match a resource for it to be
+selected. Each term has the form key=value, where value may contain '*' wildcards:
+
+  urn=<glob>             the resource's URN, using the same glob syntax as --target
+  type=<glob>            the resource's type token, e.g. type='aws:rds/*'
+  name=<glob>            the resource's name
+  parent=<glob>          the URN of the resource's parent
+  provider=<glob>        the URN of the resource's provider, or the provider's package name, e.g. provider=aws
+  tag:<key>=<glob>       the value of the given key in the resource's 'tags' output
+  output:<path>=<glob>   the value of the output property at the given property path, e.g. output:engine=postgres
+
+A term written as key!=value matches resources that the corresponding key=value term does not match.`
+
+// Selector matches resources in a snapshot by their URN, type, parent, provider, tags and outputs.
+type Selector struct {
+	text  string
+	terms []selectorTerm
+}
+
+type selectorTerm struct {
+	negate bool
+	match  func(res *resource.State) bool
+}
+
+// String returns the text that the selector was parsed from.
+func (s *Selector) String() string {
+	return s.text
+}
+
+// ParseSelector parses a selector in the syntax described by SelectorSyntax.
+func ParseSelector(text string) (*Selector, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("selector must not be empty")
+	}
+
+	s := &Selector{text: text}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid selector term %q: expected key=value", field)
+		}
+		negate := strings.HasSuffix(key, "!")
+		key = strings.TrimSuffix(key, "!")
+
+		match, err := parseSelectorTerm(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector term %q: %w", field, err)
+		}
+		s.terms = append(s.terms, selectorTerm{negate: negate, match: match})
+	}
+	return s, nil
+}
+
+func parseSelectorTerm(key, value string) (func(res *resource.State) bool, error) {
+	switch {
+	case key == "urn":
+		return urnGlobMatcher(value, func(res *resource.State) resource.URN { return res.URN }), nil
+	case key == "type":
+		glob := compileGlob(value)
+		return func(res *resource.State) bool { return glob.MatchString(string(res.Type)) }, nil
+	case key == "name":
+		glob := compileGlob(value)
+		return func(res *resource.State) bool { return glob.MatchString(string(res.URN.Name())) }, nil
+	case key == "parent":
+		return urnGlobMatcher(value, func(res *resource.State) resource.URN { return res.Parent }), nil
+	case key == "provider":
+		if !strings.HasPrefix(value, resource.URNPrefix) {
+			// Treat the value as a package name and match the provider's type.
+			glob := compileGlob(string(providers.MakeProviderType("")) + value)
+			return func(res *resource.State) bool {
+				ref, ok := providerReference(res)
+				return ok && glob.MatchString(string(ref.URN().Type()))
+			}, nil
+		}
+		return urnGlobMatcher(value, func(res *resource.State) resource.URN {
+			if ref, ok := providerReference(res); ok {
+				return ref.URN()
+			}
+			return ""
+		}), nil
+	case strings.HasPrefix(key, "tag:"):
+		tag := resource.PropertyKey(strings.TrimPrefix(key, "tag:"))
+		glob := compileGlob(value)
+		return func(res *resource.State) bool {
+			tags, ok := res.Outputs["tags"]
+			if !ok || !tags.IsObject() {
+				return false
+			}
+			v, ok := tags.ObjectValue()[tag]
+			return ok && matchPropertyValue(glob, v)
+		}, nil
+	case strings.HasPrefix(key, "output:"):
+		path, err := resource.ParsePropertyPath(strings.TrimPrefix(key, "output:"))
+		if err != nil {
+			return nil, err
+		}
+		glob := compileGlob(value)
+		return func(res *resource.State) bool {
+			v, ok := path.Get(resource.NewObjectProperty(res.Outputs))
+			return ok && matchPropertyValue(glob, v)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown key %q", key)
+	}
+}
+
+// Matches returns true if the given resource matches every term of the selector.
+func (s *Selector) Matches(res *resource.State) bool {
+	for _, term := range s.terms {
+		if term.match(res) == term.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectResources returns every resource in the snapshot that matches any of the given selectors, in snapshot order.
+// Resources that are pending deletion are never selected.
+func SelectResources(snap *deploy.Snapshot, selectors []*Selector) []*resource.State {
+	if snap == nil {
+		return nil
+	}
+
+	var selected []*resource.State
+	for _, res := range snap.Resources {
+		if res.Delete {
+			continue
+		}
+		for _, s := range selectors {
+			if s.Matches(res) {
+				selected = append(selected, res)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// urnGlobMatcher returns a function that matches the URN returned by get against a URN or URN glob, using the same
+// glob syntax as update targets.
+func urnGlobMatcher(value string, get func(res *resource.State) resource.URN) func(res *resource.State) bool {
+	targets := deploy.NewUrnTargets([]string{value})
+	return func(res *resource.State) bool {
+		urn := get(res)
+		return urn != "" && targets.Contains(urn)
+	}
+}
+
+// compileGlob compiles a glob in which '*' matches any sequence of characters into an anchored regular expression.
+func compileGlob(glob string) *regexp.Regexp {
+	parts := strings.Split(glob, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	// Because we have quoted all input, this is safe to compile.
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// matchPropertyValue matches a glob against the string form of a primitive property value. Secrets are never matched,
+// so that selectors cannot be used to probe secret values.
+func matchPropertyValue(glob *regexp.Regexp, v resource.PropertyValue) bool {
+	switch {
+	case v.IsString():
+		return glob.MatchString(v.StringValue())
+	case v.IsNumber():
+		return glob.MatchString(strconv.FormatFloat(v.NumberValue(), 'f', -1, 64))
+	case v.IsBool():
+		return glob.MatchString(strconv.FormatBool(v.BoolValue()))
+	default:
+		return false
+	}
+}
+
+func providerReference(res *resource.State) (providers.Reference, bool) {
+	if res.Provider == "" {
+		return providers.Reference{}, false
+	}
+	ref, err := providers.ParseReference(res.Provider)
+	if err != nil {
+		return providers.Reference{}, false
+	}
+	return ref, true
+}
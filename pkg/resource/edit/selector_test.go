@@ -0,0 +1,98 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package edit
+
+import (
+	"testing"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSelectorErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"", "   ", "type", "=foo", "color=red", "output:a[=b"} {
+		_, err := ParseSelector(text)
+		assert.Error(t, err, "selector %q", text)
+	}
+}
+
+func TestSelectResources(t *testing.T) {
+	t.Parallel()
+
+	pAWS := NewProviderResource("aws", "default", "0")
+	pGH := NewProviderResource("github", "gh", "1")
+
+	newTyped := func(name string, typ tokens.Type, provider *resource.State) *resource.State {
+		res := NewResource(name, provider)
+		res.Type = typ
+		res.URN = resource.NewURN("test", "test", "", typ, tokens.QName(name))
+		return res
+	}
+
+	db := newTyped("db", "aws:rds/instance:Instance", pAWS)
+	db.Outputs = resource.PropertyMap{
+		"engine": resource.NewStringProperty("postgres"),
+		"port":   resource.NewNumberProperty(5432),
+		"tags": resource.NewObjectProperty(resource.PropertyMap{
+			"env": resource.NewStringProperty("prod"),
+		}),
+	}
+	cluster := newTyped("cluster", "aws:rds/cluster:Cluster", pAWS)
+	cluster.Outputs = resource.PropertyMap{
+		"tags": resource.NewObjectProperty(resource.PropertyMap{
+			"env": resource.NewStringProperty("dev"),
+		}),
+	}
+	bucket := newTyped("bucket", "aws:s3/bucket:Bucket", pAWS)
+	bucket.Parent = cluster.URN
+	repo := newTyped("repo", "github:index/repository:Repository", pGH)
+	oldDB := newTyped("db", "aws:rds/instance:Instance", pAWS)
+	oldDB.Delete = true
+
+	snap := NewSnapshot([]*resource.State{pAWS, pGH, db, cluster, bucket, repo, oldDB})
+
+	cases := []struct {
+		selectors []string
+		expected  []*resource.State
+	}{
+		{[]string{"type=aws:rds/*"}, []*resource.State{db, cluster}},
+		{[]string{"type!=aws:rds/* type=aws:*"}, []*resource.State{bucket}},
+		{[]string{"urn=" + string(db.URN)}, []*resource.State{db}},
+		{[]string{"urn=urn:pulumi:test::test::aws:**"}, []*resource.State{db, cluster, bucket}},
+		{[]string{"name=b*"}, []*resource.State{bucket}},
+		{[]string{"parent=" + string(cluster.URN)}, []*resource.State{bucket}},
+		{[]string{"provider=github"}, []*resource.State{repo}},
+		{[]string{"provider=" + string(pAWS.URN) + " type=aws:s3*"}, []*resource.State{bucket}},
+		{[]string{"tag:env=prod"}, []*resource.State{db}},
+		{[]string{"tag:env=*"}, []*resource.State{db, cluster}},
+		{[]string{"output:engine=postgres output:port=5432"}, []*resource.State{db}},
+		{[]string{"output:port=80"}, nil},
+		{[]string{"type=aws:rds/*", "provider=github"}, []*resource.State{db, cluster, repo}},
+	}
+	for _, c := range cases {
+		var selectors []*Selector
+		for _, text := range c.selectors {
+			s, err := ParseSelector(text)
+			require.NoError(t, err)
+			selectors = append(selectors, s)
+		}
+		assert.Equal(t, c.expected, SelectResources(snap, selectors), "selectors %q", c.selectors)
+	}
+}
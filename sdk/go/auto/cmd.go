@@ -17,14 +17,90 @@ package auto
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/blang/semver"
 )
 
 const unknownErrorCode = -2
 
-func runPulumiCommandSync(
+// PulumiCommand manages the Pulumi CLI that a Workspace runs its commands with.
+type PulumiCommand interface {
+	// Run executes the Pulumi CLI with the given arguments from workdir, returning its stdout, stderr and exit code.
+	Run(ctx context.Context,
+		workdir string,
+		additionalOutput []io.Writer,
+		additionalErrorOutput []io.Writer,
+		additionalEnv []string,
+		args ...string,
+	) (string, string, int, error)
+	// Version returns the version of the Pulumi CLI.
+	Version() semver.Version
+}
+
+// PulumiCommandOptions configures the Pulumi CLI used by NewPulumiCommand and InstallPulumiCommand.
+type PulumiCommandOptions struct {
+	// Version is the exact version of the CLI to use. If unset, any version satisfying the minimum version required
+	// by the Automation API is accepted.
+	Version semver.Version
+	// Root is the directory the CLI is installed in, with the pulumi binary in Root/bin. If unset, NewPulumiCommand
+	// uses the pulumi binary on the PATH, and InstallPulumiCommand installs into $PULUMI_HOME/versions/<version>.
+	Root string
+	// Archive is the path of the release archive to install the CLI from.
+	Archive string
+	// CacheDir is a directory of release archives, named as published on the Pulumi releases page (e.g.
+	// pulumi-v3.65.0-linux-x64.tar.gz), to find the archive in when Archive is unset.
+	CacheDir string
+	// SHA256 is the hex encoded SHA-256 checksum of the release archive. If unset, the checksum is read from the
+	// release checksums file (e.g. pulumi-3.65.0-checksums.txt) next to the archive.
+	SHA256 string
+	// SkipVersionCheck skips checking that the CLI satisfies the minimum version required by the Automation API.
+	SkipVersionCheck bool
+}
+
+type pulumiCommand struct {
+	command string
+	binDir  string
+	version semver.Version
+}
+
+// NewPulumiCommand returns a PulumiCommand that runs the Pulumi CLI installed in opts.Root, or the one on the PATH
+// if no root is given, after checking that its version satisfies opts.
+func NewPulumiCommand(ctx context.Context, opts *PulumiCommandOptions) (PulumiCommand, error) {
+	if opts == nil {
+		opts = &PulumiCommandOptions{}
+	}
+
+	cmd := &pulumiCommand{command: "pulumi"}
+	if opts.Root != "" {
+		cmd.binDir = filepath.Join(opts.Root, "bin")
+		cmd.command = filepath.Join(cmd.binDir, pulumiBinaryName())
+	}
+
+	stdout, stderr, errCode, err := cmd.Run(ctx, "", nil, nil, nil, "version")
+	if err != nil {
+		return nil, newAutoError(fmt.Errorf("could not determine pulumi version: %w", err), stdout, stderr, errCode)
+	}
+	if cmd.version, err = parseAndValidatePulumiVersion(minimumVersion, stdout, opts.SkipVersionCheck); err != nil {
+		return nil, err
+	}
+	if !opts.Version.Equals(semver.Version{}) && !opts.Version.Equals(cmd.version) {
+		return nil, fmt.Errorf("pulumi CLI version mismatch: version %s was requested, but %s is version %s",
+			opts.Version, cmd.command, cmd.version)
+	}
+	return cmd, nil
+}
+
+func (c *pulumiCommand) Version() semver.Version {
+	return c.version
+}
+
+func (c *pulumiCommand) Run(
 	ctx context.Context,
 	workdir string,
 	additionalOutput []io.Writer,
@@ -35,9 +111,14 @@ func runPulumiCommandSync(
 	// all commands should be run in non-interactive mode.
 	// this causes commands to fail rather than prompting for input (and thus hanging indefinitely)
 	args = withNonInteractiveArg(args)
-	cmd := exec.CommandContext(ctx, "pulumi", args...)
+	cmd := exec.CommandContext(ctx, c.command, args...)
 	cmd.Dir = workdir
-	cmd.Env = append(os.Environ(), additionalEnv...)
+	cmd.Env = os.Environ()
+	if c.binDir != "" {
+		// Put the installation first on the PATH so that the language hosts installed alongside the CLI are used.
+		cmd.Env = append(cmd.Env, "PATH="+c.binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	cmd.Env = append(cmd.Env, additionalEnv...)
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -57,6 +138,13 @@ func runPulumiCommandSync(
 	return stdout.String(), stderr.String(), code, err
 }
 
+func pulumiBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "pulumi.exe"
+	}
+	return "pulumi"
+}
+
 func withNonInteractiveArg(args []string) []string {
 	out := make([]string, 0, len(args))
 	seen := false
@@ -0,0 +1,293 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/diag/colors"
+)
+
+// ResourceStatus is the status of a resource's step within an operation.
+type ResourceStatus string
+
+const (
+	// StatusPending indicates that the resource has been reported on, e.g. by a diagnostic or policy violation,
+	// but its step has not started yet.
+	StatusPending ResourceStatus = "pending"
+	// StatusInProgress indicates that the resource's step has started but not finished.
+	StatusInProgress ResourceStatus = "in-progress"
+	// StatusDone indicates that the resource's step finished successfully.
+	StatusDone ResourceStatus = "done"
+	// StatusFailed indicates that the resource's step failed.
+	StatusFailed ResourceStatus = "failed"
+)
+
+// ResourceState is the state of a single resource within an operation, as tracked by a Tracker.
+type ResourceState struct {
+	// URN is the URN of the resource.
+	URN string
+	// Type is the type token of the resource.
+	Type string
+	// Op is the operation performed on the resource, if its step has started.
+	Op apitype.OpType
+	// Status is the status of the resource's step.
+	Status ResourceStatus
+	// Error is the error the resource's step failed with, if its status is StatusFailed. It carries the message of
+	// the error diagnostic reported for the resource, if there was one.
+	Error error
+	// Start is when the resource's step started, or the zero time if it has not.
+	Start time.Time
+	// End is when the resource's step finished, or the zero time if it has not.
+	End time.Time
+	// Retries is the number of times the resource's step was retried after failing with a transient error.
+	Retries int
+	// Outputs are the resource's outputs once its step is done.
+	Outputs map[string]interface{}
+	// Diagnostics are the diagnostics reported for the resource.
+	Diagnostics []apitype.DiagnosticEvent
+	// PolicyViolations are the policy violations reported for the resource.
+	PolicyViolations []apitype.PolicyEvent
+}
+
+// Duration returns how long the resource's step took, or has taken so far if it is still in progress.
+func (r ResourceState) Duration() time.Duration {
+	switch {
+	case r.Start.IsZero():
+		return 0
+	case r.End.IsZero():
+		return time.Since(r.Start)
+	default:
+		return r.End.Sub(r.Start)
+	}
+}
+
+// Summary summarizes an operation, as tracked by a Tracker.
+type Summary struct {
+	// Resources are the states of the resources involved in the operation, in the order they were first seen.
+	Resources []ResourceState
+	// Diagnostics are the diagnostics that were not reported for a particular resource.
+	Diagnostics []apitype.DiagnosticEvent
+	// PolicyViolations are all of the policy violations reported during the operation.
+	PolicyViolations []apitype.PolicyEvent
+	// Changes counts the resource changes by operation, as reported by the engine once the operation finished.
+	Changes map[apitype.OpType]int
+	// Duration is how long the operation took, as reported by the engine once the operation finished.
+	Duration time.Duration
+	// Complete is set once the engine has reported the end of the operation.
+	Complete bool
+	// MaybeCorrupt is set if one or more of the resources may be in an invalid state.
+	MaybeCorrupt bool
+	// Errors are the errors encountered while reading the event stream.
+	Errors []error
+}
+
+// Failed returns the resources whose steps failed.
+func (s Summary) Failed() []ResourceState {
+	var failed []ResourceState
+	for _, r := range s.Resources {
+		if r.Status == StatusFailed {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// Tracker aggregates the engine events of an operation into the state of each resource involved, and a summary
+// of the whole operation. It is safe to query a Tracker while it is consuming events.
+//
+//	ch := make(chan events.EngineEvent)
+//	tracker := events.NewTracker()
+//	done := make(chan events.Summary)
+//	go func() { done <- tracker.Consume(ch) }()
+//	_, err := stack.Up(ctx, optup.EventStreams(ch))
+//	summary := <-done
+type Tracker struct {
+	m         sync.Mutex
+	now       func() time.Time
+	resources map[string]*ResourceState
+	order     []string
+	summary   Summary
+}
+
+// NewTracker creates a Tracker with no events.
+func NewTracker() *Tracker {
+	return &Tracker{
+		now:       time.Now,
+		resources: make(map[string]*ResourceState),
+	}
+}
+
+// Consume handles the events received from ch until it is closed, then returns the final summary. Operations close
+// the channels given to their EventStreams option once they finish.
+func (t *Tracker) Consume(ch <-chan EngineEvent) Summary {
+	for e := range ch {
+		t.Handle(e)
+	}
+	return t.Summary()
+}
+
+// Handle updates the tracked state with a single event.
+func (t *Tracker) Handle(e EngineEvent) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if e.Error != nil {
+		t.summary.Errors = append(t.summary.Errors, e.Error)
+		return
+	}
+
+	now := t.now()
+	switch {
+	case e.ResourcePreEvent != nil:
+		md := e.ResourcePreEvent.Metadata
+		r := t.resource(md.URN, md.Type)
+		r.Op, r.Status, r.Start, r.End = md.Op, StatusInProgress, now, time.Time{}
+	case e.ResOutputsEvent != nil:
+		md := e.ResOutputsEvent.Metadata
+		r := t.resource(md.URN, md.Type)
+		r.Op, r.Status, r.End = md.Op, StatusDone, now
+		if r.Start.IsZero() {
+			r.Start = now
+		}
+		if md.New != nil {
+			r.Outputs = md.New.Outputs
+		}
+	case e.ResOpFailedEvent != nil:
+		md := e.ResOpFailedEvent.Metadata
+		r := t.resource(md.URN, md.Type)
+		r.Op, r.Status, r.End = md.Op, StatusFailed, now
+		if r.Start.IsZero() {
+			r.Start = now
+		}
+		if r.Error == nil {
+			r.Error = errResourceFailed
+			// The engine typically reports the cause of the failure in an error diagnostic first.
+			for i := len(r.Diagnostics) - 1; i >= 0; i-- {
+				if r.Diagnostics[i].Severity == "error" {
+					r.Error = diagnosticError(r.Diagnostics[i])
+					break
+				}
+			}
+		}
+	case e.ResOpRetryEvent != nil:
+		md := e.ResOpRetryEvent.Metadata
+		r := t.resource(md.URN, md.Type)
+		r.Retries++
+	case e.DiagnosticEvent != nil:
+		d := *e.DiagnosticEvent
+		if d.Ephemeral {
+			// Ephemeral diagnostics are transient status messages rather than part of the operation's record.
+			return
+		}
+		if d.URN == "" {
+			t.summary.Diagnostics = append(t.summary.Diagnostics, d)
+			return
+		}
+		r := t.resource(d.URN, "")
+		r.Diagnostics = append(r.Diagnostics, d)
+		if d.Severity == "error" && r.Error == errResourceFailed {
+			r.Error = diagnosticError(d)
+		}
+	case e.PolicyEvent != nil:
+		p := *e.PolicyEvent
+		t.summary.PolicyViolations = append(t.summary.PolicyViolations, p)
+		if p.ResourceURN != "" {
+			r := t.resource(p.ResourceURN, "")
+			r.PolicyViolations = append(r.PolicyViolations, p)
+		}
+	case e.SummaryEvent != nil:
+		t.summary.Complete = true
+		t.summary.MaybeCorrupt = e.SummaryEvent.MaybeCorrupt
+		t.summary.Duration = time.Duration(e.SummaryEvent.DurationSeconds) * time.Second
+		t.summary.Changes = make(map[apitype.OpType]int, len(e.SummaryEvent.ResourceChanges))
+		for op, n := range e.SummaryEvent.ResourceChanges {
+			t.summary.Changes[op] = n
+		}
+	}
+}
+
+// errResourceFailed is the error of a failed resource whose failure had no error diagnostic.
+var errResourceFailed = errors.New("resource operation failed")
+
+func diagnosticError(d apitype.DiagnosticEvent) error {
+	return errors.New(strings.TrimSpace(colors.Never.Colorize(d.Message)))
+}
+
+// resource returns the state of the resource with the given URN, starting to track it if it is new.
+func (t *Tracker) resource(urn, typ string) *ResourceState {
+	r, ok := t.resources[urn]
+	if !ok {
+		r = &ResourceState{URN: urn, Status: StatusPending}
+		t.resources[urn] = r
+		t.order = append(t.order, urn)
+	}
+	if r.Type == "" {
+		r.Type = typ
+	}
+	return r
+}
+
+// Resource returns the current state of the resource with the given URN, if it has been seen.
+func (t *Tracker) Resource(urn string) (ResourceState, bool) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	r, ok := t.resources[urn]
+	if !ok {
+		return ResourceState{}, false
+	}
+	return r.clone(), true
+}
+
+// Resources returns the current states of the resources seen so far, in the order they were first seen.
+func (t *Tracker) Resources() []ResourceState {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	return t.snapshot()
+}
+
+// Summary returns a summary of the operation so far.
+func (t *Tracker) Summary() Summary {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	s := t.summary
+	s.Resources = t.snapshot()
+	s.Diagnostics = append([]apitype.DiagnosticEvent(nil), s.Diagnostics...)
+	s.PolicyViolations = append([]apitype.PolicyEvent(nil), s.PolicyViolations...)
+	s.Errors = append([]error(nil), s.Errors...)
+	return s
+}
+
+func (t *Tracker) snapshot() []ResourceState {
+	resources := make([]ResourceState, len(t.order))
+	for i, urn := range t.order {
+		resources[i] = t.resources[urn].clone()
+	}
+	return resources
+}
+
+func (r *ResourceState) clone() ResourceState {
+	c := *r
+	c.Diagnostics = append([]apitype.DiagnosticEvent(nil), r.Diagnostics...)
+	c.PolicyViolations = append([]apitype.PolicyEvent(nil), r.PolicyViolations...)
+	return c
+}
@@ -0,0 +1,183 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
+)
+
+const (
+	bucketURN = "urn:pulumi:dev::proj::aws:s3/bucket:Bucket::bucket"
+	roleURN   = "urn:pulumi:dev::proj::aws:iam/role:Role::role"
+)
+
+func step(op apitype.OpType, urn, typ string) apitype.StepEventMetadata {
+	return apitype.StepEventMetadata{Op: op, URN: urn, Type: typ}
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2023, 4, 26, 12, 0, 0, 0, time.UTC)
+	clock := start
+	tracker := NewTracker()
+	tracker.now = func() time.Time { return clock }
+
+	handle := func(after time.Duration, e apitype.EngineEvent) {
+		clock = start.Add(after)
+		tracker.Handle(EngineEvent{EngineEvent: e})
+	}
+
+	handle(0, apitype.EngineEvent{PreludeEvent: &apitype.PreludeEvent{}})
+	handle(0, apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+		Message:  "a warning\n",
+		Severity: "warning",
+	}})
+	handle(1*time.Second, apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+		Metadata: step(apitype.OpCreate, bucketURN, "aws:s3/bucket:Bucket"),
+	}})
+	handle(1*time.Second, apitype.EngineEvent{PolicyEvent: &apitype.PolicyEvent{
+		ResourceURN:      roleURN,
+		Message:          "roles must have a description",
+		PolicyName:       "role-description",
+		EnforcementLevel: "mandatory",
+	}})
+
+	bucket, ok := tracker.Resource(bucketURN)
+	require.True(t, ok)
+	assert.Equal(t, StatusInProgress, bucket.Status)
+	role, ok := tracker.Resource(roleURN)
+	require.True(t, ok)
+	assert.Equal(t, StatusPending, role.Status)
+	_, ok = tracker.Resource("urn:pulumi:dev::proj::pulumi:pulumi:Stack::proj-dev")
+	assert.False(t, ok)
+
+	handle(2*time.Second, apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+		Metadata: step(apitype.OpUpdate, roleURN, "aws:iam/role:Role"),
+	}})
+	handle(3*time.Second, apitype.EngineEvent{ResOpRetryEvent: &apitype.ResOpRetryEvent{
+		Metadata:     step(apitype.OpUpdate, roleURN, "aws:iam/role:Role"),
+		Attempt:      2,
+		MaxAttempts:  3,
+		DelaySeconds: 1,
+		Message:      "throttled",
+	}})
+	handle(4*time.Second, apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+		URN:       roleURN,
+		Message:   "updating...",
+		Severity:  "info",
+		Ephemeral: true,
+	}})
+	handle(5*time.Second, apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+		URN:      roleURN,
+		Message:  "<{%reset%}>access denied<{%reset%}>\n",
+		Severity: "error",
+	}})
+	handle(5*time.Second, apitype.EngineEvent{ResOpFailedEvent: &apitype.ResOpFailedEvent{
+		Metadata: step(apitype.OpUpdate, roleURN, "aws:iam/role:Role"),
+	}})
+	bucketDone := step(apitype.OpCreate, bucketURN, "aws:s3/bucket:Bucket")
+	bucketDone.New = &apitype.StepEventStateMetadata{Outputs: map[string]interface{}{"arn": "arn:aws:s3:::bucket"}}
+	handle(7*time.Second, apitype.EngineEvent{ResOutputsEvent: &apitype.ResOutputsEvent{Metadata: bucketDone}})
+	handle(8*time.Second, apitype.EngineEvent{SummaryEvent: &apitype.SummaryEvent{
+		DurationSeconds: 8,
+		ResourceChanges: map[apitype.OpType]int{apitype.OpCreate: 1},
+	}})
+
+	summary := tracker.Summary()
+	require.Len(t, summary.Resources, 2)
+
+	bucket = summary.Resources[0]
+	assert.Equal(t, bucketURN, bucket.URN)
+	assert.Equal(t, "aws:s3/bucket:Bucket", bucket.Type)
+	assert.Equal(t, apitype.OpCreate, bucket.Op)
+	assert.Equal(t, StatusDone, bucket.Status)
+	assert.NoError(t, bucket.Error)
+	assert.Equal(t, 6*time.Second, bucket.Duration())
+	assert.Equal(t, map[string]interface{}{"arn": "arn:aws:s3:::bucket"}, bucket.Outputs)
+
+	role = summary.Resources[1]
+	assert.Equal(t, roleURN, role.URN)
+	assert.Equal(t, "aws:iam/role:Role", role.Type)
+	assert.Equal(t, StatusFailed, role.Status)
+	assert.EqualError(t, role.Error, "access denied")
+	assert.Equal(t, 3*time.Second, role.Duration())
+	assert.Equal(t, 1, role.Retries)
+	assert.Len(t, role.Diagnostics, 1)
+	assert.Len(t, role.PolicyViolations, 1)
+	assert.Equal(t, []ResourceState{role}, summary.Failed())
+
+	require.Len(t, summary.Diagnostics, 1)
+	assert.Equal(t, "a warning\n", summary.Diagnostics[0].Message)
+	require.Len(t, summary.PolicyViolations, 1)
+	assert.Equal(t, "role-description", summary.PolicyViolations[0].PolicyName)
+	assert.True(t, summary.Complete)
+	assert.False(t, summary.MaybeCorrupt)
+	assert.Equal(t, 8*time.Second, summary.Duration)
+	assert.Equal(t, map[apitype.OpType]int{apitype.OpCreate: 1}, summary.Changes)
+	assert.Empty(t, summary.Errors)
+}
+
+func TestTrackerFailureWithoutDiagnostic(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTracker()
+	tracker.Handle(EngineEvent{EngineEvent: apitype.EngineEvent{ResOpFailedEvent: &apitype.ResOpFailedEvent{
+		Metadata: step(apitype.OpDelete, bucketURN, "aws:s3/bucket:Bucket"),
+	}}})
+
+	bucket, ok := tracker.Resource(bucketURN)
+	require.True(t, ok)
+	assert.Equal(t, StatusFailed, bucket.Status)
+	assert.EqualError(t, bucket.Error, "resource operation failed")
+
+	// A diagnostic reported after the failure replaces the generic error.
+	tracker.Handle(EngineEvent{EngineEvent: apitype.EngineEvent{DiagnosticEvent: &apitype.DiagnosticEvent{
+		URN:      bucketURN,
+		Message:  "bucket not empty",
+		Severity: "error",
+	}}})
+	bucket, _ = tracker.Resource(bucketURN)
+	assert.EqualError(t, bucket.Error, "bucket not empty")
+}
+
+func TestTrackerConsume(t *testing.T) {
+	t.Parallel()
+
+	ch := make(chan EngineEvent)
+	tracker := NewTracker()
+	done := make(chan Summary)
+	go func() { done <- tracker.Consume(ch) }()
+
+	streamErr := errors.New("failed to parse engine event")
+	ch <- EngineEvent{EngineEvent: apitype.EngineEvent{ResourcePreEvent: &apitype.ResourcePreEvent{
+		Metadata: step(apitype.OpCreate, bucketURN, "aws:s3/bucket:Bucket"),
+	}}}
+	ch <- EngineEvent{Error: streamErr}
+	close(ch)
+
+	summary := <-done
+	require.Len(t, summary.Resources, 1)
+	assert.Equal(t, StatusInProgress, summary.Resources[0].Status)
+	assert.False(t, summary.Complete)
+	assert.Equal(t, []error{streamErr}, summary.Errors)
+}
@@ -0,0 +1,234 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/blang/semver"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/common/workspace"
+)
+
+// InstallPulumiCommand installs the Pulumi CLI version given by opts.Version into opts.Root, and returns a
+// PulumiCommand that runs it. The CLI is installed from opts.Archive, or from the matching release archive in
+// opts.CacheDir, after verifying the archive's SHA-256 checksum. If the requested version is already installed in
+// opts.Root it is used as is, and no archive is needed.
+func InstallPulumiCommand(ctx context.Context, opts *PulumiCommandOptions) (PulumiCommand, error) {
+	if opts == nil || opts.Version.Equals(semver.Version{}) {
+		return nil, errors.New("a version is required to install the Pulumi CLI")
+	}
+	installOpts := *opts
+	if installOpts.Root == "" {
+		home, err := workspace.GetPulumiHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to install the Pulumi CLI, could not determine $PULUMI_HOME: %w", err)
+		}
+		installOpts.Root = filepath.Join(home, "versions", installOpts.Version.String())
+	}
+
+	// Reuse an existing installation of the requested version.
+	if _, err := os.Stat(filepath.Join(installOpts.Root, "bin", pulumiBinaryName())); err == nil {
+		if cmd, err := NewPulumiCommand(ctx, &installOpts); err == nil {
+			return cmd, nil
+		}
+	}
+
+	archive := installOpts.Archive
+	if archive == "" {
+		if installOpts.CacheDir == "" {
+			return nil, fmt.Errorf("failed to install Pulumi CLI version %s: no archive or cache directory given",
+				installOpts.Version)
+		}
+		archive = filepath.Join(installOpts.CacheDir, pulumiArchiveName(installOpts.Version))
+	}
+
+	checksum := installOpts.SHA256
+	if checksum == "" {
+		var err error
+		if checksum, err = readPulumiArchiveChecksum(archive, installOpts.Version); err != nil {
+			return nil, fmt.Errorf("failed to install Pulumi CLI version %s: %w", installOpts.Version, err)
+		}
+	}
+	if err := verifyChecksum(archive, checksum); err != nil {
+		return nil, fmt.Errorf("failed to install Pulumi CLI version %s: %w", installOpts.Version, err)
+	}
+
+	if err := os.MkdirAll(installOpts.Root, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to install Pulumi CLI version %s: %w", installOpts.Version, err)
+	}
+	// Extract into a temporary directory first so that an interrupted installation never leaves a partial bin dir.
+	tmp, err := os.MkdirTemp(installOpts.Root, ".install-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to install Pulumi CLI version %s: %w", installOpts.Version, err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if strings.HasSuffix(archive, ".zip") {
+		err = extractZip(archive, tmp)
+	} else {
+		err = extractTarGz(archive, tmp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to install Pulumi CLI version %s, could not extract %s: %w",
+			installOpts.Version, archive, err)
+	}
+
+	bin := filepath.Join(installOpts.Root, "bin")
+	if err := os.RemoveAll(bin); err != nil {
+		return nil, fmt.Errorf("failed to install Pulumi CLI version %s: %w", installOpts.Version, err)
+	}
+	if err := os.Rename(tmp, bin); err != nil {
+		return nil, fmt.Errorf("failed to install Pulumi CLI version %s: %w", installOpts.Version, err)
+	}
+
+	return NewPulumiCommand(ctx, &installOpts)
+}
+
+// pulumiArchiveName returns the name of the release archive of the given version for the current platform.
+func pulumiArchiveName(version semver.Version) string {
+	arch := runtime.GOARCH
+	if arch == "amd64" {
+		arch = "x64"
+	}
+	ext := ".tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = ".zip"
+	}
+	return fmt.Sprintf("pulumi-v%s-%s-%s%s", version, runtime.GOOS, arch, ext)
+}
+
+// readPulumiArchiveChecksum reads the checksum of archive from the release checksums file next to it, which lists
+// one "<checksum>  <file name>" entry per line.
+func readPulumiArchiveChecksum(archive string, version semver.Version) (string, error) {
+	path := filepath.Join(filepath.Dir(archive), fmt.Sprintf("pulumi-%s-checksums.txt", version))
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("no checksum given for %s, and the checksums file could not be read: %w", archive, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == filepath.Base(archive) {
+			return fields[0], nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("could not read %s: %w", path, err)
+	}
+	return "", fmt.Errorf("no checksum for %s in %s", filepath.Base(archive), path)
+}
+
+func verifyChecksum(path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return fmt.Errorf("could not read %s: %w", path, err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path, expected, actual)
+	}
+	return nil
+}
+
+// extractTarGz extracts the regular files in a release tarball into dir. The archives nest the binaries in a
+// top-level directory, so only the base name of each file is kept.
+func extractTarGz(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeExtractedFile(filepath.Join(dir, filepath.Base(header.Name)), tr); err != nil {
+			return err
+		}
+	}
+}
+
+// extractZip extracts the regular files in a release zip into dir, keeping only the base name of each file.
+func extractZip(archive, dir string) error {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeExtractedFile(filepath.Join(dir, filepath.Base(file.Name)), rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExtractedFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o755) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil { //nolint:gosec // archives are checksummed before extraction
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,155 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auto
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFakePulumiRelease writes a release archive of the given version into dir, containing a pulumi "binary" that
+// reports itself as reportedVersion, along with the release checksums file. It returns the archive's checksum.
+func writeFakePulumiRelease(t *testing.T, dir string, version, reportedVersion semver.Version) string {
+	script := fmt.Sprintf("#!/bin/sh\necho v%s\n", reportedVersion)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "pulumi/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "pulumi/pulumi",
+		Typeflag: tar.TypeReg,
+		Mode:     0o755,
+		Size:     int64(len(script)),
+	}))
+	_, err := tw.Write([]byte(script))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	name := pulumiArchiveName(version)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o600))
+
+	sum := sha256.Sum256(buf.Bytes())
+	checksum := hex.EncodeToString(sum[:])
+	checksums := fmt.Sprintf("%s  %s\n", checksum, name)
+	require.NoError(t, os.WriteFile(
+		filepath.Join(dir, fmt.Sprintf("pulumi-%s-checksums.txt", version)), []byte(checksums), 0o600))
+	return checksum
+}
+
+func TestInstallPulumiCommand(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake CLI is a shell script")
+	}
+
+	ctx := context.Background()
+	version := semver.MustParse("3.65.0")
+
+	t.Run("from cache", func(t *testing.T) {
+		t.Parallel()
+
+		cache, root := t.TempDir(), t.TempDir()
+		writeFakePulumiRelease(t, cache, version, version)
+
+		cmd, err := InstallPulumiCommand(ctx, &PulumiCommandOptions{Version: version, Root: root, CacheDir: cache})
+		require.NoError(t, err)
+		assert.Equal(t, version, cmd.Version())
+		assert.FileExists(t, filepath.Join(root, "bin", "pulumi"))
+
+		stdout, _, code, err := cmd.Run(ctx, root, nil, nil, nil, "version")
+		require.NoError(t, err)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "v3.65.0\n", stdout)
+
+		ws, err := NewLocalWorkspace(ctx, Pulumi(cmd), WorkDir(t.TempDir()))
+		require.NoError(t, err)
+		assert.Equal(t, "3.65.0", ws.PulumiVersion())
+		assert.Equal(t, cmd, ws.PulumiCommand())
+
+		// The existing installation is reused without needing the archive.
+		cmd, err = InstallPulumiCommand(ctx, &PulumiCommandOptions{Version: version, Root: root})
+		require.NoError(t, err)
+		assert.Equal(t, version, cmd.Version())
+	})
+
+	t.Run("explicit checksum", func(t *testing.T) {
+		t.Parallel()
+
+		cache, root := t.TempDir(), t.TempDir()
+		checksum := writeFakePulumiRelease(t, cache, version, version)
+		archive := filepath.Join(cache, pulumiArchiveName(version))
+		require.NoError(t, os.Remove(filepath.Join(cache, "pulumi-3.65.0-checksums.txt")))
+
+		_, err := InstallPulumiCommand(ctx, &PulumiCommandOptions{Version: version, Root: root, Archive: archive})
+		assert.ErrorContains(t, err, "no checksum given")
+
+		cmd, err := InstallPulumiCommand(ctx, &PulumiCommandOptions{
+			Version: version,
+			Root:    root,
+			Archive: archive,
+			SHA256:  checksum,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, version, cmd.Version())
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		cache, root := t.TempDir(), t.TempDir()
+		writeFakePulumiRelease(t, cache, version, version)
+
+		_, err := InstallPulumiCommand(ctx, &PulumiCommandOptions{
+			Version:  version,
+			Root:     root,
+			CacheDir: cache,
+			SHA256:   hex.EncodeToString(make([]byte, sha256.Size)),
+		})
+		assert.ErrorContains(t, err, "checksum mismatch")
+		assert.NoFileExists(t, filepath.Join(root, "bin", "pulumi"))
+	})
+
+	t.Run("version mismatch", func(t *testing.T) {
+		t.Parallel()
+
+		cache, root := t.TempDir(), t.TempDir()
+		writeFakePulumiRelease(t, cache, version, semver.MustParse("3.64.0"))
+
+		_, err := InstallPulumiCommand(ctx, &PulumiCommandOptions{Version: version, Root: root, CacheDir: cache})
+		assert.ErrorContains(t, err, "version 3.65.0 was requested")
+	})
+
+	t.Run("no archive", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := InstallPulumiCommand(ctx, &PulumiCommandOptions{Version: version, Root: t.TempDir()})
+		assert.ErrorContains(t, err, "no archive or cache directory given")
+	})
+}
@@ -50,6 +50,7 @@ type LocalWorkspace struct {
 	envvars                       map[string]string
 	secretsProvider               string
 	pulumiVersion                 semver.Version
+	pulumiCommand                 PulumiCommand
 	repo                          *GitRepo
 	remote                        bool
 	remoteEnvVars                 map[string]EnvVarValue
@@ -411,6 +412,11 @@ func (l *LocalWorkspace) PulumiVersion() string {
 	return l.pulumiVersion.String()
 }
 
+// PulumiCommand returns the PulumiCommand that runs the Pulumi CLI for this workspace.
+func (l *LocalWorkspace) PulumiCommand() PulumiCommand {
+	return l.pulumiCommand
+}
+
 // WhoAmI returns the currently authenticated user
 func (l *LocalWorkspace) WhoAmI(ctx context.Context) (string, error) {
 	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "whoami")
@@ -679,14 +685,6 @@ func (l *LocalWorkspace) StackOutputs(ctx context.Context, stackName string) (Ou
 	return res, nil
 }
 
-func (l *LocalWorkspace) getPulumiVersion(ctx context.Context) (string, error) {
-	stdout, stderr, errCode, err := l.runPulumiCmdSync(ctx, "version")
-	if err != nil {
-		return "", newAutoError(fmt.Errorf("could not determine pulumi version: %w", err), stdout, stderr, errCode)
-	}
-	return stdout, nil
-}
-
 //nolint:lll
 func parseAndValidatePulumiVersion(minVersion semver.Version, currentVersion string, optOut bool) (semver.Version, error) {
 	version, err := semver.ParseTolerant(currentVersion)
@@ -720,7 +718,7 @@ func (l *LocalWorkspace) runPulumiCmdSync(
 			env = append(env, strings.Join(e, "="))
 		}
 	}
-	return runPulumiCommandSync(ctx,
+	return l.pulumiCommand.Run(ctx,
 		l.WorkDir(),
 		nil, /* additionalOutputs */
 		nil, /* additionalErrorOutputs */
@@ -738,7 +736,7 @@ func (l *LocalWorkspace) supportsPulumiCmdFlag(ctx context.Context, flag string,
 	}
 
 	// Run the command with `--help`, and then we'll look for the flag in the output.
-	stdout, _, _, err := runPulumiCommandSync(ctx, l.WorkDir(), nil, nil, env, append(args, "--help")...)
+	stdout, _, _, err := l.pulumiCommand.Run(ctx, l.WorkDir(), nil, nil, env, append(args, "--help")...)
 	if err != nil {
 		return false, err
 	}
@@ -803,13 +801,16 @@ func NewLocalWorkspace(ctx context.Context, opts ...LocalWorkspaceOption) (Works
 	if val, ok := lwOpts.EnvVars[skipVersionCheckVar]; ok {
 		optOut = optOut || cmdutil.IsTruthy(val)
 	}
-	currentVersion, err := l.getPulumiVersion(ctx)
-	if err != nil {
-		return nil, err
-	}
-	if l.pulumiVersion, err = parseAndValidatePulumiVersion(minimumVersion, currentVersion, optOut); err != nil {
-		return nil, err
+	if lwOpts.PulumiCommand != nil {
+		l.pulumiCommand = lwOpts.PulumiCommand
+	} else {
+		cmd, err := NewPulumiCommand(ctx, &PulumiCommandOptions{SkipVersionCheck: optOut})
+		if err != nil {
+			return nil, err
+		}
+		l.pulumiCommand = cmd
 	}
+	l.pulumiVersion = l.pulumiCommand.Version()
 
 	// If remote was specified, ensure the CLI supports it.
 	if !optOut && l.remote {
@@ -897,6 +898,8 @@ type localWorkspaceOptions struct {
 	PreRunCommands []string
 	// RemoteSkipInstallDependencies sets whether to skip the default dependency installation step
 	RemoteSkipInstallDependencies bool
+	// PulumiCommand is the PulumiCommand used to run the Pulumi CLI. Defaults to the pulumi binary on the PATH.
+	PulumiCommand PulumiCommand
 }
 
 // LocalWorkspaceOption is used to customize and configure a LocalWorkspace at initialization time.
@@ -972,6 +975,14 @@ func Program(program pulumi.RunFunc) LocalWorkspaceOption {
 	})
 }
 
+// Pulumi sets the PulumiCommand used to run the Pulumi CLI, such as one created with InstallPulumiCommand to pin
+// the workspace to a specific CLI version. Defaults to the pulumi binary on the PATH.
+func Pulumi(cmd PulumiCommand) LocalWorkspaceOption {
+	return localWorkspaceOption(func(lo *localWorkspaceOptions) {
+		lo.PulumiCommand = cmd
+	})
+}
+
 // PulumiHome overrides the metadata directory for pulumi commands.
 func PulumiHome(dir string) LocalWorkspaceOption {
 	return localWorkspaceOption(func(lo *localWorkspaceOptions) {
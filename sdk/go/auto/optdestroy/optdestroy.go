@@ -18,6 +18,7 @@ package optdestroy
 
 import (
 	"io"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
@@ -94,6 +95,121 @@ func ShowSecrets(show bool) Option {
 	})
 }
 
+// Color allows specifying whether to colorize output. Choices are: always, never, raw, auto (default "auto")
+func Color(color string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Color = color
+	})
+}
+
+// Diff displays operation as a rich diff showing the overall change
+func Diff() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Diff = true
+	})
+}
+
+// Refresh refreshes the state of the stack's resources before the destroy
+func Refresh() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Refresh = true
+	})
+}
+
+// Exclude specifies a list of resource URNs to ignore during the destroy
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also ignores the resources that depend on or are children of the excluded resources
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ExcludeProtected destroys every resource except the protected ones
+func ExcludeProtected() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeProtected = true
+	})
+}
+
+// ContinueOnError continues with the resources that no failed resource depends on, reporting all
+// failures at the end
+func ContinueOnError() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ContinueOnError = true
+	})
+}
+
+// ConfigFile specifies the path to the stack configuration file to use instead of Pulumi.<stack>.yaml
+func ConfigFile(path string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ConfigFile = path
+	})
+}
+
+// ShowConfig shows configuration keys and variables in the progress output
+func ShowConfig() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowConfig = true
+	})
+}
+
+// ShowReplacementSteps shows the detailed resource replacement creates and deletes in the progress output
+func ShowReplacementSteps() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReplacementSteps = true
+	})
+}
+
+// ShowSames shows resources that don't need to be updated in the progress output
+func ShowSames() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSames = true
+	})
+}
+
+// SuppressOutputs suppresses the display of stack outputs, in case they contain sensitive values
+func SuppressOutputs() Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressOutputs = true
+	})
+}
+
+// SuppressPermalink configures whether to suppress the display of the state permalink. Defaults to
+// suppressing it for self-managed backends.
+func SuppressPermalink(suppress bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressPermalink = &suppress
+	})
+}
+
+// Timeout stops the destroy if it hasn't finished after the given duration. Operations still running are
+// given the TimeoutGracePeriod to finish, and are recorded as pending operations otherwise.
+func Timeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Timeout = timeout
+	})
+}
+
+// TimeoutGracePeriod specifies how long to wait for running operations to finish once the Timeout has passed
+func TimeoutGracePeriod(gracePeriod time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.TimeoutGracePeriod = gracePeriod
+	})
+}
+
+// Remove removes the stack and its configuration file once all of its resources have been destroyed
+func Remove() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Remove = true
+	})
+}
+
 // Option is a parameter to be applied to a Stack.Destroy() operation
 type Option interface {
 	ApplyOption(*Options)
@@ -126,6 +242,36 @@ type Options struct {
 	Color string
 	// Show config secrets when they appear.
 	ShowSecrets *bool
+	// Diff displays operation as a rich diff showing the overall change
+	Diff bool
+	// Refresh the state of the stack's resources before the destroy
+	Refresh bool
+	// Specify a list of resource URNs to ignore
+	Exclude []string
+	// Also ignore the resources that depend on or are children of the excluded resources
+	ExcludeDependents bool
+	// Do not destroy protected resources
+	ExcludeProtected bool
+	// Continue with the resources that no failed resource depends on, reporting all failures at the end
+	ContinueOnError bool
+	// Use the stack configuration file at this path instead of Pulumi.<stack>.yaml
+	ConfigFile string
+	// Show configuration keys and variables in the progress output
+	ShowConfig bool
+	// Show the detailed resource replacement creates and deletes in the progress output
+	ShowReplacementSteps bool
+	// Show resources that don't need to be updated in the progress output
+	ShowSames bool
+	// Suppress the display of stack outputs
+	SuppressOutputs bool
+	// Suppress the display of the state permalink
+	SuppressPermalink *bool
+	// Stop the destroy if it hasn't finished after this long
+	Timeout time.Duration
+	// How long to wait for running operations to finish once the Timeout has passed
+	TimeoutGracePeriod time.Duration
+	// Remove the stack and its configuration file once all of its resources have been destroyed
+	Remove bool
 }
 
 type optionFunc func(*Options)
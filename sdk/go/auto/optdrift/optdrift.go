@@ -0,0 +1,134 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optdrift contains functional options to be used with stack drift detection operations
+// github.com/sdk/v2/go/x/auto Stack.Drift(...optdrift.Option)
+package optdrift
+
+import (
+	"io"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+)
+
+// Parallel is the number of resource operations to run in parallel at once during drift detection
+// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+func Parallel(n int) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Parallel = n
+	})
+}
+
+// Message (optional) to associate with the drift detection operation
+func Message(message string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Message = message
+	})
+}
+
+// Target specifies an exclusive list of resource URNs to check for drift
+func Target(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Target = urns
+	})
+}
+
+// Exclude specifies a list of resource URNs to ignore. Wildcards (*, **) are supported.
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also ignores the resources that depend on or are children of the excluded resources.
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ProgressStreams allows specifying one or more io.Writers to redirect incremental drift detection stdout
+func ProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ProgressStreams = writers
+	})
+}
+
+// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental drift detection stderr
+func ErrorProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ErrorProgressStreams = writers
+	})
+}
+
+// EventStreams allows specifying one or more channels to receive the Pulumi event stream
+func EventStreams(channels ...chan<- events.EngineEvent) Option {
+	return optionFunc(func(opts *Options) {
+		opts.EventStreams = channels
+	})
+}
+
+// DebugLogging provides options for verbose logging to standard error, and enabling plugin logs.
+func DebugLogging(debugOpts debug.LoggingOptions) Option {
+	return optionFunc(func(opts *Options) {
+		opts.DebugLogOpts = debugOpts
+	})
+}
+
+// UserAgent specifies the agent responsible for the operation, stored in backends as "environment.exec.agent"
+func UserAgent(agent string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.UserAgent = agent
+	})
+}
+
+// Option is a parameter to be applied to a Stack.Drift() operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// Parallel is the number of resource operations to run in parallel at once
+	// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+	Parallel int
+	// Message (optional) to associate with the drift detection operation
+	Message string
+	// Specify an exclusive list of resource URNs to check for drift
+	Target []string
+	// Specify a list of resource URNs to ignore
+	Exclude []string
+	// Also ignore the resources that depend on or are children of the excluded resources
+	ExcludeDependents bool
+	// ProgressStreams allows specifying one or more io.Writers to redirect incremental drift detection stdout
+	ProgressStreams []io.Writer
+	// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental drift detection stderr
+	ErrorProgressStreams []io.Writer
+	// EventStreams allows specifying one or more channels to receive the Pulumi event stream
+	EventStreams []chan<- events.EngineEvent
+	// DebugLogOpts specifies additional settings for debug logging
+	DebugLogOpts debug.LoggingOptions
+	// UserAgent specifies the agent responsible for the operation, stored in backends as "environment.exec.agent"
+	UserAgent string
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
@@ -0,0 +1,182 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optimport contains functional options to be used with stack import operations
+// github.com/sdk/v2/go/x/auto Stack.ImportResources(...optimport.Option)
+package optimport
+
+import (
+	"io"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+)
+
+// ImportResource describes an existing cloud resource to import into a stack.
+type ImportResource struct {
+	// Type is the type token of the resource, e.g. "aws:s3/bucket:Bucket".
+	Type string `json:"type"`
+	// Name is the name to give the resource in the stack.
+	Name string `json:"name"`
+	// ID is the provider's ID of the resource.
+	ID string `json:"id"`
+	// Parent (optional) is the name of the resource's parent in the name table.
+	Parent string `json:"parent,omitempty"`
+	// Provider (optional) is the name of the resource's provider in the name table.
+	Provider string `json:"provider,omitempty"`
+	// Version (optional) is the version of the provider plugin to use.
+	Version string `json:"version,omitempty"`
+	// PluginDownloadURL (optional) is the URL from which to download the provider plugin.
+	PluginDownloadURL string `json:"pluginDownloadUrl,omitempty"`
+	// Properties (optional) lists the properties of the resource to import. Defaults to the resource's required
+	// inputs.
+	Properties []string `json:"properties,omitempty"`
+}
+
+// Resources specifies the resources to import
+func Resources(resources []*ImportResource) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Resources = resources
+	})
+}
+
+// NameTable maps the names used by the Parent and Provider of imported resources to the URNs of existing resources
+func NameTable(nameTable map[string]string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.NameTable = nameTable
+	})
+}
+
+// Protect configures whether the imported resources are marked as protected. Defaults to true.
+func Protect(protect bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Protect = &protect
+	})
+}
+
+// GenerateCode configures whether to generate resource declaration code for the imported resources. Defaults to true.
+func GenerateCode(generateCode bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.GenerateCode = &generateCode
+	})
+}
+
+// Parallel is the number of resource operations to run in parallel at once during the import
+// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+func Parallel(n int) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Parallel = n
+	})
+}
+
+// Message (optional) to associate with the import operation
+func Message(message string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Message = message
+	})
+}
+
+// ProgressStreams allows specifying one or more io.Writers to redirect incremental import stdout
+func ProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ProgressStreams = writers
+	})
+}
+
+// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental import stderr
+func ErrorProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ErrorProgressStreams = writers
+	})
+}
+
+// EventStreams allows specifying one or more channels to receive the Pulumi event stream
+func EventStreams(channels ...chan<- events.EngineEvent) Option {
+	return optionFunc(func(opts *Options) {
+		opts.EventStreams = channels
+	})
+}
+
+// DebugLogging provides options for verbose logging to standard error, and enabling plugin logs.
+func DebugLogging(debugOpts debug.LoggingOptions) Option {
+	return optionFunc(func(opts *Options) {
+		opts.DebugLogOpts = debugOpts
+	})
+}
+
+// UserAgent specifies the agent responsible for the import, stored in backends as "environment.exec.agent"
+func UserAgent(agent string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.UserAgent = agent
+	})
+}
+
+// Color allows specifying whether to colorize output. Choices are: always, never, raw, auto (default "auto")
+func Color(color string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Color = color
+	})
+}
+
+// ShowSecrets configures whether to show config secrets when they appear.
+func ShowSecrets(show bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSecrets = &show
+	})
+}
+
+// Option is a parameter to be applied to a Stack.ImportResources() operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// The resources to import
+	Resources []*ImportResource
+	// Maps the names used by the Parent and Provider of imported resources to the URNs of existing resources
+	NameTable map[string]string
+	// Whether the imported resources are marked as protected. Defaults to true.
+	Protect *bool
+	// Whether to generate resource declaration code for the imported resources. Defaults to true.
+	GenerateCode *bool
+	// Parallel is the number of resource operations to run in parallel at once
+	// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+	Parallel int
+	// Message (optional) to associate with the import operation
+	Message string
+	// ProgressStreams allows specifying one or more io.Writers to redirect incremental import stdout
+	ProgressStreams []io.Writer
+	// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental import stderr
+	ErrorProgressStreams []io.Writer
+	// EventStreams allows specifying one or more channels to receive the Pulumi event stream
+	EventStreams []chan<- events.EngineEvent
+	// DebugLogOpts specifies additional settings for debug logging
+	DebugLogOpts debug.LoggingOptions
+	// UserAgent specifies the agent responsible for the import, stored in backends as "environment.exec.agent"
+	UserAgent string
+	// Colorize output. Choices are: always, never, raw, auto (default "auto")
+	Color string
+	// Show config secrets when they appear.
+	ShowSecrets *bool
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
@@ -115,6 +115,121 @@ func Plan(path string) Option {
 	})
 }
 
+// Color allows specifying whether to colorize output. Choices are: always, never, raw, auto (default "auto")
+func Color(color string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Color = color
+	})
+}
+
+// PolicyPacks runs one or more policy packs as part of this preview
+func PolicyPacks(packs []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PolicyPacks = packs
+	})
+}
+
+// PolicyPackConfigs specifies paths to JSON files containing the config for the policy pack of the
+// corresponding PolicyPacks entry
+func PolicyPackConfigs(packConfigs []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PolicyPackConfigs = packConfigs
+	})
+}
+
+// Refresh refreshes the state of the stack's resources before the preview
+func Refresh() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Refresh = true
+	})
+}
+
+// TargetReplace specifies resources to replace, which are also targeted by the preview
+func TargetReplace(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.TargetReplace = urns
+	})
+}
+
+// Exclude specifies a list of resource URNs to ignore during the preview
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also ignores the resources that depend on or are children of the excluded resources
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ConfigFile specifies the path to the stack configuration file to use instead of Pulumi.<stack>.yaml
+func ConfigFile(path string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ConfigFile = path
+	})
+}
+
+// ShowConfig shows configuration keys and variables in the progress output
+func ShowConfig() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowConfig = true
+	})
+}
+
+// ShowReplacementSteps shows the detailed resource replacement creates and deletes in the progress output
+func ShowReplacementSteps() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReplacementSteps = true
+	})
+}
+
+// ShowSames shows resources that don't need to be updated in the progress output
+func ShowSames() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSames = true
+	})
+}
+
+// ShowReads shows resources that are being read in, alongside those being managed directly
+func ShowReads() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReads = true
+	})
+}
+
+// SuppressOutputs suppresses the display of stack outputs, in case they contain sensitive values
+func SuppressOutputs() Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressOutputs = true
+	})
+}
+
+// SuppressPermalink configures whether to suppress the display of the state permalink. Defaults to
+// suppressing it for self-managed backends.
+func SuppressPermalink(suppress bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressPermalink = &suppress
+	})
+}
+
+// ShowSecrets emits secrets in plaintext in the plan file saved with Plan
+func ShowSecrets() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSecrets = true
+	})
+}
+
+// ValidatePlan checks the preview against the plan saved at the given path, reporting every resource and
+// operation that diverges from it
+func ValidatePlan(path string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ValidatePlan = path
+	})
+}
+
 // Option is a parameter to be applied to a Stack.Preview() operation
 type Option interface {
 	ApplyOption(*Options)
@@ -157,6 +272,32 @@ type Options struct {
 	PolicyPacks []string
 	// Path to JSON file containing the config for the policy pack of the corresponding "--policy-pack" flag
 	PolicyPackConfigs []string
+	// Refresh the state of the stack's resources before the preview
+	Refresh bool
+	// Specify resources to replace, which are also targeted by the preview
+	TargetReplace []string
+	// Specify a list of resource URNs to ignore
+	Exclude []string
+	// Also ignore the resources that depend on or are children of the excluded resources
+	ExcludeDependents bool
+	// Use the stack configuration file at this path instead of Pulumi.<stack>.yaml
+	ConfigFile string
+	// Show configuration keys and variables in the progress output
+	ShowConfig bool
+	// Show the detailed resource replacement creates and deletes in the progress output
+	ShowReplacementSteps bool
+	// Show resources that don't need to be updated in the progress output
+	ShowSames bool
+	// Show resources that are being read in, alongside those being managed directly
+	ShowReads bool
+	// Suppress the display of stack outputs
+	SuppressOutputs bool
+	// Suppress the display of the state permalink
+	SuppressPermalink *bool
+	// Emit secrets in plaintext in the saved plan file
+	ShowSecrets bool
+	// Check the preview against the plan saved at this path
+	ValidatePlan string
 }
 
 type optionFunc func(*Options)
@@ -18,6 +18,7 @@ package optrefresh
 
 import (
 	"io"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
@@ -94,6 +95,107 @@ func ShowSecrets(show bool) Option {
 	})
 }
 
+// Color allows specifying whether to colorize output. Choices are: always, never, raw, auto (default "auto")
+func Color(color string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Color = color
+	})
+}
+
+// Diff displays operation as a rich diff showing the overall change
+func Diff() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Diff = true
+	})
+}
+
+// Exclude specifies a list of resource URNs to ignore during the refresh
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also ignores the resources that depend on or are children of the excluded resources
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ConfigFile specifies the path to the stack configuration file to use instead of Pulumi.<stack>.yaml
+func ConfigFile(path string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ConfigFile = path
+	})
+}
+
+// ShowReplacementSteps shows the detailed resource replacement creates and deletes in the progress output
+func ShowReplacementSteps() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReplacementSteps = true
+	})
+}
+
+// ShowSames shows resources that don't need to be updated in the progress output
+func ShowSames() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSames = true
+	})
+}
+
+// SuppressOutputs suppresses the display of stack outputs, in case they contain sensitive values
+func SuppressOutputs() Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressOutputs = true
+	})
+}
+
+// SuppressPermalink configures whether to suppress the display of the state permalink. Defaults to
+// suppressing it for self-managed backends.
+func SuppressPermalink(suppress bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressPermalink = &suppress
+	})
+}
+
+// Timeout stops the refresh if it hasn't finished after the given duration. Operations still running are
+// given the TimeoutGracePeriod to finish, and are recorded as pending operations otherwise.
+func Timeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Timeout = timeout
+	})
+}
+
+// TimeoutGracePeriod specifies how long to wait for running operations to finish once the Timeout has passed
+func TimeoutGracePeriod(gracePeriod time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.TimeoutGracePeriod = gracePeriod
+	})
+}
+
+// SkipPendingCreates leaves the stack's pending creates in place rather than refreshing them
+func SkipPendingCreates() Option {
+	return optionFunc(func(opts *Options) {
+		opts.SkipPendingCreates = true
+	})
+}
+
+// ClearPendingCreates drops the stack's pending creates from its state
+func ClearPendingCreates() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ClearPendingCreates = true
+	})
+}
+
+// ImportPendingCreates imports the stack's pending creates, mapping the URN of each to the provider ID of the
+// resource that it created
+func ImportPendingCreates(ids map[string]string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ImportPendingCreates = ids
+	})
+}
+
 // Option is a parameter to be applied to a Stack.Refresh() operation
 type Option interface {
 	ApplyOption(*Options)
@@ -126,6 +228,32 @@ type Options struct {
 	Color string
 	// Show config secrets when they appear.
 	ShowSecrets *bool
+	// Diff displays operation as a rich diff showing the overall change
+	Diff bool
+	// Specify a list of resource URNs to ignore
+	Exclude []string
+	// Also ignore the resources that depend on or are children of the excluded resources
+	ExcludeDependents bool
+	// Use the stack configuration file at this path instead of Pulumi.<stack>.yaml
+	ConfigFile string
+	// Show the detailed resource replacement creates and deletes in the progress output
+	ShowReplacementSteps bool
+	// Show resources that don't need to be updated in the progress output
+	ShowSames bool
+	// Suppress the display of stack outputs
+	SuppressOutputs bool
+	// Suppress the display of the state permalink
+	SuppressPermalink *bool
+	// Stop the refresh if it hasn't finished after this long
+	Timeout time.Duration
+	// How long to wait for running operations to finish once the Timeout has passed
+	TimeoutGracePeriod time.Duration
+	// Leave the stack's pending creates in place
+	SkipPendingCreates bool
+	// Drop the stack's pending creates from its state
+	ClearPendingCreates bool
+	// Import pending creates, mapping the URN of each to the provider ID of the resource that it created
+	ImportPendingCreates map[string]string
 }
 
 type optionFunc func(*Options)
@@ -0,0 +1,63 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optstatedelete contains functional options to be used with stack state delete operations
+// github.com/sdk/v2/go/x/auto Stack.StateDelete(...optstatedelete.Option)
+package optstatedelete
+
+// Force causes protected resources to be deleted from the state
+func Force() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Force = true
+	})
+}
+
+// TargetDependents causes the resources that depend on the deleted resources to be deleted as well
+func TargetDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.TargetDependents = true
+	})
+}
+
+// Select deletes every resource matching the given selectors, in addition to any resources given by URN.
+// See `pulumi state --help` for the selector syntax.
+func Select(selectors ...string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Select = append(opts.Select, selectors...)
+	})
+}
+
+// Option is a parameter to be applied to a Stack.StateDelete() operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// Delete protected resources
+	Force bool
+	// Delete the resources that depend on the deleted resources as well
+	TargetDependents bool
+	// Selectors matching resources to delete
+	Select []string
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
@@ -0,0 +1,54 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optstateunprotect contains functional options to be used with stack state unprotect operations
+// github.com/sdk/v2/go/x/auto Stack.StateUnprotect(...optstateunprotect.Option)
+package optstateunprotect
+
+// All unprotects every resource in the stack
+func All() Option {
+	return optionFunc(func(opts *Options) {
+		opts.All = true
+	})
+}
+
+// Select unprotects every resource matching the given selectors, in addition to any resources given by URN.
+// See `pulumi state --help` for the selector syntax.
+func Select(selectors ...string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Select = append(opts.Select, selectors...)
+	})
+}
+
+// Option is a parameter to be applied to a Stack.StateUnprotect() operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// Unprotect every resource in the stack
+	All bool
+	// Selectors matching resources to unprotect
+	Select []string
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
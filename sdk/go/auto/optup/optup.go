@@ -18,6 +18,7 @@ package optup
 
 import (
 	"io"
+	"time"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
@@ -122,6 +123,136 @@ func ShowSecrets(show bool) Option {
 	})
 }
 
+// Color allows specifying whether to colorize output. Choices are: always, never, raw, auto (default "auto")
+func Color(color string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Color = color
+	})
+}
+
+// PolicyPacks runs one or more policy packs as part of this update
+func PolicyPacks(packs []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PolicyPacks = packs
+	})
+}
+
+// PolicyPackConfigs specifies paths to JSON files containing the config for the policy pack of the
+// corresponding PolicyPacks entry
+func PolicyPackConfigs(packConfigs []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PolicyPackConfigs = packConfigs
+	})
+}
+
+// Refresh refreshes the state of the stack's resources before the update
+func Refresh() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Refresh = true
+	})
+}
+
+// TargetReplace specifies resources to replace, which are also targeted by the update
+func TargetReplace(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.TargetReplace = urns
+	})
+}
+
+// Exclude specifies a list of resource URNs to ignore during the update
+func Exclude(urns []string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Exclude = urns
+	})
+}
+
+// ExcludeDependents also ignores the resources that depend on or are children of the excluded resources
+func ExcludeDependents() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ExcludeDependents = true
+	})
+}
+
+// ContinueOnError continues with the resources that no failed resource depends on, reporting all
+// failures at the end
+func ContinueOnError() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ContinueOnError = true
+	})
+}
+
+// ConfigFile specifies the path to the stack configuration file to use instead of Pulumi.<stack>.yaml
+func ConfigFile(path string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ConfigFile = path
+	})
+}
+
+// ShowConfig shows configuration keys and variables in the progress output
+func ShowConfig() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowConfig = true
+	})
+}
+
+// ShowReplacementSteps shows the detailed resource replacement creates and deletes in the progress output
+func ShowReplacementSteps() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReplacementSteps = true
+	})
+}
+
+// ShowSames shows resources that don't need to be updated in the progress output
+func ShowSames() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSames = true
+	})
+}
+
+// ShowReads shows resources that are being read in, alongside those being managed directly
+func ShowReads() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReads = true
+	})
+}
+
+// ShowFullOutput configures whether to display the full length of stack outputs. Defaults to true.
+func ShowFullOutput(show bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowFullOutput = &show
+	})
+}
+
+// SuppressOutputs suppresses the display of stack outputs, in case they contain sensitive values
+func SuppressOutputs() Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressOutputs = true
+	})
+}
+
+// SuppressPermalink configures whether to suppress the display of the state permalink. Defaults to
+// suppressing it for self-managed backends.
+func SuppressPermalink(suppress bool) Option {
+	return optionFunc(func(opts *Options) {
+		opts.SuppressPermalink = &suppress
+	})
+}
+
+// Timeout stops the update if it hasn't finished after the given duration. Operations still running are
+// given the TimeoutGracePeriod to finish, and are recorded as pending operations otherwise.
+func Timeout(timeout time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Timeout = timeout
+	})
+}
+
+// TimeoutGracePeriod specifies how long to wait for running operations to finish once the Timeout has passed
+func TimeoutGracePeriod(gracePeriod time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.TimeoutGracePeriod = gracePeriod
+	})
+}
+
 // Option is a parameter to be applied to a Stack.Up() operation
 type Option interface {
 	ApplyOption(*Options)
@@ -166,6 +297,36 @@ type Options struct {
 	PolicyPackConfigs []string
 	// Show config secrets when they appear.
 	ShowSecrets *bool
+	// Refresh the state of the stack's resources before the update
+	Refresh bool
+	// Specify resources to replace, which are also targeted by the update
+	TargetReplace []string
+	// Specify a list of resource URNs to ignore
+	Exclude []string
+	// Also ignore the resources that depend on or are children of the excluded resources
+	ExcludeDependents bool
+	// Continue with the resources that no failed resource depends on, reporting all failures at the end
+	ContinueOnError bool
+	// Use the stack configuration file at this path instead of Pulumi.<stack>.yaml
+	ConfigFile string
+	// Show configuration keys and variables in the progress output
+	ShowConfig bool
+	// Show the detailed resource replacement creates and deletes in the progress output
+	ShowReplacementSteps bool
+	// Show resources that don't need to be updated in the progress output
+	ShowSames bool
+	// Show resources that are being read in, alongside those being managed directly
+	ShowReads bool
+	// Display the full length of stack outputs. Defaults to true.
+	ShowFullOutput *bool
+	// Suppress the display of stack outputs
+	SuppressOutputs bool
+	// Suppress the display of the state permalink
+	SuppressPermalink *bool
+	// Stop the update if it hasn't finished after this long
+	Timeout time.Duration
+	// How long to wait for running operations to finish once the Timeout has passed
+	TimeoutGracePeriod time.Duration
 }
 
 type optionFunc func(*Options)
@@ -0,0 +1,203 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package optwatch contains functional options to be used with stack watch operations
+// github.com/sdk/v2/go/x/auto Stack.Watch(...optwatch.Option)
+package optwatch
+
+import (
+	"io"
+	"time"
+
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
+)
+
+// Paths specifies the files or directories to watch for changes, relative to the workspace's working directory.
+// Defaults to the working directory for programs run from source; inline programs are not watched by default.
+func Paths(paths ...string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Paths = append(opts.Paths, paths...)
+	})
+}
+
+// PollInterval is how often the watched paths are checked for changes. Defaults to 500ms.
+func PollInterval(interval time.Duration) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PollInterval = interval
+	})
+}
+
+// Trigger specifies a channel that triggers an update each time it receives a value, e.g. when the source of an
+// inline program changes.
+func Trigger(trigger <-chan struct{}) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Trigger = trigger
+	})
+}
+
+// Message (optional) to associate with each update operation
+func Message(message string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Message = message
+	})
+}
+
+// Parallel is the number of resource operations to run in parallel at once during each update
+// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+func Parallel(n int) Option {
+	return optionFunc(func(opts *Options) {
+		opts.Parallel = n
+	})
+}
+
+// PolicyPacks runs one or more policy packs as part of each update
+func PolicyPacks(packs ...string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PolicyPacks = append(opts.PolicyPacks, packs...)
+	})
+}
+
+// PolicyPackConfigs specifies paths to JSON files containing the config for the policy pack of the corresponding
+// PolicyPacks option
+func PolicyPackConfigs(configs ...string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.PolicyPackConfigs = append(opts.PolicyPackConfigs, configs...)
+	})
+}
+
+// Refresh will run a refresh before each update.
+func Refresh() Option {
+	return optionFunc(func(opts *Options) {
+		opts.Refresh = true
+	})
+}
+
+// ConfigFile specifies a file to use for configuration values rather than detecting the file name
+func ConfigFile(path string) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ConfigFile = path
+	})
+}
+
+// ShowConfig shows configuration keys and variables
+func ShowConfig() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowConfig = true
+	})
+}
+
+// ShowReplacementSteps shows detailed resource replacement creates and deletes
+func ShowReplacementSteps() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowReplacementSteps = true
+	})
+}
+
+// ShowSames shows resources that don't need to be updated because they haven't changed
+func ShowSames() Option {
+	return optionFunc(func(opts *Options) {
+		opts.ShowSames = true
+	})
+}
+
+// ProgressStreams allows specifying one or more io.Writers to redirect incremental update stdout
+func ProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ProgressStreams = writers
+	})
+}
+
+// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental update stderr
+func ErrorProgressStreams(writers ...io.Writer) Option {
+	return optionFunc(func(opts *Options) {
+		opts.ErrorProgressStreams = writers
+	})
+}
+
+// EventStreams allows specifying one or more channels to receive the Pulumi event stream of every update.
+// The channels are closed once the watch ends.
+func EventStreams(channels ...chan<- events.EngineEvent) Option {
+	return optionFunc(func(opts *Options) {
+		opts.EventStreams = channels
+	})
+}
+
+// UpdateResults allows specifying one or more channels to receive the result of each update: nil if it succeeded,
+// or the error it failed with. The channels are closed once the watch ends.
+func UpdateResults(channels ...chan<- error) Option {
+	return optionFunc(func(opts *Options) {
+		opts.UpdateResults = channels
+	})
+}
+
+// DebugLogging provides options for verbose logging to standard error, and enabling plugin logs.
+func DebugLogging(debugOpts debug.LoggingOptions) Option {
+	return optionFunc(func(opts *Options) {
+		opts.DebugLogOpts = debugOpts
+	})
+}
+
+// Option is a parameter to be applied to a Stack.Watch() operation
+type Option interface {
+	ApplyOption(*Options)
+}
+
+// ---------------------------------- implementation details ----------------------------------
+
+// Options is an implementation detail
+type Options struct {
+	// Files or directories to watch for changes
+	Paths []string
+	// How often the watched paths are checked for changes
+	PollInterval time.Duration
+	// Triggers an update each time it receives a value
+	Trigger <-chan struct{}
+	// Message (optional) to associate with each update operation
+	Message string
+	// Parallel is the number of resource operations to run in parallel at once
+	// (1 for no parallelism). Defaults to unbounded. (default 2147483647)
+	Parallel int
+	// Run one or more policy packs as part of each update
+	PolicyPacks []string
+	// Path to JSON file containing the config for the policy pack of the corresponding "--policy-pack" flag
+	PolicyPackConfigs []string
+	// Refresh will run a refresh before each update
+	Refresh bool
+	// Use the configuration values in the specified file rather than detecting the file name
+	ConfigFile string
+	// Show configuration keys and variables
+	ShowConfig bool
+	// Show detailed resource replacement creates and deletes
+	ShowReplacementSteps bool
+	// Show resources that don't need to be updated because they haven't changed
+	ShowSames bool
+	// ProgressStreams allows specifying one or more io.Writers to redirect incremental update stdout
+	ProgressStreams []io.Writer
+	// ErrorProgressStreams allows specifying one or more io.Writers to redirect incremental update stderr
+	ErrorProgressStreams []io.Writer
+	// EventStreams allows specifying one or more channels to receive the Pulumi event stream of every update
+	EventStreams []chan<- events.EngineEvent
+	// UpdateResults allows specifying one or more channels to receive the result of each update
+	UpdateResults []chan<- error
+	// DebugLogOpts specifies additional settings for debug logging
+	DebugLogOpts debug.LoggingOptions
+}
+
+type optionFunc func(*Options)
+
+// ApplyOption is an implementation detail
+func (o optionFunc) ApplyOption(opts *Options) {
+	o(opts)
+}
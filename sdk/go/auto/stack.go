@@ -101,12 +101,15 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	pbempty "github.com/golang/protobuf/ptypes/empty"
 	"github.com/nxadm/tail"
@@ -115,12 +118,18 @@ import (
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/debug"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdestroy"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optdrift"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/opthistory"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optimport"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optrefresh"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optstatedelete"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optstateunprotect"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optwatch"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/constant"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/display"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil"
@@ -254,6 +263,45 @@ func (s *Stack) Preview(ctx context.Context, opts ...optpreview.Option) (Preview
 	if preOpts.Plan != "" {
 		sharedArgs = append(sharedArgs, fmt.Sprintf("--save-plan=%s", preOpts.Plan))
 	}
+	if preOpts.ShowSecrets {
+		sharedArgs = append(sharedArgs, "--show-secrets")
+	}
+	if preOpts.ValidatePlan != "" {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--plan=%s", preOpts.ValidatePlan))
+	}
+	if preOpts.Refresh {
+		sharedArgs = append(sharedArgs, "--refresh")
+	}
+	for _, rURN := range preOpts.TargetReplace {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--target-replace=%s", rURN))
+	}
+	for _, eURN := range preOpts.Exclude {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if preOpts.ExcludeDependents {
+		sharedArgs = append(sharedArgs, "--exclude-dependents")
+	}
+	if preOpts.ConfigFile != "" {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--config-file=%s", preOpts.ConfigFile))
+	}
+	if preOpts.ShowConfig {
+		sharedArgs = append(sharedArgs, "--show-config")
+	}
+	if preOpts.ShowReplacementSteps {
+		sharedArgs = append(sharedArgs, "--show-replacement-steps")
+	}
+	if preOpts.ShowSames {
+		sharedArgs = append(sharedArgs, "--show-sames")
+	}
+	if preOpts.ShowReads {
+		sharedArgs = append(sharedArgs, "--show-reads")
+	}
+	if preOpts.SuppressOutputs {
+		sharedArgs = append(sharedArgs, "--suppress-outputs")
+	}
+	if preOpts.SuppressPermalink != nil {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--suppress-permalink=%t", *preOpts.SuppressPermalink))
+	}
 
 	// Apply the remote args, if needed.
 	sharedArgs = append(sharedArgs, s.remoteArgs()...)
@@ -376,6 +424,51 @@ func (s *Stack) Up(ctx context.Context, opts ...optup.Option) (UpResult, error)
 	if upOpts.Plan != "" {
 		sharedArgs = append(sharedArgs, fmt.Sprintf("--plan=%s", upOpts.Plan))
 	}
+	if upOpts.Refresh {
+		sharedArgs = append(sharedArgs, "--refresh")
+	}
+	for _, rURN := range upOpts.TargetReplace {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--target-replace=%s", rURN))
+	}
+	for _, eURN := range upOpts.Exclude {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if upOpts.ExcludeDependents {
+		sharedArgs = append(sharedArgs, "--exclude-dependents")
+	}
+	if upOpts.ContinueOnError {
+		sharedArgs = append(sharedArgs, "--continue-on-error")
+	}
+	if upOpts.ConfigFile != "" {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--config-file=%s", upOpts.ConfigFile))
+	}
+	if upOpts.ShowConfig {
+		sharedArgs = append(sharedArgs, "--show-config")
+	}
+	if upOpts.ShowReplacementSteps {
+		sharedArgs = append(sharedArgs, "--show-replacement-steps")
+	}
+	if upOpts.ShowSames {
+		sharedArgs = append(sharedArgs, "--show-sames")
+	}
+	if upOpts.ShowReads {
+		sharedArgs = append(sharedArgs, "--show-reads")
+	}
+	if upOpts.ShowFullOutput != nil {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--show-full-output=%t", *upOpts.ShowFullOutput))
+	}
+	if upOpts.SuppressOutputs {
+		sharedArgs = append(sharedArgs, "--suppress-outputs")
+	}
+	if upOpts.SuppressPermalink != nil {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--suppress-permalink=%t", *upOpts.SuppressPermalink))
+	}
+	if upOpts.Timeout > 0 {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--timeout=%s", upOpts.Timeout))
+	}
+	if upOpts.TimeoutGracePeriod > 0 {
+		sharedArgs = append(sharedArgs, fmt.Sprintf("--timeout-grace-period=%s", upOpts.TimeoutGracePeriod))
+	}
 
 	// Apply the remote args, if needed.
 	sharedArgs = append(sharedArgs, s.remoteArgs()...)
@@ -472,6 +565,52 @@ func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) (Refresh
 	if refreshOpts.Color != "" {
 		args = append(args, fmt.Sprintf("--color=%s", refreshOpts.Color))
 	}
+	if refreshOpts.Diff {
+		args = append(args, "--diff")
+	}
+	for _, eURN := range refreshOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if refreshOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if refreshOpts.ConfigFile != "" {
+		args = append(args, fmt.Sprintf("--config-file=%s", refreshOpts.ConfigFile))
+	}
+	if refreshOpts.ShowReplacementSteps {
+		args = append(args, "--show-replacement-steps")
+	}
+	if refreshOpts.ShowSames {
+		args = append(args, "--show-sames")
+	}
+	if refreshOpts.SuppressOutputs {
+		args = append(args, "--suppress-outputs")
+	}
+	if refreshOpts.SuppressPermalink != nil {
+		args = append(args, fmt.Sprintf("--suppress-permalink=%t", *refreshOpts.SuppressPermalink))
+	}
+	if refreshOpts.Timeout > 0 {
+		args = append(args, fmt.Sprintf("--timeout=%s", refreshOpts.Timeout))
+	}
+	if refreshOpts.TimeoutGracePeriod > 0 {
+		args = append(args, fmt.Sprintf("--timeout-grace-period=%s", refreshOpts.TimeoutGracePeriod))
+	}
+	if refreshOpts.SkipPendingCreates {
+		args = append(args, "--skip-pending-creates")
+	}
+	if refreshOpts.ClearPendingCreates {
+		args = append(args, "--clear-pending-creates")
+	}
+	urns := make([]string, 0, len(refreshOpts.ImportPendingCreates))
+	for urn := range refreshOpts.ImportPendingCreates {
+		urns = append(urns, urn)
+	}
+	sort.Strings(urns)
+	for _, urn := range urns {
+		// Each pending create is given by its URN followed by the ID of the resource that it created.
+		id := refreshOpts.ImportPendingCreates[urn]
+		args = append(args, "--import-pending-creates="+urn, "--import-pending-creates="+id)
+	}
 	execKind := constant.ExecKindAutoLocal
 	if s.Workspace().Program() != nil {
 		execKind = constant.ExecKindAutoInline
@@ -529,6 +668,77 @@ func (s *Stack) Refresh(ctx context.Context, opts ...optrefresh.Option) (Refresh
 	return res, nil
 }
 
+// driftExitCode is the code that `pulumi drift` exits with when it detects drift.
+const driftExitCode = 3
+
+// Drift reads the current state of each resource in the stack from its provider, without modifying the stack's
+// state, and reports which resources are in sync, have drifted, or were deleted out-of-band. Detecting drift is not an
+// error; use DriftResult.HasDrift to check whether any was found.
+func (s *Stack) Drift(ctx context.Context, opts ...optdrift.Option) (DriftResult, error) {
+	var res DriftResult
+
+	driftOpts := &optdrift.Options{}
+	for _, o := range opts {
+		o.ApplyOption(driftOpts)
+	}
+
+	args := make([]string, 0, len(driftOpts.Target)+len(driftOpts.Exclude))
+
+	args = debug.AddArgs(&driftOpts.DebugLogOpts, args)
+	args = append(args, "drift", "--json")
+	if driftOpts.Message != "" {
+		args = append(args, fmt.Sprintf("--message=%q", driftOpts.Message))
+	}
+	for _, tURN := range driftOpts.Target {
+		args = append(args, fmt.Sprintf("--target=%s", tURN))
+	}
+	for _, eURN := range driftOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if driftOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if driftOpts.Parallel > 0 {
+		args = append(args, fmt.Sprintf("--parallel=%d", driftOpts.Parallel))
+	}
+	if driftOpts.UserAgent != "" {
+		args = append(args, fmt.Sprintf("--exec-agent=%s", driftOpts.UserAgent))
+	}
+	execKind := constant.ExecKindAutoLocal
+	if s.Workspace().Program() != nil {
+		execKind = constant.ExecKindAutoInline
+	}
+	args = append(args, fmt.Sprintf("--exec-kind=%s", execKind))
+
+	if len(driftOpts.EventStreams) > 0 {
+		eventChannels := driftOpts.EventStreams
+		t, err := tailLogs("drift", eventChannels)
+		if err != nil {
+			return res, fmt.Errorf("failed to tail logs: %w", err)
+		}
+		defer t.Close()
+		args = append(args, "--event-log", t.Filename)
+	}
+
+	stdout, stderr, code, err := s.runPulumiCmdSync(
+		ctx,
+		driftOpts.ProgressStreams,      /* additionalOutputs */
+		driftOpts.ErrorProgressStreams, /* additionalErrorOutputs */
+		args...,
+	)
+	if err != nil && code != driftExitCode {
+		return res, newAutoError(fmt.Errorf("failed to detect drift: %w", err), stdout, stderr, code)
+	}
+
+	res.StdOut = stdout
+	res.StdErr = stderr
+	if err := json.Unmarshal([]byte(stdout), &res.Report); err != nil {
+		return res, newAutoError(fmt.Errorf("unable to unmarshal drift report: %w", err), stdout, stderr, code)
+	}
+
+	return res, nil
+}
+
 // Destroy deletes all resources in a stack, leaving all history and configuration intact.
 func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (DestroyResult, error) {
 	var res DestroyResult
@@ -560,6 +770,51 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 	if destroyOpts.Color != "" {
 		args = append(args, fmt.Sprintf("--color=%s", destroyOpts.Color))
 	}
+	if destroyOpts.Diff {
+		args = append(args, "--diff")
+	}
+	if destroyOpts.Refresh {
+		args = append(args, "--refresh")
+	}
+	for _, eURN := range destroyOpts.Exclude {
+		args = append(args, fmt.Sprintf("--exclude=%s", eURN))
+	}
+	if destroyOpts.ExcludeDependents {
+		args = append(args, "--exclude-dependents")
+	}
+	if destroyOpts.ExcludeProtected {
+		args = append(args, "--exclude-protected")
+	}
+	if destroyOpts.ContinueOnError {
+		args = append(args, "--continue-on-error")
+	}
+	if destroyOpts.ConfigFile != "" {
+		args = append(args, fmt.Sprintf("--config-file=%s", destroyOpts.ConfigFile))
+	}
+	if destroyOpts.ShowConfig {
+		args = append(args, "--show-config")
+	}
+	if destroyOpts.ShowReplacementSteps {
+		args = append(args, "--show-replacement-steps")
+	}
+	if destroyOpts.ShowSames {
+		args = append(args, "--show-sames")
+	}
+	if destroyOpts.SuppressOutputs {
+		args = append(args, "--suppress-outputs")
+	}
+	if destroyOpts.SuppressPermalink != nil {
+		args = append(args, fmt.Sprintf("--suppress-permalink=%t", *destroyOpts.SuppressPermalink))
+	}
+	if destroyOpts.Timeout > 0 {
+		args = append(args, fmt.Sprintf("--timeout=%s", destroyOpts.Timeout))
+	}
+	if destroyOpts.TimeoutGracePeriod > 0 {
+		args = append(args, fmt.Sprintf("--timeout-grace-period=%s", destroyOpts.TimeoutGracePeriod))
+	}
+	if destroyOpts.Remove {
+		args = append(args, "--remove")
+	}
 	execKind := constant.ExecKindAutoLocal
 	if s.Workspace().Program() != nil {
 		execKind = constant.ExecKindAutoInline
@@ -589,6 +844,15 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 		return res, newAutoError(fmt.Errorf("failed to destroy stack: %w", err), stdout, stderr, code)
 	}
 
+	// A removed stack has no history to summarize the destroy with.
+	if destroyOpts.Remove {
+		res = DestroyResult{
+			StdOut: stdout,
+			StdErr: stderr,
+		}
+		return res, nil
+	}
+
 	historyOpts := []opthistory.Option{}
 	if showSecrets := destroyOpts.ShowSecrets; showSecrets != nil {
 		historyOpts = append(historyOpts, opthistory.ShowSecrets(*showSecrets))
@@ -617,6 +881,142 @@ func (s *Stack) Destroy(ctx context.Context, opts ...optdestroy.Option) (Destroy
 	return res, nil
 }
 
+// Watch updates the stack, and then updates it again each time the program changes, until ctx is cancelled.
+// Programs run from source are watched for changes to the files in their working directory, or in the paths given
+// by optwatch.Paths. Inline programs are updated each time the channel given by optwatch.Trigger receives a value.
+// Changes made while an update is running are coalesced into a single follow-up update. A failed update does not
+// end the watch; its error is sent to the channels given by optwatch.UpdateResults instead.
+// Watch returns once ctx is cancelled, interrupting any update in progress.
+func (s *Stack) Watch(ctx context.Context, opts ...optwatch.Option) error {
+	watchOpts := &optwatch.Options{}
+	for _, o := range opts {
+		o.ApplyOption(watchOpts)
+	}
+	defer func() {
+		for _, ch := range watchOpts.EventStreams {
+			close(ch)
+		}
+		for _, ch := range watchOpts.UpdateResults {
+			close(ch)
+		}
+	}()
+
+	paths := append([]string(nil), watchOpts.Paths...)
+	if len(paths) == 0 && s.Workspace().Program() == nil {
+		paths = []string{s.Workspace().WorkDir()}
+	}
+	for i, path := range paths {
+		if !filepath.IsAbs(path) {
+			paths[i] = filepath.Join(s.Workspace().WorkDir(), path)
+		}
+		if _, err := os.Stat(paths[i]); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+	}
+
+	interval := watchOpts.PollInterval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+
+	// triggers holds at most one pending update, so that triggers received during an update are coalesced.
+	triggers := make(chan struct{}, 1)
+	trigger := func() {
+		select {
+		case triggers <- struct{}{}:
+		default:
+		}
+	}
+	trigger()
+	if len(paths) > 0 {
+		go pollPaths(ctx, paths, interval, trigger)
+	}
+	if watchOpts.Trigger != nil {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case _, ok := <-watchOpts.Trigger:
+					if !ok {
+						return
+					}
+					trigger()
+				}
+			}
+		}()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-triggers:
+		}
+
+		err := s.watchUpdate(ctx, watchOpts)
+		if ctx.Err() != nil {
+			return nil
+		}
+		for _, ch := range watchOpts.UpdateResults {
+			select {
+			case ch <- err:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+// watchUpdate runs a single update of a Stack.Watch operation, forwarding its events to the watch's event streams.
+func (s *Stack) watchUpdate(ctx context.Context, watchOpts *optwatch.Options) error {
+	upOpts := []optup.Option{
+		optup.Message(watchOpts.Message),
+		optup.Parallel(watchOpts.Parallel),
+		optup.PolicyPacks(watchOpts.PolicyPacks),
+		optup.PolicyPackConfigs(watchOpts.PolicyPackConfigs),
+		optup.ConfigFile(watchOpts.ConfigFile),
+		optup.ProgressStreams(watchOpts.ProgressStreams...),
+		optup.ErrorProgressStreams(watchOpts.ErrorProgressStreams...),
+		optup.DebugLogging(watchOpts.DebugLogOpts),
+	}
+	if watchOpts.Refresh {
+		upOpts = append(upOpts, optup.Refresh())
+	}
+	if watchOpts.ShowConfig {
+		upOpts = append(upOpts, optup.ShowConfig())
+	}
+	if watchOpts.ShowReplacementSteps {
+		upOpts = append(upOpts, optup.ShowReplacementSteps())
+	}
+	if watchOpts.ShowSames {
+		upOpts = append(upOpts, optup.ShowSames())
+	}
+
+	// Each update closes its event stream when it finishes, so forward its events to the watch's streams, which
+	// stay open across updates.
+	var forwarded chan struct{}
+	if len(watchOpts.EventStreams) > 0 {
+		ch := make(chan events.EngineEvent)
+		forwarded = make(chan struct{})
+		go func() {
+			defer close(forwarded)
+			for e := range ch {
+				for _, stream := range watchOpts.EventStreams {
+					stream <- e
+				}
+			}
+		}()
+		upOpts = append(upOpts, optup.EventStreams(ch))
+	}
+
+	_, err := s.Up(ctx, upOpts...)
+	if forwarded != nil {
+		<-forwarded
+	}
+	return err
+}
+
 // Outputs get the current set of Stack outputs from the last Stack.Up().
 func (s *Stack) Outputs(ctx context.Context) (OutputMap, error) {
 	return s.Workspace().StackOutputs(ctx, s.Name())
@@ -795,6 +1195,340 @@ func (s *Stack) Import(ctx context.Context, state apitype.UntypedDeployment) err
 	return s.Workspace().ImportStack(ctx, s.Name(), state)
 }
 
+// ImportResources imports existing cloud resources into the stack, so that they are managed by Pulumi from then on.
+// Unless disabled with optimport.GenerateCode(false), the result contains the code declaring the imported resources,
+// which should be added to the program so that the next Stack.Up() doesn't delete them.
+func (s *Stack) ImportResources(ctx context.Context, opts ...optimport.Option) (ImportResult, error) {
+	var res ImportResult
+
+	importOpts := &optimport.Options{}
+	for _, o := range opts {
+		o.ApplyOption(importOpts)
+	}
+
+	tempDir, err := os.MkdirTemp("", "pulumi-import-")
+	if err != nil {
+		return res, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	importFile, err := json.Marshal(struct {
+		NameTable map[string]string           `json:"nameTable,omitempty"`
+		Resources []*optimport.ImportResource `json:"resources"`
+	}{
+		NameTable: importOpts.NameTable,
+		Resources: importOpts.Resources,
+	})
+	if err != nil {
+		return res, fmt.Errorf("failed to marshal import file: %w", err)
+	}
+	importFilePath := filepath.Join(tempDir, "import.json")
+	if err := os.WriteFile(importFilePath, importFile, 0o600); err != nil {
+		return res, fmt.Errorf("failed to write import file: %w", err)
+	}
+
+	var args []string
+	args = debug.AddArgs(&importOpts.DebugLogOpts, args)
+	args = append(args, "import", "--yes", "--skip-preview", "--file", importFilePath)
+
+	generateCode := importOpts.GenerateCode == nil || *importOpts.GenerateCode
+	generatedCodePath := filepath.Join(tempDir, "generated.txt")
+	if generateCode {
+		args = append(args, "--out", generatedCodePath)
+	} else {
+		args = append(args, "--generate-code=false")
+	}
+	if importOpts.Protect != nil {
+		args = append(args, fmt.Sprintf("--protect=%t", *importOpts.Protect))
+	}
+	if importOpts.Message != "" {
+		args = append(args, fmt.Sprintf("--message=%q", importOpts.Message))
+	}
+	if importOpts.Parallel > 0 {
+		args = append(args, fmt.Sprintf("--parallel=%d", importOpts.Parallel))
+	}
+	if importOpts.UserAgent != "" {
+		args = append(args, fmt.Sprintf("--exec-agent=%s", importOpts.UserAgent))
+	}
+	if importOpts.Color != "" {
+		args = append(args, fmt.Sprintf("--color=%s", importOpts.Color))
+	}
+	execKind := constant.ExecKindAutoLocal
+	if s.Workspace().Program() != nil {
+		execKind = constant.ExecKindAutoInline
+	}
+	args = append(args, fmt.Sprintf("--exec-kind=%s", execKind))
+
+	if len(importOpts.EventStreams) > 0 {
+		eventChannels := importOpts.EventStreams
+		t, err := tailLogs("import", eventChannels)
+		if err != nil {
+			return res, fmt.Errorf("failed to tail logs: %w", err)
+		}
+		defer t.Close()
+		args = append(args, "--event-log", t.Filename)
+	}
+
+	stdout, stderr, code, err := s.runPulumiCmdSync(
+		ctx,
+		importOpts.ProgressStreams,      /* additionalOutputs */
+		importOpts.ErrorProgressStreams, /* additionalErrorOutputs */
+		args...,
+	)
+	if err != nil {
+		return res, newAutoError(fmt.Errorf("failed to import resources: %w", err), stdout, stderr, code)
+	}
+
+	var generatedCode []byte
+	if generateCode {
+		generatedCode, err = os.ReadFile(generatedCodePath)
+		if err != nil {
+			return res, fmt.Errorf("failed to read generated code: %w", err)
+		}
+	}
+
+	historyOpts := []opthistory.Option{}
+	if showSecrets := importOpts.ShowSecrets; showSecrets != nil {
+		historyOpts = append(historyOpts, opthistory.ShowSecrets(*showSecrets))
+	}
+	history, err := s.History(ctx, 1 /*pageSize*/, 1 /*page*/, historyOpts...)
+	if err != nil {
+		return res, fmt.Errorf("failed to import resources: %w", err)
+	}
+
+	var summary UpdateSummary
+	if len(history) > 0 {
+		summary = history[0]
+	}
+
+	res = ImportResult{
+		StdOut:        stdout,
+		StdErr:        stderr,
+		GeneratedCode: string(generatedCode),
+		Summary:       summary,
+	}
+
+	return res, nil
+}
+
+// StateDelete deletes the resources with the given URNs, and any resources matching optstatedelete.Select, from the
+// stack's state without deleting the resources themselves. Resources that others depend on can only be deleted
+// along with their dependents, using optstatedelete.TargetDependents.
+func (s *Stack) StateDelete(ctx context.Context, urns []string,
+	opts ...optstatedelete.Option,
+) (StateDeleteResult, error) {
+	var res StateDeleteResult
+
+	deleteOpts := &optstatedelete.Options{}
+	for _, o := range opts {
+		o.ApplyOption(deleteOpts)
+	}
+
+	args := append([]string{"state", "delete", "--yes"}, urns...)
+	if deleteOpts.Force {
+		args = append(args, "--force")
+	}
+	if deleteOpts.TargetDependents {
+		args = append(args, "--target-dependents")
+	}
+	for _, selector := range deleteOpts.Select {
+		args = append(args, fmt.Sprintf("--select=%s", selector))
+	}
+
+	before, after, stdout, stderr, err := s.runStateCmd(ctx, "delete resources from state", args...)
+	if err != nil {
+		return res, err
+	}
+
+	res = StateDeleteResult{
+		StdOut:  stdout,
+		StdErr:  stderr,
+		Deleted: removedURNs(before, after),
+	}
+	return res, nil
+}
+
+// StateRename renames the resource with the given URN in the stack's state, without changing the resource itself.
+// The program must be updated to use the new name, or to alias the old one, before the next Stack.Up().
+func (s *Stack) StateRename(ctx context.Context, urn, newName string) (StateRenameResult, error) {
+	var res StateRenameResult
+
+	before, after, stdout, stderr, err := s.runStateCmd(ctx, "rename resource in state",
+		"state", "rename", "--yes", urn, newName)
+	if err != nil {
+		return res, err
+	}
+
+	res = StateRenameResult{
+		StdOut: stdout,
+		StdErr: stderr,
+		OldURN: urn,
+	}
+	if added := removedURNs(after, before); len(added) == 1 {
+		res.NewURN = added[0]
+	}
+	return res, nil
+}
+
+// StateUnprotect unprotects the resources with the given URNs, and any resources matching optstateunprotect.Select,
+// in the stack's state, so that they can be deleted.
+func (s *Stack) StateUnprotect(ctx context.Context, urns []string,
+	opts ...optstateunprotect.Option,
+) (StateUnprotectResult, error) {
+	var res StateUnprotectResult
+
+	unprotectOpts := &optstateunprotect.Options{}
+	for _, o := range opts {
+		o.ApplyOption(unprotectOpts)
+	}
+
+	args := append([]string{"state", "unprotect", "--yes"}, urns...)
+	if unprotectOpts.All {
+		args = append(args, "--all")
+	}
+	for _, selector := range unprotectOpts.Select {
+		args = append(args, fmt.Sprintf("--select=%s", selector))
+	}
+
+	before, after, stdout, stderr, err := s.runStateCmd(ctx, "unprotect resources in state", args...)
+	if err != nil {
+		return res, err
+	}
+
+	res = StateUnprotectResult{
+		StdOut:      stdout,
+		StdErr:      stderr,
+		Unprotected: unprotectedURNs(before, after),
+	}
+	return res, nil
+}
+
+// Rename renames the stack. The stack's configuration is moved to the settings file of the new name, and the Stack
+// refers to the stack by its new name from then on. The new name may be fully qualified to move the stack to a
+// different project; see FullyQualifiedStackName.
+func (s *Stack) Rename(ctx context.Context, newName string) (RenameResult, error) {
+	var res RenameResult
+
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		nil, /* additionalErrorOutput */
+		"stack", "rename", newName)
+	if err != nil {
+		return res, newAutoError(fmt.Errorf("failed to rename stack: %w", err), stdout, stderr, errCode)
+	}
+
+	res = RenameResult{
+		StdOut:  stdout,
+		StdErr:  stderr,
+		OldName: s.stackName,
+		NewName: newName,
+	}
+	s.stackName = newName
+	return res, nil
+}
+
+// ChangeSecretsProvider changes the secrets provider of the stack, re-encrypting its secret configuration and state
+// with the new provider. Valid secrets providers are `default`, `passphrase`, or the URL of a cloud secrets provider
+// such as `awskms://alias/ExampleAlias?region=us-east-1`. A new passphrase is read from the PULUMI_CONFIG_PASSPHRASE
+// environment variable of the workspace.
+func (s *Stack) ChangeSecretsProvider(ctx context.Context, newSecretsProvider string) (ChangeSecretsProviderResult,
+	error,
+) {
+	var res ChangeSecretsProviderResult
+
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		nil, /* additionalErrorOutput */
+		"stack", "change-secrets-provider", newSecretsProvider)
+	if err != nil {
+		return res, newAutoError(fmt.Errorf("failed to change secrets provider: %w", err), stdout, stderr, errCode)
+	}
+
+	res = ChangeSecretsProviderResult{
+		StdOut:          stdout,
+		StdErr:          stderr,
+		SecretsProvider: newSecretsProvider,
+	}
+	return res, nil
+}
+
+// runStateCmd runs a command that edits the stack's state, and returns the resources in the state before and after
+// the edit, so that callers can report exactly what changed. Errors are reported as failing to perform the given
+// action.
+func (s *Stack) runStateCmd(ctx context.Context, action string, args ...string) (before, after []apitype.ResourceV3,
+	stdout, stderr string, err error,
+) {
+	before, err = s.stateResources(ctx)
+	if err != nil {
+		return nil, nil, "", "", fmt.Errorf("failed to %s: %w", action, err)
+	}
+
+	stdout, stderr, errCode, err := s.runPulumiCmdSync(
+		ctx,
+		nil, /* additionalOutput */
+		nil, /* additionalErrorOutput */
+		args...)
+	if err != nil {
+		return nil, nil, stdout, stderr, newAutoError(fmt.Errorf("failed to %s: %w", action, err), stdout, stderr, errCode)
+	}
+
+	after, err = s.stateResources(ctx)
+	if err != nil {
+		return nil, nil, stdout, stderr, fmt.Errorf("failed to %s: %w", action, err)
+	}
+	return before, after, stdout, stderr, nil
+}
+
+// stateResources returns the resources in the stack's current state.
+func (s *Stack) stateResources(ctx context.Context) ([]apitype.ResourceV3, error) {
+	state, err := s.Export(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(state.Deployment) == 0 {
+		return nil, nil
+	}
+	var deployment apitype.DeploymentV3
+	if err := json.Unmarshal(state.Deployment, &deployment); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal stack state: %w", err)
+	}
+	return deployment.Resources, nil
+}
+
+// removedURNs returns the URNs of the resources in before that aren't in after, in state order.
+func removedURNs(before, after []apitype.ResourceV3) []string {
+	remaining := make(map[resource.URN]bool, len(after))
+	for _, r := range after {
+		remaining[r.URN] = true
+	}
+	var removed []string
+	for _, r := range before {
+		if !remaining[r.URN] {
+			removed = append(removed, string(r.URN))
+		}
+	}
+	return removed
+}
+
+// unprotectedURNs returns the URNs of the resources that are protected in before but not in after, in state order.
+func unprotectedURNs(before, after []apitype.ResourceV3) []string {
+	protected := make(map[resource.URN]bool, len(after))
+	for _, r := range after {
+		if r.Protect {
+			protected[r.URN] = true
+		}
+	}
+	var unprotected []string
+	for _, r := range before {
+		if r.Protect && !protected[r.URN] {
+			unprotected = append(unprotected, string(r.URN))
+		}
+	}
+	return unprotected
+}
+
 // UpdateSummary provides a summary of a Stack lifecycle operation (up/preview/refresh/destroy).
 type UpdateSummary struct {
 	Version     int               `json:"version"`
@@ -911,6 +1645,18 @@ func (rr *RefreshResult) GetPermalink() (string, error) {
 	return GetPermalink(rr.StdOut)
 }
 
+// DriftResult is the output of a successful Stack.Drift operation
+type DriftResult struct {
+	StdOut string
+	StdErr string
+	Report display.DriftDigest
+}
+
+// HasDrift returns true if any resource has drifted or was deleted out-of-band.
+func (dr *DriftResult) HasDrift() bool {
+	return dr.Report.HasDrift()
+}
+
 // DestroyResult is the output of a successful Stack.Destroy operation
 type DestroyResult struct {
 	StdOut  string
@@ -923,6 +1669,59 @@ func (dr *DestroyResult) GetPermalink() (string, error) {
 	return GetPermalink(dr.StdOut)
 }
 
+// ImportResult is the output of a successful Stack.ImportResources operation
+type ImportResult struct {
+	StdOut        string
+	StdErr        string
+	GeneratedCode string
+	Summary       UpdateSummary
+}
+
+// GetPermalink returns the permalink URL in the Pulumi Console for the import operation.
+func (ir *ImportResult) GetPermalink() (string, error) {
+	return GetPermalink(ir.StdOut)
+}
+
+// StateDeleteResult is the output of a successful Stack.StateDelete operation
+type StateDeleteResult struct {
+	StdOut string
+	StdErr string
+	// Deleted lists the URNs of the resources deleted from the state.
+	Deleted []string
+}
+
+// StateRenameResult is the output of a successful Stack.StateRename operation
+type StateRenameResult struct {
+	StdOut string
+	StdErr string
+	// OldURN and NewURN are the URN of the renamed resource before and after the rename.
+	OldURN string
+	NewURN string
+}
+
+// StateUnprotectResult is the output of a successful Stack.StateUnprotect operation
+type StateUnprotectResult struct {
+	StdOut string
+	StdErr string
+	// Unprotected lists the URNs of the resources that were protected before the operation.
+	Unprotected []string
+}
+
+// RenameResult is the output of a successful Stack.Rename operation
+type RenameResult struct {
+	StdOut  string
+	StdErr  string
+	OldName string
+	NewName string
+}
+
+// ChangeSecretsProviderResult is the output of a successful Stack.ChangeSecretsProvider operation
+type ChangeSecretsProviderResult struct {
+	StdOut          string
+	StdErr          string
+	SecretsProvider string
+}
+
 // secretSentinel represents the CLI response for an output marked as "secret"
 const secretSentinel = "[secret]"
 
@@ -962,7 +1761,7 @@ func (s *Stack) runPulumiCmdSync(
 	args = append(args, additionalArgs...)
 	args = append(args, "--stack", s.Name())
 
-	stdout, stderr, errCode, err := runPulumiCommandSync(
+	stdout, stderr, errCode, err := s.Workspace().PulumiCommand().Run(
 		ctx,
 		s.Workspace().WorkDir(),
 		additionalOutput,
@@ -1271,6 +2070,61 @@ func watchFile(path string, receivers []chan<- events.EngineEvent) (*fileWatcher
 	}, nil
 }
 
+// pollPaths calls trigger each time the files in paths change, checking for changes every interval until ctx is
+// cancelled.
+func pollPaths(ctx context.Context, paths []string, interval time.Duration, trigger func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := fingerprintPaths(paths)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if current := fingerprintPaths(paths); current != last {
+				last = current
+				trigger()
+			}
+		}
+	}
+}
+
+// unwatchedDirs are the directories that language hosts write to while running programs.
+var unwatchedDirs = map[string]bool{
+	"node_modules": true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// fingerprintPaths summarizes the name, size and modification time of every file in paths, so that any change to
+// the files changes the fingerprint. Hidden directories, such as .git, and directories of dependencies and build
+// artifacts written by language hosts are skipped.
+func fingerprintPaths(paths []string) string {
+	var b strings.Builder
+	for _, root := range paths {
+		//nolint:errcheck // files that cannot be read are left out of the fingerprint
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && (strings.HasPrefix(d.Name(), ".") || unwatchedDirs[d.Name()]) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			fmt.Fprintf(&b, "%s\x00%d\x00%d\n", path, info.Size(), info.ModTime().UnixNano())
+			return nil
+		})
+	}
+	return b.String()
+}
+
 func tailLogs(command string, receivers []chan<- events.EngineEvent) (*fileWatcher, error) {
 	logDir, err := os.MkdirTemp("", fmt.Sprintf("automation-logs-%s-", command))
 	if err != nil {
@@ -18,10 +18,18 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/events"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optpreview"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optremove"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optstatedelete"
 	"github.com/pulumi/pulumi/sdk/v3/go/auto/optup"
+	"github.com/pulumi/pulumi/sdk/v3/go/auto/optwatch"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/apitype"
 	"github.com/pulumi/pulumi/sdk/v3/go/pulumi"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -135,3 +143,180 @@ func TestUpdatePlans(t *testing.T) {
 	assert.Equal(t, "destroy", dRes.Summary.Kind)
 	assert.Equal(t, "succeeded", dRes.Summary.Result)
 }
+
+type testComponent struct {
+	pulumi.ResourceState
+}
+
+func TestStateOperations(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	sName := randomStackName()
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	// initialize
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		for _, name := range []string{"a", "b", "c"} {
+			var comp testComponent
+			err := ctx.RegisterComponentResource("test:index:Component", name, &comp, pulumi.Protect(true))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err, "failed to initialize stack, err: %v", err)
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(ctx, s.Name(), optremove.Force())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	// -- pulumi up --
+	_, err = s.Up(ctx)
+	require.NoError(t, err, "up failed, err: %v", err)
+
+	urn := func(name string) string {
+		return fmt.Sprintf("urn:pulumi:%s::%s::test:index:Component::%s", sName, pName, name)
+	}
+
+	// -- pulumi state unprotect --
+	unprotectRes, err := s.StateUnprotect(ctx, []string{urn("a"), urn("b")})
+	require.NoError(t, err, "state unprotect failed, err: %v", err)
+	assert.Equal(t, []string{urn("a"), urn("b")}, unprotectRes.Unprotected)
+
+	// -- pulumi state rename --
+	renameRes, err := s.StateRename(ctx, urn("b"), "renamed")
+	require.NoError(t, err, "state rename failed, err: %v", err)
+	assert.Equal(t, urn("b"), renameRes.OldURN)
+	assert.Equal(t, urn("renamed"), renameRes.NewURN)
+
+	// -- pulumi state delete --
+	deleteRes, err := s.StateDelete(ctx, []string{urn("a")})
+	require.NoError(t, err, "state delete failed, err: %v", err)
+	assert.Equal(t, []string{urn("a")}, deleteRes.Deleted)
+
+	// c is still protected, so it can only be deleted by force.
+	_, err = s.StateDelete(ctx, []string{urn("c")})
+	assert.Error(t, err)
+	deleteRes, err = s.StateDelete(ctx, []string{urn("c")}, optstatedelete.Force())
+	require.NoError(t, err, "state delete failed, err: %v", err)
+	assert.Equal(t, []string{urn("c")}, deleteRes.Deleted)
+
+	// -- pulumi stack rename --
+	newStackName := FullyQualifiedStackName(pulumiOrg, pName, randomStackName())
+	stackRenameRes, err := s.Rename(ctx, newStackName)
+	require.NoError(t, err, "stack rename failed, err: %v", err)
+	assert.Equal(t, stackName, stackRenameRes.OldName)
+	assert.Equal(t, newStackName, s.Name())
+}
+
+func TestStateChanges(t *testing.T) {
+	t.Parallel()
+
+	before := []apitype.ResourceV3{
+		{URN: "urn:pulumi:stack::proj::test:index:Component::a", Protect: true},
+		{URN: "urn:pulumi:stack::proj::test:index:Component::b", Protect: true},
+		{URN: "urn:pulumi:stack::proj::test:index:Component::c"},
+	}
+	after := []apitype.ResourceV3{
+		{URN: "urn:pulumi:stack::proj::test:index:Component::a", Protect: true},
+		{URN: "urn:pulumi:stack::proj::test:index:Component::b"},
+		{URN: "urn:pulumi:stack::proj::test:index:Component::d"},
+	}
+
+	assert.Equal(t, []string{"urn:pulumi:stack::proj::test:index:Component::c"}, removedURNs(before, after))
+	assert.Equal(t, []string{"urn:pulumi:stack::proj::test:index:Component::d"}, removedURNs(after, before))
+	assert.Equal(t, []string{"urn:pulumi:stack::proj::test:index:Component::b"}, unprotectedURNs(before, after))
+}
+
+func TestWatchInlineSource(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sName := randomStackName()
+	stackName := FullyQualifiedStackName(pulumiOrg, pName, sName)
+
+	var runs int32
+	s, err := NewStackInlineSource(ctx, stackName, pName, func(ctx *pulumi.Context) error {
+		ctx.Export("runs", pulumi.Int(atomic.AddInt32(&runs, 1)))
+		return nil
+	})
+	require.NoError(t, err, "failed to initialize stack, err: %v", err)
+
+	defer func() {
+		// -- pulumi stack rm --
+		err = s.Workspace().RemoveStack(context.Background(), s.Name(), optremove.Force())
+		assert.Nil(t, err, "failed to remove stack. Resources have leaked.")
+	}()
+
+	trigger := make(chan struct{})
+	eventsCh := make(chan events.EngineEvent)
+	results := make(chan error)
+	watchDone := make(chan error)
+	go func() {
+		watchDone <- s.Watch(ctx,
+			optwatch.Trigger(trigger),
+			optwatch.EventStreams(eventsCh),
+			optwatch.UpdateResults(results))
+	}()
+
+	summaries := make(chan int)
+	go func() {
+		n := 0
+		for e := range eventsCh {
+			if e.SummaryEvent != nil {
+				n++
+			}
+		}
+		summaries <- n
+	}()
+
+	// The stack is updated once when the watch starts, and again when triggered.
+	require.NoError(t, <-results, "initial update failed")
+	trigger <- struct{}{}
+	require.NoError(t, <-results, "triggered update failed")
+
+	outs, err := s.Outputs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), outs["runs"].Value)
+
+	cancel()
+	assert.NoError(t, <-watchDone)
+	_, open := <-results
+	assert.False(t, open, "results should be closed once the watch ends")
+	assert.Equal(t, 2, <-summaries, "events should be streamed for every update")
+}
+
+func TestPollPaths(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	triggered := make(chan struct{}, 10)
+	go pollPaths(ctx, []string{dir}, 10*time.Millisecond, func() { triggered <- struct{}{} })
+	// Give the poller time to take its initial fingerprint.
+	time.Sleep(50 * time.Millisecond)
+
+	// Changes to hidden directories are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref"), 0o600))
+	select {
+	case <-triggered:
+		t.Fatal("a change to a hidden directory triggered an update")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main // changed"), 0o600))
+	select {
+	case <-triggered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("a change to a watched file did not trigger an update")
+	}
+}
@@ -101,6 +101,8 @@ type Workspace interface {
 	PulumiHome() string
 	// PulumiVersion returns the version of the underlying Pulumi CLI/Engine.
 	PulumiVersion() string
+	// PulumiCommand returns the PulumiCommand that runs the Pulumi CLI for this workspace.
+	PulumiCommand() PulumiCommand
 	// WhoAmI returns the currently authenticated user.
 	WhoAmI(context.Context) (string, error)
 	// WhoAmIDetails returns detailed information about the currently
@@ -116,6 +116,9 @@ type CheckpointV3 struct {
 	Config config.Map `json:"config,omitempty" yaml:"config,omitempty"`
 	// Latest is the latest/current deployment (if an update has occurred).
 	Latest *DeploymentV3 `json:"latest,omitempty" yaml:"latest,omitempty"`
+	// Revision is incremented each time the checkpoint is written by the self-managed backend, which uses it to detect
+	// concurrent modifications.
+	Revision int64 `json:"revision,omitempty" yaml:"revision,omitempty"`
 }
 
 // DeploymentV1 represents a deployment that has actually occurred. It is similar to the engine's snapshot structure,
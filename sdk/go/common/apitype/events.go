@@ -181,6 +181,19 @@ type ResOpFailedEvent struct {
 	Steps    int               `json:"steps"`
 }
 
+// ResOpRetryEvent is emitted when a resource operation fails with a transient error and is about to be retried.
+type ResOpRetryEvent struct {
+	Metadata StepEventMetadata `json:"metadata"`
+	// Attempt is the attempt that is about to be made, counting the first one.
+	Attempt int `json:"attempt"`
+	// MaxAttempts is the maximum number of attempts.
+	MaxAttempts int `json:"maxAttempts"`
+	// DelaySeconds is how long the engine waits before the attempt.
+	DelaySeconds float64 `json:"delaySeconds"`
+	// Message is the message of the transient error.
+	Message string `json:"message"`
+}
+
 // EngineEvent describes a Pulumi engine event, such as a change to a resource or diagnostic
 // message. EngineEvent is a discriminated union of all possible event types, and exactly one
 // field will be non-nil.
@@ -205,6 +218,7 @@ type EngineEvent struct {
 	ResourcePreEvent *ResourcePreEvent  `json:"resourcePreEvent,omitempty"`
 	ResOutputsEvent  *ResOutputsEvent   `json:"resOutputsEvent,omitempty"`
 	ResOpFailedEvent *ResOpFailedEvent  `json:"resOpFailedEvent,omitempty"`
+	ResOpRetryEvent  *ResOpRetryEvent   `json:"resOpRetryEvent,omitempty"`
 	PolicyEvent      *PolicyEvent       `json:"policyEvent,omitempty"`
 }
 
@@ -81,6 +81,10 @@ func GetResourceWillBeDestroyedButWasNotSpecifiedInTargetList(urn resource.URN)
 Either include resource in --target list or pass --target-dependents to proceed.`)
 }
 
+func GetResourceWillBeCreatedButWasExcluded(urn resource.URN) *Diag {
+	return newError(urn, 2017, `Resource '%v' depends on '%v' which was excluded with --exclude.`)
+}
+
 func GetDefaultProviderDenied(urn resource.URN) *Diag {
 	return newError(urn, 2015, `Default provider for '%v' disabled. '%v' must use an explicit provider.`)
 }
@@ -45,6 +45,8 @@ type PreviewDigest struct {
 	ChangeSummary ResourceChanges `json:"changeSummary,omitempty"`
 	// MaybeCorrupt indicates whether one or more resources may be corrupt.
 	MaybeCorrupt bool `json:"maybeCorrupt,omitempty"`
+	// BlastRadius describes the resources affected by the replacements and deletions of the preview, if there are any.
+	BlastRadius *BlastRadius `json:"blastRadius,omitempty"`
 }
 
 // PropertyDiff contains information about the difference in a single property value.
@@ -75,6 +77,47 @@ type PreviewStep struct {
 	DetailedDiff map[string]PropertyDiff `json:"detailedDiff"`
 }
 
+// BlastRadius is a JSON-serializable overview of the resources that are affected by the replacements and deletions
+// planned by a preview.
+type BlastRadius struct {
+	// Replaces is the number of resources that would be replaced.
+	Replaces int `json:"replaces"`
+	// Deletes is the number of resources that would be deleted.
+	Deletes int `json:"deletes"`
+	// Resources lists each existing resource that would be replaced or deleted, along with the dependents it touches.
+	Resources []BlastRadiusResource `json:"resources,omitempty"`
+}
+
+// BlastRadiusResource is a resource that would be replaced or deleted, along with the resources that depend on it,
+// directly or indirectly, and that would be changed too.
+type BlastRadiusResource struct {
+	// URN is the resource that would be replaced or deleted.
+	URN resource.URN `json:"urn"`
+	// Op is the operation that would be performed on the resource: either a replace or a delete.
+	Op StepOp `json:"op"`
+	// Protect is true if the resource is protected.
+	Protect bool `json:"protect,omitempty"`
+	// RetainOnDelete is true if the resource is retained by its provider when it is deleted.
+	RetainOnDelete bool `json:"retainOnDelete,omitempty"`
+	// Dependents lists the resources that depend on this resource and would be changed, in dependency order.
+	Dependents []BlastRadiusDependent `json:"dependents,omitempty"`
+	// HighImpact is true if the resource would be replaced and has many dependents that would be changed.
+	HighImpact bool `json:"highImpact,omitempty"`
+}
+
+// BlastRadiusDependent is a resource that depends on a resource that would be replaced or deleted, and that would be
+// changed too.
+type BlastRadiusDependent struct {
+	// URN is the dependent resource.
+	URN resource.URN `json:"urn"`
+	// Op is the operation that would be performed on the dependent resource.
+	Op StepOp `json:"op"`
+	// Protect is true if the dependent resource is protected.
+	Protect bool `json:"protect,omitempty"`
+	// RetainOnDelete is true if the dependent resource is retained by its provider when it is deleted.
+	RetainOnDelete bool `json:"retainOnDelete,omitempty"`
+}
+
 // PreviewDiagnostic is a warning or error emitted during the execution of the preview.
 type PreviewDiagnostic struct {
 	URN      resource.URN  `json:"urn,omitempty"`
@@ -82,3 +125,46 @@ type PreviewDiagnostic struct {
 	Message  string        `json:"message,omitempty"`
 	Severity diag.Severity `json:"severity,omitempty"`
 }
+
+// DriftStatus describes how the actual state of a resource compares to the state recorded for it.
+type DriftStatus string
+
+const (
+	// DriftInSync means that the resource's actual state matches its recorded state.
+	DriftInSync DriftStatus = "in-sync"
+	// DriftDrifted means that some of the resource's properties were changed out-of-band.
+	DriftDrifted DriftStatus = "drifted"
+	// DriftDeleted means that the resource was deleted out-of-band.
+	DriftDeleted DriftStatus = "deleted"
+)
+
+// DriftDigest is a JSON-serializable overview of a drift detection operation.
+type DriftDigest struct {
+	// Resources contains the drift status of each custom resource that was read.
+	Resources []DriftResource `json:"resources,omitempty"`
+	// Diagnostics contains a record of all warnings/errors that took place while detecting drift.
+	Diagnostics []PreviewDiagnostic `json:"diagnostics,omitempty"`
+
+	// Duration records the amount of time it took to detect drift.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// HasDrift returns true if any resource in the digest has drifted or was deleted.
+func (d DriftDigest) HasDrift() bool {
+	for _, r := range d.Resources {
+		if r.Status != DriftInSync {
+			return true
+		}
+	}
+	return false
+}
+
+// DriftResource is the drift status of a single resource.
+type DriftResource struct {
+	// URN is the resource that was read.
+	URN resource.URN `json:"urn"`
+	// Status is the drift status of the resource.
+	Status DriftStatus `json:"status"`
+	// DetailedDiff is the per-property difference between the recorded and actual outputs of a drifted resource.
+	DetailedDiff map[string]PropertyDiff `json:"detailedDiff,omitempty"`
+}
@@ -76,4 +76,20 @@ var (
 
 	SelfManagedStateLegacyLayout = env.Bool("SELF_MANAGED_STATE_LEGACY_LAYOUT",
 		"Uses the legacy layout for new buckets, which currently default to project-scoped stacks.")
+
+	SelfManagedStateLockTTL = env.String("SELF_MANAGED_STATE_LOCK_TTL",
+		"How long a stack lock is held without being renewed before it is considered stale, e.g. 5m. "+
+			"Locks are renewed while the operation holding them is running. Set to 0 to disable lock expiry.")
+
+	SelfManagedStateJournal = env.Bool("SELF_MANAGED_STATE_JOURNAL",
+		"Writes checkpoints as a journal of small delta records during updates, rather than rewriting the whole "+
+			"checkpoint after every step. The journal is compacted into a full checkpoint when the update finishes.")
+
+	SelfManagedStateJournalCompaction = env.Int("SELF_MANAGED_STATE_JOURNAL_COMPACTION",
+		"The number of delta records written to the checkpoint journal before it is compacted into a full "+
+			"checkpoint. Defaults to 100.")
+
+	SelfManagedStateEncrypt = env.Bool("SELF_MANAGED_STATE_ENCRYPT",
+		"Encrypts whole checkpoints, history files and journal records with the stack's secrets provider, "+
+			"rather than only the values of secrets. Encrypted state is decrypted when read whether or not this is set.")
 )
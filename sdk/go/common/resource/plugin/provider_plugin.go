@@ -1775,6 +1775,23 @@ func (ie *InitError) Error() string {
 	return err.Error()
 }
 
+// IsRetryableError returns true if the given error, returned by a provider's Create, Update or Delete method, is
+// transient, so that the operation may succeed if it is retried. Providers advertise that an error is retryable by
+// failing with the gRPC status code Unavailable (e.g. when a request was throttled) or ResourceExhausted (e.g. when a
+// rate limit or quota was exceeded).
+func IsRetryableError(err error) bool {
+	rpcErr, ok := rpcerror.FromError(err)
+	if !ok {
+		return false
+	}
+	switch rpcErr.Code() {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
 func decorateSpanWithType(span opentracing.Span, urn string) {
 	if urn := resource.URN(urn); urn.IsValid() {
 		span.SetTag("pulumi-decorator", urn.Type())
@@ -12,10 +12,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/protobuf/types/known/emptypb"
 
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/testing/diagtest"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/util/rpcutil/rpcerror"
 	pulumirpc "github.com/pulumi/pulumi/sdk/v3/proto/go"
 )
 
@@ -680,3 +682,13 @@ func (c *stubClient) Delete(
 	}
 	return c.ResourceProviderClient.Delete(ctx, req, opts...)
 }
+
+func TestIsRetryableError(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsRetryableError(rpcerror.New(codes.Unavailable, "throttled")))
+	assert.True(t, IsRetryableError(rpcerror.Convert(rpcerror.New(codes.ResourceExhausted, "quota exceeded"))))
+	assert.False(t, IsRetryableError(rpcerror.New(codes.InvalidArgument, "bad input")))
+	assert.False(t, IsRetryableError(errors.New("boom")))
+	assert.False(t, IsRetryableError(&InitError{Reasons: []string{"failed to start"}}))
+}
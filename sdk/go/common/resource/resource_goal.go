@@ -43,6 +43,8 @@ type Goal struct {
 	// if set, the providers Delete method will not be called for this resource
 	// if specified resource is being deleted as well.
 	DeletedWith URN
+	// an optional policy for retrying provider operations that fail with transient errors.
+	Retry *RetryPolicy
 }
 
 // NewGoal allocates a new resource goal state.
@@ -0,0 +1,43 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+// RetryPolicy controls how provider operations on a resource that fail with transient errors are retried. Delays are
+// in seconds. Zero values are unset, and are inherited from a base policy by Merge.
+type RetryPolicy struct {
+	MaxAttempts  int     `json:"maxAttempts,omitempty" yaml:"maxAttempts,omitempty"`
+	InitialDelay float64 `json:"initialDelay,omitempty" yaml:"initialDelay,omitempty"`
+	MaxDelay     float64 `json:"maxDelay,omitempty" yaml:"maxDelay,omitempty"`
+}
+
+// Merge returns a copy of this policy with any unset values taken from the given base policy. Either policy may be nil.
+func (p *RetryPolicy) Merge(base *RetryPolicy) *RetryPolicy {
+	var merged RetryPolicy
+	if base != nil {
+		merged = *base
+	}
+	if p != nil {
+		if p.MaxAttempts != 0 {
+			merged.MaxAttempts = p.MaxAttempts
+		}
+		if p.InitialDelay != 0 {
+			merged.InitialDelay = p.InitialDelay
+		}
+		if p.MaxDelay != 0 {
+			merged.MaxDelay = p.MaxDelay
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,35 @@
+// Copyright 2016-2023, Pulumi Corporation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resource
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyMerge(t *testing.T) {
+	t.Parallel()
+
+	base := &RetryPolicy{MaxAttempts: 3, InitialDelay: 2, MaxDelay: 10}
+	assert.Equal(t, &RetryPolicy{}, (*RetryPolicy)(nil).Merge(nil))
+	assert.Equal(t, base, (*RetryPolicy)(nil).Merge(base))
+	assert.Equal(t, &RetryPolicy{MaxAttempts: 5}, (&RetryPolicy{MaxAttempts: 5}).Merge(nil))
+	assert.Equal(t, &RetryPolicy{MaxAttempts: 5, InitialDelay: 2, MaxDelay: 10},
+		(&RetryPolicy{MaxAttempts: 5}).Merge(base))
+
+	// Merging doesn't modify either policy.
+	assert.Equal(t, &RetryPolicy{MaxAttempts: 3, InitialDelay: 2, MaxDelay: 10}, base)
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/encoding"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/util/contract"
@@ -91,6 +92,21 @@ type ProjectBackend struct {
 type ProjectOptions struct {
 	// Refresh is the ability to always run a refresh as part of a pulumi update / preview / destroy
 	Refresh string `json:"refresh,omitempty" yaml:"refresh,omitempty"`
+	// Retry is the policy used to retry provider operations that fail with transient errors. Resources may override it
+	// with the retry resource option.
+	Retry *resource.RetryPolicy `json:"retry,omitempty" yaml:"retry,omitempty"`
+	// Limits caps the concurrency and rate of the operations on resources, keyed by the name of a provider package
+	// (e.g. "github") or by a resource type token (e.g. "aws:s3/bucket:Bucket").
+	Limits map[string]ResourceLimits `json:"limits,omitempty" yaml:"limits,omitempty"`
+}
+
+// ResourceLimits caps the operations on the resources of a provider package or of a resource type. Zero values are
+// unlimited.
+type ResourceLimits struct {
+	// Parallel is the maximum number of resource operations that may run at once.
+	Parallel int `json:"parallel,omitempty" yaml:"parallel,omitempty"`
+	// Rate is the maximum number of resource operations that may start per second.
+	Rate float64 `json:"rate,omitempty" yaml:"rate,omitempty"`
 }
 
 type PluginOptions struct {
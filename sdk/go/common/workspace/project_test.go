@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource/config"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/tokens"
 	"github.com/stretchr/testify/assert"
@@ -669,6 +670,58 @@ config: ./some/path`
 	assert.Equal(t, 0, len(project.Config), "Config should be empty")
 }
 
+func TestProjectRetryOptions(t *testing.T) {
+	t.Parallel()
+	projectYaml := `
+name: test
+runtime: dotnet
+options:
+  retry:
+    maxAttempts: 5
+    initialDelay: 0.5`
+
+	project, projectError := loadProjectFromText(t, projectYaml)
+	require.NoError(t, projectError)
+	assert.Equal(t, &resource.RetryPolicy{MaxAttempts: 5, InitialDelay: 0.5}, project.Options.Retry)
+
+	_, projectError = loadProjectFromText(t, `
+name: test
+runtime: dotnet
+options:
+  retry:
+    attempts: 5`)
+	assert.ErrorContains(t, projectError, "additionalProperties 'attempts' not allowed")
+}
+
+func TestProjectLimitsOptions(t *testing.T) {
+	t.Parallel()
+	projectYaml := `
+name: test
+runtime: dotnet
+options:
+  limits:
+    github:
+      parallel: 2
+    aws:s3/bucket:Bucket:
+      rate: 0.5`
+
+	project, projectError := loadProjectFromText(t, projectYaml)
+	require.NoError(t, projectError)
+	assert.Equal(t, map[string]ResourceLimits{
+		"github":               {Parallel: 2},
+		"aws:s3/bucket:Bucket": {Rate: 0.5},
+	}, project.Options.Limits)
+
+	_, projectError = loadProjectFromText(t, `
+name: test
+runtime: dotnet
+options:
+  limits:
+    github:
+      parallel: -1`)
+	assert.Error(t, projectError)
+}
+
 func TestDefningBothConfigAndStackConfigDirErrorsOut(t *testing.T) {
 	t.Parallel()
 	projectYaml := `
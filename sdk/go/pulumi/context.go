@@ -926,6 +926,7 @@ func (ctx *Context) registerResource(
 				DeleteBeforeReplace:     inputs.deleteBeforeReplace,
 				ImportId:                inputs.importID,
 				CustomTimeouts:          inputs.customTimeouts,
+				Retry:                   inputs.retry,
 				IgnoreChanges:           inputs.ignoreChanges,
 				AliasURNs:               aliasURNs,
 				Aliases:                 aliases,
@@ -1328,6 +1329,7 @@ type resourceInputs struct {
 	deleteBeforeReplace     bool
 	importID                string
 	customTimeouts          *pulumirpc.RegisterResourceRequest_CustomTimeouts
+	retry                   *pulumirpc.RegisterResourceRequest_RetryPolicy
 	ignoreChanges           []string
 	aliases                 []*pulumirpc.Alias
 	additionalSecretOutputs []string
@@ -1593,6 +1595,7 @@ func (ctx *Context) prepareResourceInputs(res Resource, props Input, t string, o
 		deleteBeforeReplace:     resOpts.deleteBeforeReplace,
 		importID:                string(resOpts.importID),
 		customTimeouts:          getTimeouts(opts.CustomTimeouts),
+		retry:                   getRetryPolicy(opts.Retry),
 		ignoreChanges:           resOpts.ignoreChanges,
 		aliases:                 aliases,
 		additionalSecretOutputs: resOpts.additionalSecretOutputs,
@@ -1614,6 +1617,17 @@ func getTimeouts(custom *CustomTimeouts) *pulumirpc.RegisterResourceRequest_Cust
 	return &timeouts
 }
 
+func getRetryPolicy(policy *RetryPolicy) *pulumirpc.RegisterResourceRequest_RetryPolicy {
+	if policy == nil {
+		return nil
+	}
+	return &pulumirpc.RegisterResourceRequest_RetryPolicy{
+		MaxAttempts:  int32(policy.MaxAttempts),
+		InitialDelay: policy.InitialDelay,
+		MaxDelay:     policy.MaxDelay,
+	}
+}
+
 // Helper struct for the return type of `getOpts`.
 type resourceOpts struct {
 	parentURN               URN
@@ -286,6 +286,21 @@ type CustomTimeouts struct {
 	Delete string
 }
 
+// RetryPolicy specifies how the create, update and delete operations
+// of a resource are retried when its provider fails with a transient error,
+// such as a throttled request.
+// Use it with the [Retry] option to override the project's retry policy.
+//
+// Retries are disabled unless MaxAttempts is greater than 1.
+// The delay between retries starts at InitialDelay (1 second by default)
+// and doubles after each attempt, up to MaxDelay (30 seconds by default).
+// Delays are specified as duration strings such as "500ms" or "1m30s".
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay string
+	MaxDelay     string
+}
+
 // ResourceOptions is a snapshot of one or more [ResourceOption]s.
 //
 // You cannot pass a ResourceOptions struct to a resource constructor.
@@ -376,6 +391,10 @@ type ResourceOptions struct {
 	// DeletedWith holds a container resource that, if deleted,
 	// also deletes this resource.
 	DeletedWith Resource
+
+	// Retry, if set, overrides the project's policy for retrying
+	// resource CRUD operations that fail with transient errors.
+	Retry *RetryPolicy
 }
 
 // NewResourceOptions builds a preview of the effect of the provided options.
@@ -410,6 +429,7 @@ type resourceOptions struct {
 	PluginDownloadURL       string
 	RetainOnDelete          bool
 	DeletedWith             Resource
+	Retry                   *RetryPolicy
 }
 
 func resourceOptionsSnapshot(ro *resourceOptions) *ResourceOptions {
@@ -465,6 +485,7 @@ func resourceOptionsSnapshot(ro *resourceOptions) *ResourceOptions {
 		PluginDownloadURL:       ro.PluginDownloadURL,
 		RetainOnDelete:          ro.RetainOnDelete,
 		DeletedWith:             ro.DeletedWith,
+		Retry:                   ro.Retry,
 	}
 }
 
@@ -817,6 +838,13 @@ func Timeouts(o *CustomTimeouts) ResourceOption {
 	})
 }
 
+// Retry is an optional policy used to retry CRUD operations that fail with transient errors
+func Retry(o *RetryPolicy) ResourceOption {
+	return resourceOption(func(ro *resourceOptions) {
+		ro.Retry = o
+	})
+}
+
 // Transformations is an optional list of transformations to be applied to the resource.
 func Transformations(o []ResourceTransformation) ResourceOption {
 	return resourceOption(func(ro *resourceOptions) {
@@ -1103,6 +1103,13 @@ func TestNewResourceOptions(t *testing.T) {
 				CustomTimeouts: &CustomTimeouts{Create: "10s"},
 			},
 		},
+		{
+			desc: "Retry",
+			give: Retry(&RetryPolicy{MaxAttempts: 3}),
+			want: ResourceOptions{
+				Retry: &RetryPolicy{MaxAttempts: 3},
+			},
+		},
 		{
 			desc: "URN",
 			give: URN_("foo::bar"),